@@ -0,0 +1,80 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NotificationFailedReason is used for events recording failed deliveries of notifications.
+	NotificationFailedReason = "NotificationFailed"
+)
+
+// NotificationTargetSpec defines the desired state of NotificationTarget
+type NotificationTargetSpec struct {
+	// URL is the HTTP(S) endpoint receiving the JSON encoded condition transitions of UpgradePlans.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// SigningSecretRef references a key of a Secret within the namespace of the NotificationTarget
+	// holding the key used for signing the payloads with HMAC-SHA256.
+	// The signature is sent in the X-Upgrade-Controller-Signature header.
+	// +optional
+	SigningSecretRef *corev1.SecretKeySelector `json:"signingSecretRef,omitempty"`
+
+	// Conditions limits the notifications to transitions of the given condition types, e.g. OSUpgraded or Ready.
+	// Transitions of all conditions are sent if empty.
+	// +optional
+	Conditions []string `json:"conditions,omitempty"`
+
+	// Reasons limits the notifications to transitions to the given condition reasons, e.g. Failed or Stalled.
+	// Transitions to all reasons are sent if empty.
+	// +optional
+	Reasons []string `json:"reasons,omitempty"`
+
+	// UpgradePlanSelector limits the notifications to the UpgradePlans matching the selector.
+	// All UpgradePlans within the namespace of the NotificationTarget are covered if empty.
+	// +optional
+	UpgradePlanSelector *metav1.LabelSelector `json:"upgradePlanSelector,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// NotificationTarget is the Schema for the notificationtargets API
+type NotificationTarget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NotificationTargetSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NotificationTargetList contains a list of NotificationTarget
+type NotificationTargetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NotificationTarget `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NotificationTarget{}, &NotificationTargetList{})
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SyncedCondition indicates whether the ReleaseCatalog has been successfully synchronized with its source.
+	SyncedCondition = "Synced"
+
+	SyncSucceededReason = "SyncSucceeded"
+	SyncFailedReason    = "SyncFailed"
+
+	// UpgradeAvailableCondition indicates whether a newer release than the last successfully applied one is available.
+	UpgradeAvailableCondition = "UpgradeAvailable"
+
+	NewerReleaseAvailableReason = "NewerReleaseAvailable"
+	UpToDateReason              = "UpToDate"
+)
+
+// ReleaseCatalogSpec defines the desired state of ReleaseCatalog
+// +kubebuilder:validation:XValidation:rule="!(has(self.repository) && has(self.indexURL))",message="repository and indexURL are mutually exclusive"
+type ReleaseCatalogSpec struct {
+	// Repository is the OCI repository holding release manifest images whose tags represent release versions,
+	// for example "registry.opensuse.org/isv/suse/edge/lifecycle/containerfile/release-manifest".
+	// Defaults to the release manifest repository configured in the controller unless IndexURL is specified.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+	// IndexURL is the HTTP(S) location of a YAML or JSON index file listing the available release versions
+	// under a "versions" key.
+	// +optional
+	IndexURL string `json:"indexURL,omitempty"`
+	// Interval specifies how often the catalog source is synchronized. Defaults to 6h.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// ReleaseCatalogStatus defines the observed state of ReleaseCatalog
+type ReleaseCatalogStatus struct {
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// ObservedGeneration is the last generation of the ReleaseCatalog which has been synchronized.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AvailableVersions lists the release versions found in the catalog source in ascending order.
+	// +optional
+	AvailableVersions []string `json:"availableVersions,omitempty"`
+
+	// LatestVersion is the highest release version found in the catalog source.
+	// +optional
+	LatestVersion string `json:"latestVersion,omitempty"`
+
+	// LastSyncTime is the time of the last successful synchronization.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Latest",type="string",JSONPath=".status.latestVersion"
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
+
+// ReleaseCatalog is the Schema for the releasecatalogs API
+type ReleaseCatalog struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReleaseCatalogSpec   `json:"spec,omitempty"`
+	Status ReleaseCatalogStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReleaseCatalogList contains a list of ReleaseCatalog
+type ReleaseCatalogList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReleaseCatalog `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReleaseCatalog{}, &ReleaseCatalogList{})
+}
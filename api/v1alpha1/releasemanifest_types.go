@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strings"
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,6 +29,20 @@ const (
 	ArchTypeARM Arch = "aarch64"
 )
 
+const (
+	// ValidCondition indicates whether the ReleaseManifest is structurally valid.
+	ValidCondition = "Valid"
+
+	// VerifiedCondition indicates whether the ReleaseManifest is applicable to the cluster it is deployed on.
+	VerifiedCondition = "Verified"
+
+	ValidationSucceededReason      = "ValidationSucceeded"
+	InvalidReleaseManifestReason   = "InvalidReleaseManifest"
+	VerificationSucceededReason    = "VerificationSucceeded"
+	UnsupportedKubernetesReason    = "UnsupportedKubernetesDistribution"
+	ReleaseManifestImmutableReason = "ReleaseManifestImmutable"
+)
+
 // +kubebuilder:validation:Enum=x86_64;aarch64
 type Arch string
 
@@ -56,12 +71,37 @@ func SupportedArchitectures(architectures []Arch) map[string]struct{} {
 
 // ReleaseManifestSpec defines the desired state of ReleaseManifest
 type ReleaseManifestSpec struct {
-	ReleaseVersion string     `json:"releaseVersion"`
-	Components     Components `json:"components,omitempty"`
+	ReleaseVersion string `json:"releaseVersion"`
+	// MinimumUpgradeableFrom is the lowest installed release version which can be upgraded to this release, e.g. "3.1.0".
+	// +optional
+	MinimumUpgradeableFrom string `json:"minimumUpgradeableFrom,omitempty"`
+	// UpgradeableFrom is a version constraint which the installed release version must satisfy
+	// in order to be upgraded to this release, e.g. ">= 3.1.0, < 3.3.0" or "3.2.x".
+	// +optional
+	UpgradeableFrom string     `json:"upgradeableFrom,omitempty"`
+	Components      Components `json:"components,omitempty"`
 }
 
 // ReleaseManifestStatus defines the observed state of ReleaseManifest
 type ReleaseManifestStatus struct {
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// ObservedGeneration is the last generation of the ReleaseManifest which has been validated.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// UpgradePlans lists the UpgradePlans referencing this ReleaseManifest in "namespace/name" format.
+	// +optional
+	UpgradePlans []string `json:"upgradePlans,omitempty"`
+
+	// Immutable indicates that an upgrade has already been started using this ReleaseManifest.
+	// The spec of immutable ReleaseManifests can no longer be edited.
+	// +optional
+	Immutable bool `json:"immutable,omitempty"`
 }
 
 type Components struct {
@@ -74,6 +114,29 @@ type Workloads struct {
 	Helm []HelmChart `json:"helm"`
 }
 
+// ValuesMergeStrategy determines how additional values are combined with the installed values of a chart.
+// +kubebuilder:validation:Enum=Merge;DeepMerge;StrategicMerge;JSONPatch
+type ValuesMergeStrategy string
+
+const (
+	// ValuesMergeStrategyMerge recursively merges maps, replacing lists and any other values.
+	ValuesMergeStrategyMerge ValuesMergeStrategy = "Merge"
+
+	// ValuesMergeStrategyDeepMerge behaves like Merge, additionally removing keys whose value is null.
+	ValuesMergeStrategyDeepMerge ValuesMergeStrategy = "DeepMerge"
+
+	// ValuesMergeStrategyStrategicMerge behaves like DeepMerge, additionally merging lists of maps
+	// by the value of their merge key. List items containing "$patch: delete" are removed.
+	ValuesMergeStrategyStrategicMerge ValuesMergeStrategy = "StrategicMerge"
+
+	// ValuesMergeStrategyJSONPatch applies the values as a list of RFC 6902 JSON patch operations
+	// after all other values have been merged.
+	ValuesMergeStrategyJSONPatch ValuesMergeStrategy = "JSONPatch"
+
+	// DefaultValuesMergeKey is the key identifying list items merged by the StrategicMerge strategy.
+	DefaultValuesMergeKey = "name"
+)
+
 type HelmChart struct {
 	ReleaseName string                `json:"releaseName"`
 	Name        string                `json:"chart"`
@@ -81,6 +144,13 @@ type HelmChart struct {
 	Version     string                `json:"version"`
 	PrettyName  string                `json:"prettyName"`
 	Values      *apiextensionsv1.JSON `json:"values,omitempty"`
+	// MergeStrategy determines how the values are combined with the installed values of the chart.
+	// Defaults to Merge.
+	// +optional
+	MergeStrategy ValuesMergeStrategy `json:"mergeStrategy,omitempty"`
+	// MergeKey identifies the list items merged by the StrategicMerge strategy. Defaults to "name".
+	// +optional
+	MergeKey string `json:"mergeKey,omitempty"`
 
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +kubebuilder:validation:Schemaless
@@ -95,6 +165,18 @@ type Kubernetes struct {
 	RKE2 KubernetesDistribution `json:"rke2"`
 }
 
+// Distribution returns the distribution matching the given kubelet version or nil if it is not supported.
+func (k *Kubernetes) Distribution(kubeletVersion string) *KubernetesDistribution {
+	switch {
+	case strings.Contains(kubeletVersion, "k3s"):
+		return &k.K3S
+	case strings.Contains(kubeletVersion, "rke2"):
+		return &k.RKE2
+	default:
+		return nil
+	}
+}
+
 type KubernetesDistribution struct {
 	Version        string          `json:"version"`
 	CoreComponents []CoreComponent `json:"coreComponents,omitempty"`
@@ -0,0 +1,110 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-lifecycle-suse-com-v1alpha1-releasemanifest,mutating=false,failurePolicy=fail,sideEffects=None,groups=lifecycle.suse.com,resources=releasemanifests,verbs=create;update;delete,versions=v1alpha1,name=vreleasemanifest.kb.io,admissionReviewVersions=v1
+
+// DefaultCatalogAdminGroups are the groups allowed to manage release manifests in the catalog namespace
+// unless configured otherwise.
+var DefaultCatalogAdminGroups = []string{"system:masters", "lifecycle.suse.com:catalog-admins"}
+
+var _ webhook.CustomValidator = &ReleaseManifestValidator{}
+
+type ReleaseManifestValidator struct {
+	CatalogNamespace string
+	// CatalogAdminGroups are allowed to manage release manifests in the catalog namespace
+	// in addition to the service accounts of the catalog namespace.
+	CatalogAdminGroups []string
+}
+
+func (v *ReleaseManifestValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	manifest, ok := obj.(*ReleaseManifest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type: %T", obj)
+	}
+
+	return nil, v.validateCatalogAccess(ctx, manifest)
+}
+
+func (v *ReleaseManifestValidator) ValidateUpdate(ctx context.Context, old, new runtime.Object) (admission.Warnings, error) {
+	oldManifest, ok := old.(*ReleaseManifest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type: %T", old)
+	}
+
+	newManifest, ok := new.(*ReleaseManifest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type: %T", new)
+	}
+
+	if err := v.validateCatalogAccess(ctx, newManifest); err != nil {
+		return nil, err
+	}
+
+	if oldManifest.Status.Immutable && !equality.Semantic.DeepEqual(oldManifest.Spec, newManifest.Spec) {
+		return nil, fmt.Errorf("release manifest '%s' is in use by upgrade plans %v and cannot be edited",
+			newManifest.Name, oldManifest.Status.UpgradePlans)
+	}
+
+	return nil, nil
+}
+
+func (v *ReleaseManifestValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	manifest, ok := obj.(*ReleaseManifest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type: %T", obj)
+	}
+
+	return nil, v.validateCatalogAccess(ctx, manifest)
+}
+
+// validateCatalogAccess ensures that release manifests shared via the catalog namespace
+// can only be managed by privileged users, regardless of the RBAC granted to tenants.
+func (v *ReleaseManifestValidator) validateCatalogAccess(ctx context.Context, manifest *ReleaseManifest) error {
+	if v.CatalogNamespace == "" || manifest.Namespace != v.CatalogNamespace {
+		return nil
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieving admission request: %w", err)
+	}
+
+	privilegedGroups := append(slices.Clone(v.CatalogAdminGroups), fmt.Sprintf("system:serviceaccounts:%s", v.CatalogNamespace))
+
+	for _, group := range req.UserInfo.Groups {
+		if slices.Contains(privilegedGroups, group) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("user '%s' is not allowed to manage release manifests in catalog namespace '%s'",
+		req.UserInfo.Username, v.CatalogNamespace)
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/assert"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("ReleaseManifest Webhook", func() {
+	Context("When updating ReleaseManifest under Validating Webhook", Ordered, func() {
+		manifest := &ReleaseManifest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "release-manifest-3-1-0",
+				Namespace: "default",
+			},
+			Spec: ReleaseManifestSpec{
+				ReleaseVersion: "3.1.0",
+				Components: Components{
+					OperatingSystem: OperatingSystem{
+						SupportedArchs: []Arch{ArchTypeX86},
+					},
+				},
+			},
+		}
+
+		BeforeAll(func() {
+			By("Creating the release manifest")
+			Expect(k8sClient.Create(ctx, manifest)).To(Succeed())
+		})
+
+		It("Should pass if the release manifest is not in use", func() {
+			manifest.Spec.Components.OperatingSystem.Version = "6.0"
+			Expect(k8sClient.Update(ctx, manifest)).To(Succeed())
+		})
+
+		It("Should be denied if the release manifest is in use", func() {
+			manifest.Status.Immutable = true
+			manifest.Status.UpgradePlans = []string{"default/plan1"}
+			Expect(k8sClient.Status().Update(ctx, manifest)).To(Succeed())
+
+			manifest.Spec.Components.OperatingSystem.Version = "6.1"
+
+			err := k8sClient.Update(ctx, manifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("release manifest 'release-manifest-3-1-0' is in use by upgrade plans [default/plan1] and cannot be edited")))
+		})
+
+		It("Should pass metadata updates if the release manifest is in use", func() {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(manifest), manifest)).To(Succeed())
+
+			manifest.Labels = map[string]string{"foo": "bar"}
+			Expect(k8sClient.Update(ctx, manifest)).To(Succeed())
+		})
+	})
+})
+
+func TestReleaseManifestValidator_CatalogAccess(t *testing.T) {
+	validator := &ReleaseManifestValidator{
+		CatalogNamespace:   "release-catalog",
+		CatalogAdminGroups: []string{"system:masters", "release-admins"},
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		user        authenticationv1.UserInfo
+		expectedErr string
+	}{
+		{
+			name:      "Tenant namespace",
+			namespace: "tenant",
+			user:      authenticationv1.UserInfo{Username: "tenant-user", Groups: []string{"system:authenticated"}},
+		},
+		{
+			name:        "Catalog namespace by unprivileged user",
+			namespace:   "release-catalog",
+			user:        authenticationv1.UserInfo{Username: "tenant-user", Groups: []string{"system:authenticated"}},
+			expectedErr: "user 'tenant-user' is not allowed to manage release manifests in catalog namespace 'release-catalog'",
+		},
+		{
+			name:      "Catalog namespace by cluster administrator",
+			namespace: "release-catalog",
+			user:      authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}},
+		},
+		{
+			name:      "Catalog namespace by catalog administrator",
+			namespace: "release-catalog",
+			user:      authenticationv1.UserInfo{Username: "catalog-admin", Groups: []string{"release-admins"}},
+		},
+		{
+			name:        "Catalog namespace by member of a default group which is not configured",
+			namespace:   "release-catalog",
+			user:        authenticationv1.UserInfo{Username: "catalog-admin", Groups: []string{"lifecycle.suse.com:catalog-admins"}},
+			expectedErr: "user 'catalog-admin' is not allowed to manage release manifests in catalog namespace 'release-catalog'",
+		},
+		{
+			name:      "Catalog namespace by catalog service account",
+			namespace: "release-catalog",
+			user: authenticationv1.UserInfo{
+				Username: "system:serviceaccount:release-catalog:sync",
+				Groups:   []string{"system:serviceaccounts", "system:serviceaccounts:release-catalog"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest := &ReleaseManifest{ObjectMeta: metav1.ObjectMeta{Name: "release-3-1-0", Namespace: test.namespace}}
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{UserInfo: test.user},
+			})
+
+			_, createErr := validator.ValidateCreate(ctx, manifest)
+			_, updateErr := validator.ValidateUpdate(ctx, manifest, manifest)
+			_, deleteErr := validator.ValidateDelete(ctx, manifest)
+
+			for _, err := range []error{createErr, updateErr, deleteErr} {
+				if test.expectedErr == "" {
+					assert.NoError(t, err)
+				} else {
+					assert.EqualError(t, err, test.expectedErr)
+				}
+			}
+		})
+	}
+}
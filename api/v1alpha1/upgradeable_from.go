@@ -0,0 +1,46 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// ValidateUpgradeableFrom verifies that the given installed release version satisfies the upgrade
+// constraints of the release. Unknown installed versions and releases without constraints are not restricted.
+func (s *ReleaseManifestSpec) ValidateUpgradeableFrom(installedVersion string) error {
+	if installedVersion == "" {
+		return nil
+	}
+
+	installed, err := semver.NewVersion(installedVersion)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a semantic version", installedVersion)
+	}
+
+	if s.MinimumUpgradeableFrom != "" {
+		minimum, err := semver.NewVersion(s.MinimumUpgradeableFrom)
+		if err != nil {
+			return fmt.Errorf("minimum upgradeable from version '%s' is not a semantic version", s.MinimumUpgradeableFrom)
+		}
+
+		if installed.LessThan(minimum) {
+			return fmt.Errorf("release %s can only be upgraded from release %s or later, installed release is %s",
+				s.ReleaseVersion, s.MinimumUpgradeableFrom, installedVersion)
+		}
+	}
+
+	if s.UpgradeableFrom != "" {
+		constraint, err := semver.NewConstraint(s.UpgradeableFrom)
+		if err != nil {
+			return fmt.Errorf("upgradeable from constraint '%s' is not valid", s.UpgradeableFrom)
+		}
+
+		if !constraint.Check(installed) {
+			return fmt.Errorf("release %s can only be upgraded from releases matching '%s', installed release is %s",
+				s.ReleaseVersion, s.UpgradeableFrom, installedVersion)
+		}
+	}
+
+	return nil
+}
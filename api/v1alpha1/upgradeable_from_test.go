@@ -0,0 +1,129 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateUpgradeableFrom(t *testing.T) {
+	tests := []struct {
+		name             string
+		spec             ReleaseManifestSpec
+		installedVersion string
+		expectedErr      string
+	}{
+		{
+			name:             "No constraints",
+			spec:             ReleaseManifestSpec{ReleaseVersion: "3.2.0"},
+			installedVersion: "2.0.0",
+		},
+		{
+			name:             "Unknown installed version",
+			spec:             ReleaseManifestSpec{ReleaseVersion: "3.2.0", MinimumUpgradeableFrom: "3.1.0"},
+			installedVersion: "",
+		},
+		{
+			name:             "Minimum version satisfied",
+			spec:             ReleaseManifestSpec{ReleaseVersion: "3.2.0", MinimumUpgradeableFrom: "3.1.0"},
+			installedVersion: "3.1.0",
+		},
+		{
+			name:             "Minimum version not satisfied",
+			spec:             ReleaseManifestSpec{ReleaseVersion: "3.2.0", MinimumUpgradeableFrom: "3.1.0"},
+			installedVersion: "3.0.2",
+			expectedErr:      "release 3.2.0 can only be upgraded from release 3.1.0 or later, installed release is 3.0.2",
+		},
+		{
+			name:             "Constraint satisfied",
+			spec:             ReleaseManifestSpec{ReleaseVersion: "3.3.0", UpgradeableFrom: ">= 3.1.0, < 3.3.0"},
+			installedVersion: "3.2.1",
+		},
+		{
+			name:             "Constraint not satisfied",
+			spec:             ReleaseManifestSpec{ReleaseVersion: "3.3.0", UpgradeableFrom: "3.2.x"},
+			installedVersion: "3.1.1",
+			expectedErr:      "release 3.3.0 can only be upgraded from releases matching '3.2.x', installed release is 3.1.1",
+		},
+		{
+			name:             "Invalid constraint",
+			spec:             ReleaseManifestSpec{ReleaseVersion: "3.3.0", UpgradeableFrom: "latest"},
+			installedVersion: "3.1.1",
+			expectedErr:      "upgradeable from constraint 'latest' is not valid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.spec.ValidateUpgradeableFrom(test.installedVersion)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUpgradePlanValidator_UpgradeableFrom(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, AddToScheme(scheme))
+
+	manifest := &ReleaseManifest{
+		ObjectMeta: metav1.ObjectMeta{Name: "release-3-2-0", Namespace: "default"},
+		Spec:       ReleaseManifestSpec{ReleaseVersion: "3.2.0", MinimumUpgradeableFrom: "3.1.0"},
+	}
+
+	validator := &UpgradePlanValidator{
+		Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(manifest).Build(),
+	}
+
+	tests := []struct {
+		name                 string
+		lastReleaseVersion   string
+		intermediateReleases bool
+		expectedErr          string
+	}{
+		{
+			name:               "Supported installed release",
+			lastReleaseVersion: "3.1.0",
+		},
+		{
+			name:               "Unsupported installed release",
+			lastReleaseVersion: "3.0.2",
+			expectedErr:        "release 3.2.0 can only be upgraded from release 3.1.0 or later, installed release is 3.0.2",
+		},
+		{
+			name:                 "Intermediate releases",
+			lastReleaseVersion:   "3.0.2",
+			intermediateReleases: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oldPlan := &UpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{Name: "plan1", Namespace: "default"},
+				Spec:       UpgradePlanSpec{ReleaseVersion: test.lastReleaseVersion},
+				Status:     UpgradePlanStatus{LastSuccessfulReleaseVersion: test.lastReleaseVersion},
+			}
+			newPlan := oldPlan.DeepCopy()
+			newPlan.Spec.ReleaseVersion = "3.2.0"
+			newPlan.Spec.IntermediateReleases = test.intermediateReleases
+
+			_, err := validator.ValidateUpdate(context.Background(), oldPlan, newPlan)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package v1alpha1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// clearOverrides resets the settings which may be changed while an upgrade run is blocked
+// without starting a new upgrade run.
+func clearOverrides(spec *UpgradePlanSpec) {
+	spec.Preflight = nil
+	spec.DisableDrain = nil
+	spec.DrainBlockerPolicy = ""
+	spec.RemovedAPIPolicy = ""
+	spec.Timeouts = nil
+}
+
+// RunHash identifies the upgrade run performed for the spec. Specs which only differ
+// in the settings overriding a blocked upgrade run share the same hash.
+func (s *UpgradePlanSpec) RunHash() (string, error) {
+	spec := s.DeepCopy()
+	clearOverrides(spec)
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
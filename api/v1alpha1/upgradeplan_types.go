@@ -26,8 +26,22 @@ import (
 const (
 	UpgradePlanFinalizer = "upgradeplan.lifecycle.suse.com/finalizer"
 
-	ValidationFailedCondition     = "ValidationFailed"
-	UnsupportedArchitectureReason = "UnsupportedArchitecture"
+	ValidationFailedCondition       = "ValidationFailed"
+	UnsupportedArchitectureReason   = "UnsupportedArchitecture"
+	DuplicateReleaseManifestsReason = "DuplicateReleaseManifests"
+	ReleaseManifestMismatchReason   = "ReleaseManifestMismatch"
+	ReleaseManifestNotFoundReason   = "ReleaseManifestNotFound"
+	KubernetesVersionSkewReason     = "KubernetesVersionSkew"
+	UpgradePathNotFoundReason       = "UpgradePathNotFound"
+	UnsupportedUpgradeSourceReason  = "UnsupportedUpgradeSource"
+
+	// SkipVersionSkewCheckAnnotation disables the validation of the Kubernetes version skew when set to "true".
+	// Intended for emergencies only, as skipping minor versions or downgrading Kubernetes is not supported.
+	SkipVersionSkewCheckAnnotation = "lifecycle.suse.com/skip-version-skew-check"
+
+	// SpecUpdatedByAnnotation holds the name of the user which last created or changed the spec of an UpgradePlan.
+	// It is maintained by the mutating webhook and cannot be set by users.
+	SpecUpdatedByAnnotation = "lifecycle.suse.com/spec-updated-by"
 
 	OperatingSystemUpgradedCondition = "OSUpgraded"
 	KubernetesUpgradedCondition      = "KubernetesUpgraded"
@@ -49,6 +63,137 @@ const (
 
 	// UpgradeFailed indicates that the upgrade process has failed.
 	UpgradeFailed = "Failed"
+
+	// UpgradeStalled indicates that the upgrade process has exceeded its deadline.
+	UpgradeStalled = "Stalled"
+
+	// ReleaseVersionBumpedReason is used for events recording automatic upgrades initiated by a channel.
+	ReleaseVersionBumpedReason = "ReleaseVersionBumped"
+
+	// ReleaseVersionBumpRejectedReason is used for events recording automatic upgrades initiated by a channel
+	// which were rejected by the API server.
+	ReleaseVersionBumpRejectedReason = "ReleaseVersionBumpRejected"
+
+	// FailureLogsCollectedReason is used for events recording the collection of logs of failed upgrade jobs.
+	FailureLogsCollectedReason = "FailureLogsCollected"
+
+	// ChartValidationSkippedReason is used for events recording that a Helm chart could not be fetched for validation.
+	ChartValidationSkippedReason = "ChartValidationSkipped"
+
+	// IntermediateReleaseAppliedReason is used for events recording the completion of an intermediate release.
+	IntermediateReleaseAppliedReason = "IntermediateReleaseApplied"
+
+	// ReadyCondition summarizes the state of the whole upgrade.
+	ReadyCondition = "Ready"
+
+	// UpgradeProgressingReason indicates that the upgrade has not finished yet.
+	UpgradeProgressingReason = "UpgradeProgressing"
+
+	// UpgradeCompletedReason indicates that all components have been upgraded.
+	UpgradeCompletedReason = "UpgradeCompleted"
+
+	// ComponentUpgradeFailedReason indicates that the upgrade has finished, but some components failed to upgrade.
+	ComponentUpgradeFailedReason = "ComponentUpgradeFailed"
+
+	// PreflightChecksCondition summarizes the results of the checks performed before the OS upgrade starts.
+	// The result of each individual check is reported in the status.preflightChecks conditions.
+	PreflightChecksCondition = "PreflightChecks"
+
+	// PreflightChecksPassedReason indicates that all pre-flight checks have passed.
+	PreflightChecksPassedReason = "PreflightChecksPassed"
+
+	// PreflightChecksFailedReason indicates that one or more pre-flight checks have failed and the upgrade is blocked.
+	PreflightChecksFailedReason = "PreflightChecksFailed"
+
+	// PreflightChecksOverriddenReason indicates that one or more pre-flight checks have failed,
+	// but the upgrade proceeds as failures are ignored.
+	PreflightChecksOverriddenReason = "PreflightChecksOverridden"
+
+	// Reasons of the conditions reporting the individual pre-flight checks.
+	PreflightCheckPassedReason  = "CheckPassed"
+	PreflightCheckFailedReason  = "CheckFailed"
+	PreflightCheckSkippedReason = "CheckSkipped"
+	PreflightCheckErrorReason   = "CheckError"
+
+	// DrainBlockedCondition reports pods which would be disrupted or would block the eviction
+	// when draining the nodes targeted by the next SUC Plan.
+	DrainBlockedCondition = "DrainBlocked"
+
+	// DrainBlockersNotFoundReason indicates that all pods of the analyzed nodes can be evicted safely.
+	DrainBlockersNotFoundReason = "NoDrainBlockers"
+
+	// DrainBlockersReportedReason indicates that drain blockers were found, but the nodes are drained regardless.
+	DrainBlockersReportedReason = "DrainBlockersReported"
+
+	// DrainRefusedReason indicates that the upgrade is blocked until the drain blockers are resolved.
+	DrainRefusedReason = "DrainRefused"
+
+	// DrainDisabledReason indicates that drain blockers were found and the nodes are upgraded without draining.
+	DrainDisabledReason = "DrainDisabled"
+
+	// RemovedAPIsCondition reports objects using API versions which are removed by the target Kubernetes version.
+	RemovedAPIsCondition = "RemovedAPIs"
+
+	// RemovedAPIsNotFoundReason indicates that no objects use API versions removed by the target Kubernetes version.
+	RemovedAPIsNotFoundReason = "NoRemovedAPIs"
+
+	// RemovedAPIsReportedReason indicates that removed API versions are in use, but the Kubernetes upgrade proceeds regardless.
+	RemovedAPIsReportedReason = "RemovedAPIsReported"
+
+	// RemovedAPIsBlockedReason indicates that the Kubernetes upgrade is blocked until the removed API versions are no longer in use.
+	RemovedAPIsBlockedReason = "RemovedAPIsBlocked"
+)
+
+// DrainBlockerPolicy determines how the upgrade proceeds if pods would block or be disrupted by a drain.
+// +kubebuilder:validation:Enum=Report;Refuse;DisableRoleDrain
+type DrainBlockerPolicy string
+
+const (
+	// DrainBlockerPolicyReport drains the nodes regardless of the reported blockers.
+	DrainBlockerPolicyReport DrainBlockerPolicy = "Report"
+	// DrainBlockerPolicyRefuse blocks the upgrade until the blockers are resolved.
+	DrainBlockerPolicyRefuse DrainBlockerPolicy = "Refuse"
+	// DrainBlockerPolicyDisableRoleDrain upgrades all nodes of the role (control plane or worker) targeted by the affected
+	// SUC Plan without draining them, including the nodes without drain blockers. SUC Plans drain either all or none of
+	// their nodes, hence drain cannot be disabled for individual nodes.
+	DrainBlockerPolicyDisableRoleDrain DrainBlockerPolicy = "DisableRoleDrain"
+)
+
+// RemovedAPIPolicy determines how the Kubernetes upgrade proceeds if objects use API versions removed by the target version.
+// +kubebuilder:validation:Enum=Report;Block
+type RemovedAPIPolicy string
+
+const (
+	// RemovedAPIPolicyReport upgrades Kubernetes regardless of the reported usages.
+	RemovedAPIPolicyReport RemovedAPIPolicy = "Report"
+	// RemovedAPIPolicyBlock blocks the Kubernetes upgrade until the usages are resolved.
+	RemovedAPIPolicyBlock RemovedAPIPolicy = "Block"
+)
+
+// DrainBlockerReason describes why a pod blocks or is disrupted by a drain.
+// +kubebuilder:validation:Enum=PodDisruptionBudget;Unmanaged;LocalStorage
+type DrainBlockerReason string
+
+const (
+	// DrainBlockerPodDisruptionBudget indicates that the PodDisruptionBudget of the pod does not allow its eviction.
+	DrainBlockerPodDisruptionBudget DrainBlockerReason = "PodDisruptionBudget"
+	// DrainBlockerUnmanaged indicates that the pod has no controller and is not recreated after its deletion.
+	DrainBlockerUnmanaged DrainBlockerReason = "Unmanaged"
+	// DrainBlockerLocalStorage indicates that the pod uses emptyDir volumes whose data is deleted.
+	DrainBlockerLocalStorage DrainBlockerReason = "LocalStorage"
+)
+
+// UpgradePlanPhase is the overall phase of an upgrade.
+// +kubebuilder:validation:Enum=Pending;OS;Kubernetes;Workloads;Completed;Failed
+type UpgradePlanPhase string
+
+const (
+	UpgradePlanPhasePending    UpgradePlanPhase = "Pending"
+	UpgradePlanPhaseOS         UpgradePlanPhase = "OS"
+	UpgradePlanPhaseKubernetes UpgradePlanPhase = "Kubernetes"
+	UpgradePlanPhaseWorkloads  UpgradePlanPhase = "Workloads"
+	UpgradePlanPhaseCompleted  UpgradePlanPhase = "Completed"
+	UpgradePlanPhaseFailed     UpgradePlanPhase = "Failed"
 )
 
 // UpgradePlanSpec defines the desired state of UpgradePlan
@@ -56,6 +201,11 @@ type UpgradePlanSpec struct {
 	// ReleaseVersion specifies the target version for platform upgrade.
 	// The version format is X.Y.Z, for example "3.0.2".
 	ReleaseVersion string `json:"releaseVersion"`
+	// ReleaseManifestRef specifies the ReleaseManifest to use for the upgrade.
+	// The referenced manifest must have a releaseVersion matching the one of the plan.
+	// If not specified, the ReleaseManifest is looked up by releaseVersion.
+	// +optional
+	ReleaseManifestRef *ReleaseManifestReference `json:"releaseManifestRef,omitempty"`
 	// DisableDrain specifies whether control-plane and worker nodes drain should be disabled.
 	// +optional
 	DisableDrain *DisableDrain `json:"disableDrain"`
@@ -65,6 +215,85 @@ type UpgradePlanSpec struct {
 	// the respective charts have been upgraded to the next version.
 	// +optional
 	Helm []HelmValues `json:"helm"`
+	// Channel enables automatic upgrades to newer releases matching a version constraint.
+	// +optional
+	Channel *UpgradeChannel `json:"channel,omitempty"`
+	// Timeouts specifies deadlines for the OS and Kubernetes upgrades.
+	// +optional
+	Timeouts *UpgradeTimeouts `json:"timeouts,omitempty"`
+	// Preflight configures the checks verifying the health of the cluster before the OS upgrade starts.
+	// +optional
+	Preflight *PreflightChecks `json:"preflight,omitempty"`
+	// DrainBlockerPolicy determines how the upgrade proceeds if pods would block or be disrupted by draining
+	// the nodes targeted by a SUC Plan. Defaults to Report.
+	// +optional
+	DrainBlockerPolicy DrainBlockerPolicy `json:"drainBlockerPolicy,omitempty"`
+	// RemovedAPIPolicy determines how the Kubernetes upgrade proceeds if Helm releases or live objects
+	// use API versions removed by the target Kubernetes version. Defaults to Report.
+	// +optional
+	RemovedAPIPolicy RemovedAPIPolicy `json:"removedAPIPolicy,omitempty"`
+	// IntermediateReleases allows upgrading through the releases between the installed and the target one
+	// if the target release cannot be applied directly, e.g. because its Kubernetes version skips a minor version.
+	// +optional
+	IntermediateReleases bool `json:"intermediateReleases,omitempty"`
+}
+
+type PreflightChecks struct {
+	// Skip lists the names of the pre-flight checks which are not performed, e.g. DrainCapacitySufficient.
+	// +optional
+	Skip []string `json:"skip,omitempty"`
+	// IgnoreFailures starts the OS upgrade even if pre-flight checks fail.
+	// Failed checks are still reported in the status.
+	// +optional
+	IgnoreFailures bool `json:"ignoreFailures,omitempty"`
+}
+
+type UpgradeTimeouts struct {
+	// Stage is the maximum duration of the OS and Kubernetes upgrade stages each.
+	// +optional
+	Stage *metav1.Duration `json:"stage,omitempty"`
+	// Node is the maximum duration of the upgrade of a single node within a stage.
+	// +optional
+	Node *metav1.Duration `json:"node,omitempty"`
+	// HaltOnStall prevents the creation of further SUC Plans within a stage once it is stalled.
+	// +optional
+	HaltOnStall bool `json:"haltOnStall,omitempty"`
+}
+
+type UpgradeChannel struct {
+	// Version is the constraint newer releases must satisfy in order to be applied automatically,
+	// for example "3.1.x" for all patch releases of 3.1.
+	Version string `json:"version"`
+	// MaintenanceWindows restrict automatic upgrades to the specified time frames.
+	// Upgrades may start at any time if not specified.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Sunday;Monday;Tuesday;Wednesday;Thursday;Friday;Saturday
+type Weekday string
+
+type MaintenanceWindow struct {
+	// Days of the week the maintenance window applies to. Defaults to every day.
+	// +optional
+	Days []Weekday `json:"days,omitempty"`
+	// Start is the beginning of the maintenance window in "HH:MM" format.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// Duration is the length of the maintenance window.
+	Duration metav1.Duration `json:"duration"`
+	// TimeZone is the IANA time zone of the maintenance window. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type ReleaseManifestReference struct {
+	// Name is the name of the referenced ReleaseManifest.
+	Name string `json:"name"`
+	// Namespace is the namespace of the referenced ReleaseManifest.
+	// Defaults to the namespace of the UpgradePlan.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 type DisableDrain struct {
@@ -75,8 +304,46 @@ type DisableDrain struct {
 }
 
 type HelmValues struct {
-	Chart  string                `json:"chart"`
-	Values *apiextensionsv1.JSON `json:"values"`
+	Chart string `json:"chart"`
+	// +optional
+	Values *apiextensionsv1.JSON `json:"values,omitempty"`
+	// ValuesFrom references Secrets and ConfigMaps in the namespace of the UpgradePlan holding additional values.
+	// Values of ConfigMaps are merged in order before the inline values, while values of Secrets are passed
+	// to the HelmChart through a values Secret and take precedence over all other values, including the inline values
+	// and the results of JSON patches. The MergeStrategy does not apply to values of Secrets: they are merged with
+	// each other and with the other values by recursively merging maps and replacing any other values, including lists.
+	// +optional
+	ValuesFrom []HelmValuesReference `json:"valuesFrom,omitempty"`
+	// MergeStrategy determines how the values are combined with the installed and release values of the chart.
+	// Values using the JSONPatch strategy are applied after those of the release manifest. Defaults to Merge.
+	// +optional
+	MergeStrategy ValuesMergeStrategy `json:"mergeStrategy,omitempty"`
+	// MergeKey identifies the list items merged by the StrategicMerge strategy. Defaults to "name".
+	// +optional
+	MergeKey string `json:"mergeKey,omitempty"`
+}
+
+const (
+	SecretValuesReference    = "Secret"
+	ConfigMapValuesReference = "ConfigMap"
+
+	// DefaultValuesReferenceKey is the key holding the values of referenced Secrets and ConfigMaps unless specified.
+	DefaultValuesReferenceKey = "values.yaml"
+)
+
+// HelmValuesReference selects values stored in a Secret or ConfigMap in the namespace of the UpgradePlan.
+type HelmValuesReference struct {
+	// Kind of the referenced object.
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	Kind string `json:"kind"`
+	// Name of the referenced object.
+	Name string `json:"name"`
+	// Key of the referenced object holding the values in YAML format. Defaults to "values.yaml".
+	// +optional
+	Key string `json:"key,omitempty"`
+	// Optional ignores the reference if the object or the key do not exist.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
 }
 
 // UpgradePlanStatus defines the observed state of UpgradePlan
@@ -92,15 +359,311 @@ type UpgradePlanStatus struct {
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
 	// SUCNameSuffix is the suffix added to all resources created for SUC. Meant for internal use only.
-	// Changes for each new ObservedGeneration.
+	// Changes for each new upgrade run.
 	SUCNameSuffix string `json:"sucNameSuffix,omitempty"`
 
+	// RunSpecHash identifies the spec of the current upgrade run. Generations which only change the settings
+	// overriding a blocked upgrade continue the current run. Meant for internal use only.
+	// +optional
+	RunSpecHash string `json:"runSpecHash,omitempty"`
+
 	// LastSuccessfulReleaseVersion is the last release version that this UpgradePlan has successfully upgraded to.
 	LastSuccessfulReleaseVersion string `json:"lastSuccessfulReleaseVersion,omitempty"`
+
+	// Phase is the overall phase of the upgrade.
+	// +optional
+	Phase UpgradePlanPhase `json:"phase,omitempty"`
+
+	// Progress is the number of finished components out of all components of the upgrade, e.g. "3/7".
+	// +optional
+	Progress string `json:"progress,omitempty"`
+
+	// Stages holds the start and completion times of the upgrade stages.
+	// Reset for each new upgrade run.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Stages []UpgradeStageStatus `json:"stages,omitempty"`
+
+	// Nodes tracks the upgrade progress of the individual cluster nodes.
+	// Reset for each new upgrade run.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Nodes []NodeUpgradeStatus `json:"nodes,omitempty"`
+
+	// FailureLogsConfigMap is the name of the ConfigMap within the namespace of the UpgradePlan
+	// holding the log tails of the pods of failed upgrade jobs.
+	// +optional
+	FailureLogsConfigMap string `json:"failureLogsConfigMap,omitempty"`
+
+	// FailureLogs lists the failed upgrade jobs whose logs are stored in the FailureLogsConfigMap.
+	// The oldest entries are evicted once the ConfigMap exceeds its size limit.
+	// +listType=map
+	// +listMapKey=key
+	// +optional
+	FailureLogs []FailedJobLogs `json:"failureLogs,omitempty"`
+
+	// ValuesDiffConfigMap is the name of the ConfigMap within the namespace of the UpgradePlan
+	// holding the differences between the previous and the new values of the upgraded Helm charts.
+	// +optional
+	ValuesDiffConfigMap string `json:"valuesDiffConfigMap,omitempty"`
+
+	// ValuesDiffs lists the Helm chart upgrades whose values diff is stored in the ValuesDiffConfigMap.
+	// The oldest entries are evicted once the number of entries exceeds its limit.
+	// +listType=map
+	// +listMapKey=key
+	// +optional
+	ValuesDiffs []HelmValuesDiff `json:"valuesDiffs,omitempty"`
+
+	// ReportConfigMap is the name of the ConfigMap within the namespace of the UpgradePlan holding the report
+	// of the last completed upgrade in JSON (report.json) and Markdown (report.md) formats.
+	// +optional
+	ReportConfigMap string `json:"reportConfigMap,omitempty"`
+
+	// History holds the most recent upgrade runs of the UpgradePlan, starting with the latest one.
+	// +optional
+	History []UpgradeRun `json:"history,omitempty"`
+
+	// PreflightChecks holds the result of each check performed before the OS upgrade of the current generation.
+	// Summarized in the PreflightChecks condition.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	PreflightChecks []metav1.Condition `json:"preflightChecks,omitempty"`
+
+	// DrainBlockers lists the pods which would block or be disrupted by draining the nodes
+	// targeted by the SUC Plans of the current generation. Summarized in the DrainBlocked condition.
+	// +optional
+	DrainBlockers []DrainBlocker `json:"drainBlockers,omitempty"`
+
+	// RemovedAPIs lists the objects using API versions which are removed by the target Kubernetes version.
+	// Summarized in the RemovedAPIs condition.
+	// +optional
+	RemovedAPIs []RemovedAPIUsage `json:"removedAPIs,omitempty"`
+
+	// UpgradePath lists the releases applied in sequence in order to reach the target release version.
+	// Only set if the target release is reached via intermediate releases.
+	// +optional
+	UpgradePath *UpgradePath `json:"upgradePath,omitempty"`
+}
+
+type RemovedAPIUsage struct {
+	// APIVersion is the removed API version, e.g. "policy/v1beta1".
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the object.
+	Kind string `json:"kind"`
+	// Object is the name of the object in "namespace/name" format, or "name" for cluster-scoped objects.
+	Object string `json:"object"`
+	// HelmRelease is the name of the Helm release whose manifest contains the object.
+	// Not set for live objects whose last applied configuration or managed fields use the removed API version.
+	// +optional
+	HelmRelease string `json:"helmRelease,omitempty"`
+	// RemovedIn is the Kubernetes minor version which removes the API version, e.g. "v1.25".
+	RemovedIn string `json:"removedIn"`
+	// Replacement is the API version to migrate to.
+	// +optional
+	Replacement string `json:"replacement,omitempty"`
+}
+
+type UpgradePath struct {
+	// Releases are the release versions applied in sequence, ending with the target release version.
+	Releases []string `json:"releases"`
+	// Current is the release version which is currently being applied.
+	Current string `json:"current"`
+}
+
+type DrainBlocker struct {
+	// Node is the name of the node running the pod.
+	Node string `json:"node"`
+	// Pod is the namespaced name of the pod in "namespace/name" format.
+	Pod string `json:"pod"`
+	// Reason describes why the pod blocks or is disrupted by the drain.
+	Reason DrainBlockerReason `json:"reason"`
+	// Message is a human-readable description of the blocker.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// UpgradeRunResult is the outcome of an upgrade run.
+// +kubebuilder:validation:Enum=Succeeded;Failed;Superseded
+type UpgradeRunResult string
+
+const (
+	UpgradeRunSucceeded UpgradeRunResult = "Succeeded"
+	UpgradeRunFailed    UpgradeRunResult = "Failed"
+	// UpgradeRunSuperseded indicates that the UpgradePlan was edited before the run finished.
+	UpgradeRunSuperseded UpgradeRunResult = "Superseded"
+)
+
+// UpgradeRun describes a single upgrade performed for a generation of an UpgradePlan.
+type UpgradeRun struct {
+	// Generation is the generation of the UpgradePlan the run was performed for.
+	Generation int64 `json:"generation"`
+
+	// FromReleaseVersion is the last successfully applied release version when the run started.
+	// +optional
+	FromReleaseVersion string `json:"fromReleaseVersion,omitempty"`
+
+	// ToReleaseVersion is the targeted release version.
+	ToReleaseVersion string `json:"toReleaseVersion"`
+
+	// TriggeredBy is the user which last updated the spec of the UpgradePlan before the run started.
+	// Plans updated before the user was recorded report the field manager of the update instead.
+	// +optional
+	TriggeredBy string `json:"triggeredBy,omitempty"`
+
+	// StartTime is the time when the run started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is the time when the run finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Result is the outcome of the run. Empty while the run is ongoing.
+	// +optional
+	Result UpgradeRunResult `json:"result,omitempty"`
+
+	// Components holds the result of each upgraded component once the run has finished.
+	// +optional
+	Components []ComponentResult `json:"components,omitempty"`
+}
+
+// ComponentResult describes the outcome of the upgrade of a single component.
+type ComponentResult struct {
+	// Name is the type of the condition tracking the component, e.g. OSUpgraded.
+	Name string `json:"name"`
+
+	// Result is the reason of the condition tracking the component, e.g. Succeeded or Skipped.
+	Result string `json:"result"`
+
+	// Message is the message of the condition tracking the component.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// HelmValuesDiff references the difference between the previous and the new values of an upgraded Helm chart.
+type HelmValuesDiff struct {
+	// Key is the key of the ValuesDiffConfigMap holding the diff.
+	Key string `json:"key"`
+
+	// Chart is the release name of the upgraded Helm chart.
+	Chart string `json:"chart"`
+
+	// ReleaseVersion is the release version the Helm chart is upgraded to.
+	ReleaseVersion string `json:"releaseVersion"`
+
+	// Added is the number of added values.
+	Added int `json:"added"`
+
+	// Removed is the number of removed values.
+	Removed int `json:"removed"`
+
+	// Changed is the number of changed values.
+	Changed int `json:"changed"`
+
+	// RecordTime is the time when the diff was recorded.
+	RecordTime metav1.Time `json:"recordTime"`
+}
+
+// FailedJobLogs references the log tail of a failed upgrade job.
+type FailedJobLogs struct {
+	// Key is the key of the FailureLogsConfigMap holding the log tail.
+	Key string `json:"key"`
+
+	// Job is the namespaced name of the failed job.
+	Job string `json:"job"`
+
+	// Component is the upgrade component the job belongs to, e.g. OS, Kubernetes or the name of a Helm chart.
+	Component string `json:"component"`
+
+	// CollectionTime is the time when the logs were collected.
+	CollectionTime metav1.Time `json:"collectionTime"`
+}
+
+// UpgradeStageStatus describes the timing of a single upgrade stage.
+type UpgradeStageStatus struct {
+	// Name is the name of the stage, one of OS, Kubernetes or Workloads.
+	Name UpgradePlanPhase `json:"name"`
+
+	// StartTime is the time when the stage started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time when the stage finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// NodeRole is the role of a node within the cluster.
+// +kubebuilder:validation:Enum=ControlPlane;Worker
+type NodeRole string
+
+const (
+	NodeRoleControlPlane NodeRole = "ControlPlane"
+	NodeRoleWorker       NodeRole = "Worker"
+)
+
+// NodePhase is the upgrade phase of a node within the current upgrade stage.
+// +kubebuilder:validation:Enum=Pending;Cordoned;Draining;Upgrading;Rebooting;Done;Failed
+type NodePhase string
+
+const (
+	NodePhasePending   NodePhase = "Pending"
+	NodePhaseCordoned  NodePhase = "Cordoned"
+	NodePhaseDraining  NodePhase = "Draining"
+	NodePhaseUpgrading NodePhase = "Upgrading"
+	NodePhaseRebooting NodePhase = "Rebooting"
+	NodePhaseDone      NodePhase = "Done"
+	NodePhaseFailed    NodePhase = "Failed"
+)
+
+// NodeUpgradeStatus describes the upgrade progress of a single node.
+type NodeUpgradeStatus struct {
+	// Name is the name of the node.
+	Name string `json:"name"`
+
+	// Role is the role of the node within the cluster.
+	Role NodeRole `json:"role"`
+
+	// Phase is the upgrade phase of the node within the current upgrade stage (OS or Kubernetes).
+	// Nodes return to Pending once the next stage begins.
+	Phase NodePhase `json:"phase"`
+
+	// InitialOSImage is the OS image of the node before the upgrade.
+	// +optional
+	InitialOSImage string `json:"initialOSImage,omitempty"`
+
+	// OSImage is the current OS image of the node.
+	// +optional
+	OSImage string `json:"osImage,omitempty"`
+
+	// InitialKubeletVersion is the kubelet version of the node before the upgrade.
+	// +optional
+	InitialKubeletVersion string `json:"initialKubeletVersion,omitempty"`
+
+	// KubeletVersion is the current kubelet version of the node.
+	// +optional
+	KubeletVersion string `json:"kubeletVersion,omitempty"`
+
+	// StartTime is the time when the node started being upgraded within the current stage.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time when the upgrade of the node finished within the current stage.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// LastTransitionTime is the last time the phase of the node changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Release",type="string",JSONPath=".spec.releaseVersion"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Progress",type="string",JSONPath=".status.progress"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // UpgradePlan is the Schema for the upgradeplans API
 type UpgradePlan struct {
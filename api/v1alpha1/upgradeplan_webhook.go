@@ -18,42 +18,134 @@ package v1alpha1
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"slices"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/version"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
-func SetupWebhookWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewWebhookManagedBy(mgr).
-		WithValidator(&UpgradePlanValidator{}).
+// SetupWebhookWithManager registers the webhooks with the Manager.
+// The catalogNamespace holds ReleaseManifests shared across all namespaces; it is ignored if empty.
+// The catalogAdminGroups are allowed to manage the ReleaseManifests in the catalogNamespace.
+func SetupWebhookWithManager(mgr ctrl.Manager, catalogNamespace string, catalogAdminGroups []string) error {
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		WithDefaulter(&UpgradePlanDefaulter{}).
+		WithValidator(&UpgradePlanValidator{Reader: mgr.GetAPIReader(), CatalogNamespace: catalogNamespace}).
 		For(&UpgradePlan{}).
+		Complete(); err != nil {
+		return err
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		WithValidator(&ReleaseManifestValidator{CatalogNamespace: catalogNamespace, CatalogAdminGroups: catalogAdminGroups}).
+		For(&ReleaseManifest{}).
 		Complete()
 }
 
+// +kubebuilder:webhook:path=/mutate-lifecycle-suse-com-v1alpha1-upgradeplan,mutating=true,failurePolicy=fail,sideEffects=None,groups=lifecycle.suse.com,resources=upgradeplans,verbs=create;update,versions=v1alpha1,name=mupgradeplan.kb.io,admissionReviewVersions=v1
+
+var _ webhook.CustomDefaulter = &UpgradePlanDefaulter{}
+
+// UpgradePlanDefaulter records the user which last created or changed the spec of an UpgradePlan.
+// +kubebuilder:object:generate=false
+type UpgradePlanDefaulter struct{}
+
+func (d *UpgradePlanDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	plan, ok := obj.(*UpgradePlan)
+	if !ok {
+		return fmt.Errorf("unexpected object type: %T", obj)
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieving admission request: %w", err)
+	}
+
+	updatedBy := req.UserInfo.Username
+
+	if req.Operation == admissionv1.Update {
+		oldPlan := &UpgradePlan{}
+		if err = json.Unmarshal(req.OldObject.Raw, oldPlan); err != nil {
+			return fmt.Errorf("decoding previous upgrade plan: %w", err)
+		}
+
+		// Retain the recorded user unless the spec changes.
+		if equality.Semantic.DeepEqual(oldPlan.Spec, plan.Spec) {
+			updatedBy = oldPlan.Annotations[SpecUpdatedByAnnotation]
+		}
+	}
+
+	if updatedBy == "" {
+		delete(plan.Annotations, SpecUpdatedByAnnotation)
+		return nil
+	}
+
+	if plan.Annotations == nil {
+		plan.Annotations = map[string]string{}
+	}
+	plan.Annotations[SpecUpdatedByAnnotation] = updatedBy
+
+	return nil
+}
+
 // NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
 // Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
 // +kubebuilder:webhook:path=/validate-lifecycle-suse-com-v1alpha1-upgradeplan,mutating=false,failurePolicy=fail,sideEffects=None,groups=lifecycle.suse.com,resources=upgradeplans,verbs=create;update,versions=v1alpha1,name=vupgradeplan.kb.io,admissionReviewVersions=v1
 
 var _ webhook.CustomValidator = &UpgradePlanValidator{}
 
-type UpgradePlanValidator struct{}
+// +kubebuilder:object:generate=false
+type UpgradePlanValidator struct {
+	// Reader looks up the nodes and the target ReleaseManifest when validating the Kubernetes version skew.
+	// The validation is skipped if nil.
+	Reader           client.Reader
+	CatalogNamespace string
+}
 
-func (*UpgradePlanValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+func (v *UpgradePlanValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	upgradePlan, ok := obj.(*UpgradePlan)
 	if !ok {
 		return nil, fmt.Errorf("unexpected object type: %T", obj)
 	}
 
-	_, err := validateReleaseVersion(upgradePlan.Spec.ReleaseVersion)
-	return nil, err
+	if _, err := ValidateReleaseVersion(upgradePlan.Spec.ReleaseVersion); err != nil {
+		return nil, err
+	}
+
+	if err := validateChannel(upgradePlan.Spec.Channel); err != nil {
+		return nil, err
+	}
+
+	if err := validateTimeouts(upgradePlan.Spec.Timeouts); err != nil {
+		return nil, err
+	}
+
+	if err := validateHelmValues(upgradePlan.Spec.Helm); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateReleaseManifestRef(upgradePlan); err != nil {
+		return nil, err
+	}
+
+	return nil, v.validateVersionSkew(ctx, upgradePlan)
 }
 
-func (*UpgradePlanValidator) ValidateUpdate(ctx context.Context, old, new runtime.Object) (admission.Warnings, error) {
+func (v *UpgradePlanValidator) ValidateUpdate(ctx context.Context, old, new runtime.Object) (admission.Warnings, error) {
 	oldPlan, ok := old.(*UpgradePlan)
 	if !ok {
 		return nil, fmt.Errorf("unexpected object type: %T", old)
@@ -70,6 +162,15 @@ func (*UpgradePlanValidator) ValidateUpdate(ctx context.Context, old, new runtim
 		return nil, nil
 	}
 
+	if isStallOverride(oldPlan, newPlan) {
+		return nil, validateTimeouts(newPlan.Spec.Timeouts)
+	}
+
+	if isPreflightOverride(oldPlan, newPlan) || isDrainOverride(oldPlan, newPlan) || isRemovedAPIOverride(oldPlan, newPlan) ||
+		isVersionSkewOverride(oldPlan, newPlan) {
+		return nil, nil
+	}
+
 	disallowingUpdateStates := []string{UpgradeInProgress, UpgradePending, UpgradeError}
 
 	for _, condition := range newPlan.Status.Conditions {
@@ -78,11 +179,27 @@ func (*UpgradePlanValidator) ValidateUpdate(ctx context.Context, old, new runtim
 		}
 	}
 
-	newReleaseVersion, err := validateReleaseVersion(newPlan.Spec.ReleaseVersion)
+	newReleaseVersion, err := ValidateReleaseVersion(newPlan.Spec.ReleaseVersion)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = v.validateReleaseManifestRef(newPlan); err != nil {
+		return nil, err
+	}
+
+	if err = validateChannel(newPlan.Spec.Channel); err != nil {
+		return nil, err
+	}
+
+	if err = validateTimeouts(newPlan.Spec.Timeouts); err != nil {
+		return nil, err
+	}
+
+	if err = validateHelmValues(newPlan.Spec.Helm); err != nil {
+		return nil, err
+	}
+
 	if oldPlan.Status.LastSuccessfulReleaseVersion != "" {
 		indicator, err := newReleaseVersion.Compare(oldPlan.Status.LastSuccessfulReleaseVersion)
 		if err != nil {
@@ -97,14 +214,262 @@ func (*UpgradePlanValidator) ValidateUpdate(ctx context.Context, old, new runtim
 		}
 	}
 
-	return nil, nil
+	if err = v.validateUpgradeableFrom(ctx, newPlan, oldPlan.Status.LastSuccessfulReleaseVersion); err != nil {
+		return nil, err
+	}
+
+	return nil, v.validateVersionSkew(ctx, newPlan)
 }
 
 func (*UpgradePlanValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
 	return nil, nil
 }
 
-func validateReleaseVersion(releaseVersion string) (*version.Version, error) {
+// isPreflightOverride reports whether the update only changes the pre-flight check settings
+// of a plan whose upgrade is blocked by failed pre-flight checks.
+func isPreflightOverride(oldPlan, newPlan *UpgradePlan) bool {
+	if !meta.IsStatusConditionPresentAndEqual(newPlan.Status.Conditions, PreflightChecksCondition, metav1.ConditionFalse) {
+		return false
+	}
+
+	return isSpecEqualExcept(oldPlan, newPlan, func(spec *UpgradePlanSpec) {
+		spec.Preflight = nil
+	})
+}
+
+// isDrainOverride reports whether the update only changes the drain settings
+// of a plan whose upgrade is blocked by drain blockers.
+func isDrainOverride(oldPlan, newPlan *UpgradePlan) bool {
+	condition := meta.FindStatusCondition(newPlan.Status.Conditions, DrainBlockedCondition)
+	if condition == nil || condition.Reason != DrainRefusedReason {
+		return false
+	}
+
+	return isSpecEqualExcept(oldPlan, newPlan, func(spec *UpgradePlanSpec) {
+		spec.DisableDrain = nil
+		spec.DrainBlockerPolicy = ""
+	})
+}
+
+// isRemovedAPIOverride reports whether the update only changes the removed API policy
+// of a plan whose Kubernetes upgrade is blocked by usages of removed API versions.
+func isRemovedAPIOverride(oldPlan, newPlan *UpgradePlan) bool {
+	condition := meta.FindStatusCondition(newPlan.Status.Conditions, RemovedAPIsCondition)
+	if condition == nil || condition.Reason != RemovedAPIsBlockedReason {
+		return false
+	}
+
+	return isSpecEqualExcept(oldPlan, newPlan, func(spec *UpgradePlanSpec) {
+		spec.RemovedAPIPolicy = ""
+	})
+}
+
+// isStallOverride reports whether the update only changes the timeouts of a plan whose OS or Kubernetes upgrade is stalled.
+func isStallOverride(oldPlan, newPlan *UpgradePlan) bool {
+	stalled := slices.ContainsFunc(newPlan.Status.Conditions, func(condition metav1.Condition) bool {
+		return (condition.Type == OperatingSystemUpgradedCondition || condition.Type == KubernetesUpgradedCondition) &&
+			condition.Reason == UpgradeStalled
+	})
+	if !stalled {
+		return false
+	}
+
+	return isSpecEqualExcept(oldPlan, newPlan, func(spec *UpgradePlanSpec) {
+		spec.Timeouts = nil
+	})
+}
+
+// isVersionSkewOverride reports whether the update only adds the annotation skipping the Kubernetes version skew check.
+func isVersionSkewOverride(oldPlan, newPlan *UpgradePlan) bool {
+	if oldPlan.Annotations[SkipVersionSkewCheckAnnotation] == "true" || newPlan.Annotations[SkipVersionSkewCheckAnnotation] != "true" {
+		return false
+	}
+
+	return equality.Semantic.DeepEqual(oldPlan.Spec, newPlan.Spec)
+}
+
+// isSpecEqualExcept reports whether the specs of both plans are equal once the ignored fields are cleared.
+func isSpecEqualExcept(oldPlan, newPlan *UpgradePlan, ignore func(spec *UpgradePlanSpec)) bool {
+	oldSpec := oldPlan.Spec.DeepCopy()
+	ignore(oldSpec)
+	newSpec := newPlan.Spec.DeepCopy()
+	ignore(newSpec)
+
+	return equality.Semantic.DeepEqual(oldSpec, newSpec)
+}
+
+func (v *UpgradePlanValidator) validateReleaseManifestRef(plan *UpgradePlan) error {
+	ref := plan.Spec.ReleaseManifestRef
+	if ref == nil || ref.Namespace == "" || ref.Namespace == plan.Namespace {
+		return nil
+	}
+
+	if v.CatalogNamespace == "" || ref.Namespace != v.CatalogNamespace {
+		return fmt.Errorf("release manifest can only be referenced from the '%s' namespace or the catalog namespace", plan.Namespace)
+	}
+
+	return nil
+}
+
+// validateUpgradeableFrom verifies that the target release of the plan can be upgraded from the installed release.
+// Plans allowing intermediate releases are validated by the controller while computing the upgrade path.
+func (v *UpgradePlanValidator) validateUpgradeableFrom(ctx context.Context, plan *UpgradePlan, installedVersion string) error {
+	if v.Reader == nil || installedVersion == "" || plan.Spec.IntermediateReleases {
+		return nil
+	}
+
+	manifest, err := v.findReleaseManifest(ctx, plan)
+	if err != nil {
+		return fmt.Errorf("looking up release manifest: %w", err)
+	} else if manifest == nil {
+		return nil
+	}
+
+	return manifest.Spec.ValidateUpgradeableFrom(installedVersion)
+}
+
+// validateVersionSkew verifies that the Kubernetes version of the target release can be applied to all nodes.
+// Plans whose release manifest is not available yet are validated by the controller once it is retrieved.
+func (v *UpgradePlanValidator) validateVersionSkew(ctx context.Context, plan *UpgradePlan) error {
+	// Plans allowing intermediate releases are upgraded via a path which does not skip minor versions.
+	if v.Reader == nil || plan.Annotations[SkipVersionSkewCheckAnnotation] == "true" || plan.Spec.IntermediateReleases {
+		return nil
+	}
+
+	manifest, err := v.findReleaseManifest(ctx, plan)
+	if err != nil {
+		return fmt.Errorf("looking up release manifest: %w", err)
+	} else if manifest == nil {
+		return nil
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err = v.Reader.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	} else if len(nodeList.Items) == 0 {
+		return nil
+	}
+
+	distribution := manifest.Spec.Components.Kubernetes.Distribution(nodeList.Items[0].Status.NodeInfo.KubeletVersion)
+	if distribution == nil {
+		return nil
+	}
+
+	if err = ValidateKubernetesVersionSkew(nodeList.Items, distribution.Version); err != nil {
+		return fmt.Errorf("%w; set the '%s' annotation to 'true' in order to skip this check", err, SkipVersionSkewCheckAnnotation)
+	}
+
+	return nil
+}
+
+// findReleaseManifest looks up the release manifest targeted by the given plan.
+// Returns nil if the manifest does not exist or is ambiguous.
+func (v *UpgradePlanValidator) findReleaseManifest(ctx context.Context, plan *UpgradePlan) (*ReleaseManifest, error) {
+	if ref := plan.Spec.ReleaseManifestRef; ref != nil {
+		key := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+		if key.Namespace == "" {
+			key.Namespace = plan.Namespace
+		}
+
+		manifest := &ReleaseManifest{}
+		if err := v.Reader.Get(ctx, key, manifest); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+
+		return manifest, nil
+	}
+
+	namespaces := []string{plan.Namespace}
+	if v.CatalogNamespace != "" && v.CatalogNamespace != plan.Namespace {
+		namespaces = append(namespaces, v.CatalogNamespace)
+	}
+
+	for _, namespace := range namespaces {
+		manifests := &ReleaseManifestList{}
+		if err := v.Reader.List(ctx, manifests, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+
+		var matching []ReleaseManifest
+		for _, manifest := range manifests.Items {
+			if manifest.Spec.ReleaseVersion == plan.Spec.ReleaseVersion {
+				matching = append(matching, manifest)
+			}
+		}
+
+		switch len(matching) {
+		case 0:
+			continue
+		case 1:
+			return &matching[0], nil
+		default:
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func validateChannel(channel *UpgradeChannel) error {
+	if channel == nil {
+		return nil
+	}
+
+	if _, err := semver.NewConstraint(channel.Version); err != nil {
+		return fmt.Errorf("channel version '%s' is not a valid constraint: %w", channel.Version, err)
+	}
+
+	for _, window := range channel.MaintenanceWindows {
+		if _, err := time.LoadLocation(window.TimeZone); err != nil {
+			return fmt.Errorf("maintenance window time zone '%s' is invalid: %w", window.TimeZone, err)
+		}
+
+		if window.Duration.Duration <= 0 || window.Duration.Duration > 7*24*time.Hour {
+			return fmt.Errorf("maintenance window duration must be positive and not exceed a week")
+		}
+	}
+
+	return nil
+}
+
+func validateTimeouts(timeouts *UpgradeTimeouts) error {
+	if timeouts == nil {
+		return nil
+	}
+
+	if timeouts.Stage != nil && timeouts.Stage.Duration <= 0 {
+		return fmt.Errorf("stage timeout must be positive")
+	}
+
+	if timeouts.Node != nil && timeouts.Node.Duration <= 0 {
+		return fmt.Errorf("node timeout must be positive")
+	}
+
+	return nil
+}
+
+func validateHelmValues(helmValues []HelmValues) error {
+	for _, h := range helmValues {
+		if err := ValidateValuesMerge(h.Values, h.MergeStrategy, h.MergeKey); err != nil {
+			return fmt.Errorf("invalid values for chart '%s': %w", h.Chart, err)
+		}
+
+		for i, ref := range h.ValuesFrom {
+			if ref.Kind != SecretValuesReference && ref.Kind != ConfigMapValuesReference {
+				return fmt.Errorf("values reference %d of chart '%s' has unsupported kind '%s'", i, h.Chart, ref.Kind)
+			}
+
+			if ref.Name == "" {
+				return fmt.Errorf("values reference %d of chart '%s' does not specify a name", i, h.Chart)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateReleaseVersion parses the given release version, ensuring that it is in semantic format.
+func ValidateReleaseVersion(releaseVersion string) (*version.Version, error) {
 	if releaseVersion == "" {
 		return nil, fmt.Errorf("release version is required")
 	}
@@ -17,9 +17,12 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -54,6 +57,98 @@ var _ = Describe("UpgradePlan Webhook", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(ContainSubstring("'v1' is not a semantic version")))
 		})
+
+		It("Should be denied if the referenced release manifest is in a foreign namespace", func() {
+			plan := &UpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "plan1",
+					Namespace: "default",
+				},
+				Spec: UpgradePlanSpec{
+					ReleaseVersion:     "3.1.0",
+					ReleaseManifestRef: &ReleaseManifestReference{Name: "release-manifest", Namespace: "tenant"},
+				},
+			}
+
+			err := k8sClient.Create(ctx, plan)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("release manifest can only be referenced from the 'default' namespace or the catalog namespace")))
+		})
+
+		It("Should be denied if the channel version is not a valid constraint", func() {
+			plan := &UpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "plan1",
+					Namespace: "default",
+				},
+				Spec: UpgradePlanSpec{
+					ReleaseVersion: "3.1.0",
+					Channel:        &UpgradeChannel{Version: "latest"},
+				},
+			}
+
+			err := k8sClient.Create(ctx, plan)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("channel version 'latest' is not a valid constraint")))
+		})
+
+		It("Should be denied if the node timeout is not positive", func() {
+			plan := &UpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "plan1",
+					Namespace: "default",
+				},
+				Spec: UpgradePlanSpec{
+					ReleaseVersion: "3.1.0",
+					Timeouts:       &UpgradeTimeouts{Node: &metav1.Duration{Duration: -time.Minute}},
+				},
+			}
+
+			err := k8sClient.Create(ctx, plan)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("node timeout must be positive")))
+		})
+
+		It("Should be denied if the values of a chart do not match its merge strategy", func() {
+			plan := &UpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "plan1",
+					Namespace: "default",
+				},
+				Spec: UpgradePlanSpec{
+					ReleaseVersion: "3.1.0",
+					Helm: []HelmValues{{
+						Chart:         "rancher",
+						Values:        &apiextensionsv1.JSON{Raw: []byte(`{"replicas": 2}`)},
+						MergeStrategy: ValuesMergeStrategyJSONPatch,
+					}},
+				},
+			}
+
+			err := k8sClient.Create(ctx, plan)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("invalid values for chart 'rancher': values are not a valid JSON patch")))
+		})
+
+		It("Should be denied if a values reference does not specify a name", func() {
+			plan := &UpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "plan1",
+					Namespace: "default",
+				},
+				Spec: UpgradePlanSpec{
+					ReleaseVersion: "3.1.0",
+					Helm: []HelmValues{{
+						Chart:      "rancher",
+						ValuesFrom: []HelmValuesReference{{Kind: SecretValuesReference}},
+					}},
+				},
+			}
+
+			err := k8sClient.Create(ctx, plan)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("values reference 0 of chart 'rancher' does not specify a name")))
+		})
 	})
 
 	Context("When updating UpgradePlan under Validating Webhook", Ordered, func() {
@@ -111,6 +206,66 @@ var _ = Describe("UpgradePlan Webhook", func() {
 			Expect(err).To(MatchError(ContainSubstring("upgrade plan cannot be edited while condition 'KubernetesUpgraded' is in 'Error' state")))
 		})
 
+		It("Should pass if only the timeouts change while an upgrade is stalled", func() {
+			meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: OperatingSystemUpgradedCondition, Status: metav1.ConditionFalse, Reason: UpgradeStalled})
+			meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: KubernetesUpgradedCondition, Status: metav1.ConditionFalse, Reason: UpgradePending})
+			Expect(k8sClient.Status().Update(ctx, plan)).To(Succeed())
+
+			plan.Spec.Timeouts = &UpgradeTimeouts{Stage: &metav1.Duration{Duration: 2 * time.Hour}, HaltOnStall: true}
+			Expect(k8sClient.Update(ctx, plan)).To(Succeed())
+
+			plan.Spec.Timeouts = &UpgradeTimeouts{Stage: &metav1.Duration{Duration: -time.Hour}}
+			err := k8sClient.Update(ctx, plan)
+			Expect(err).To(MatchError(ContainSubstring("stage timeout must be positive")))
+
+			plan.Spec.Timeouts = nil
+			plan.Spec.ReleaseVersion = "3.1.1"
+			err = k8sClient.Update(ctx, plan)
+			Expect(err).To(MatchError(ContainSubstring("upgrade plan cannot be edited while condition 'KubernetesUpgraded' is in 'Pending' state")))
+			plan.Spec.ReleaseVersion = "3.1.0"
+		})
+
+		It("Should pass if only pre-flight settings change while pre-flight checks fail", func() {
+			meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: OperatingSystemUpgradedCondition, Status: metav1.ConditionFalse, Reason: UpgradePending})
+			meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: PreflightChecksCondition, Status: metav1.ConditionFalse, Reason: PreflightChecksFailedReason})
+			Expect(k8sClient.Status().Update(ctx, plan)).To(Succeed())
+
+			plan.Spec.Preflight = &PreflightChecks{IgnoreFailures: true}
+			Expect(k8sClient.Update(ctx, plan)).To(Succeed())
+		})
+
+		It("Should pass if only drain settings change while the drain is refused", func() {
+			meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: OperatingSystemUpgradedCondition, Status: metav1.ConditionFalse, Reason: UpgradeInProgress})
+			meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: DrainBlockedCondition, Status: metav1.ConditionTrue, Reason: DrainRefusedReason})
+			Expect(k8sClient.Status().Update(ctx, plan)).To(Succeed())
+
+			plan.Spec.DrainBlockerPolicy = DrainBlockerPolicyDisableRoleDrain
+			Expect(k8sClient.Update(ctx, plan)).To(Succeed())
+
+			plan.Spec.ReleaseVersion = "3.1.1"
+			err := k8sClient.Update(ctx, plan)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(MatchError(ContainSubstring("upgrade plan cannot be edited while condition 'OSUpgraded' is in 'InProgress' state")))
+			plan.Spec.ReleaseVersion = "3.1.0"
+		})
+
+		It("Should pass if only the removed API policy changes while the Kubernetes upgrade is blocked", func() {
+			meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: KubernetesUpgradedCondition, Status: metav1.ConditionFalse, Reason: UpgradeInProgress})
+			meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: RemovedAPIsCondition, Status: metav1.ConditionTrue, Reason: RemovedAPIsBlockedReason})
+			Expect(k8sClient.Status().Update(ctx, plan)).To(Succeed())
+
+			plan.Spec.RemovedAPIPolicy = RemovedAPIPolicyReport
+			Expect(k8sClient.Update(ctx, plan)).To(Succeed())
+		})
+
+		It("Should pass if only the version skew check is skipped while an upgrade is pending", func() {
+			meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: KubernetesUpgradedCondition, Status: metav1.ConditionFalse, Reason: UpgradePending})
+			Expect(k8sClient.Status().Update(ctx, plan)).To(Succeed())
+
+			plan.Annotations = map[string]string{SkipVersionSkewCheckAnnotation: "true"}
+			Expect(k8sClient.Update(ctx, plan)).To(Succeed())
+		})
+
 		It("Should be denied if release version is not specified", func() {
 			plan.Spec.ReleaseVersion = ""
 
@@ -149,4 +304,31 @@ var _ = Describe("UpgradePlan Webhook", func() {
 		})
 	})
 
+	Context("When changing UpgradePlans under Mutating Webhook", Ordered, func() {
+		plan := &UpgradePlan{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "plan2",
+				Namespace: "default",
+			},
+			Spec: UpgradePlanSpec{
+				ReleaseVersion: "3.1.0",
+			},
+		}
+
+		It("Should record the user creating the plan", func() {
+			plan.Annotations = map[string]string{SpecUpdatedByAnnotation: "someone-else"}
+			Expect(k8sClient.Create(ctx, plan)).To(Succeed())
+			Expect(plan.Annotations[SpecUpdatedByAnnotation]).NotTo(BeEmpty())
+			Expect(plan.Annotations[SpecUpdatedByAnnotation]).NotTo(Equal("someone-else"))
+		})
+
+		It("Should retain the recorded user if the spec does not change", func() {
+			user := plan.Annotations[SpecUpdatedByAnnotation]
+
+			plan.Annotations[SpecUpdatedByAnnotation] = "someone-else"
+			Expect(k8sClient.Update(ctx, plan)).To(Succeed())
+			Expect(plan.Annotations[SpecUpdatedByAnnotation]).To(Equal(user))
+		})
+	})
+
 })
@@ -0,0 +1,63 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UpgradeRecordSpec holds the outcome of a finished upgrade run of an UpgradePlan.
+type UpgradeRecordSpec struct {
+	// UpgradePlan is the name of the UpgradePlan which performed the upgrade.
+	UpgradePlan string `json:"upgradePlan"`
+
+	// UpgradePlanUID is the UID of the UpgradePlan which performed the upgrade.
+	// +optional
+	UpgradePlanUID string `json:"upgradePlanUID,omitempty"`
+
+	UpgradeRun `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Plan",type="string",JSONPath=".spec.upgradePlan"
+// +kubebuilder:printcolumn:name="From",type="string",JSONPath=".spec.fromReleaseVersion"
+// +kubebuilder:printcolumn:name="To",type="string",JSONPath=".spec.toReleaseVersion"
+// +kubebuilder:printcolumn:name="Result",type="string",JSONPath=".spec.result"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// UpgradeRecord is the Schema for the upgraderecords API.
+// Records are created for each finished upgrade run and are retained after the deletion of the UpgradePlan.
+type UpgradeRecord struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+	Spec UpgradeRecordSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// UpgradeRecordList contains a list of UpgradeRecord
+type UpgradeRecordList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UpgradeRecord `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UpgradeRecord{}, &UpgradeRecordList{})
+}
@@ -0,0 +1,42 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// ValidateValuesMerge verifies that the given values can be combined with the values of a chart
+// using the given merge strategy.
+func ValidateValuesMerge(values *apiextensionsv1.JSON, strategy ValuesMergeStrategy, mergeKey string) error {
+	switch strategy {
+	case "", ValuesMergeStrategyMerge, ValuesMergeStrategyDeepMerge, ValuesMergeStrategyStrategicMerge, ValuesMergeStrategyJSONPatch:
+	default:
+		return fmt.Errorf("unsupported merge strategy '%s'", strategy)
+	}
+
+	if mergeKey != "" && strategy != ValuesMergeStrategyStrategicMerge {
+		return fmt.Errorf("merge key is only supported by the %s strategy", ValuesMergeStrategyStrategicMerge)
+	}
+
+	if values == nil || len(values.Raw) == 0 {
+		return nil
+	}
+
+	if strategy != ValuesMergeStrategyJSONPatch {
+		var v map[string]any
+		if err := json.Unmarshal(values.Raw, &v); err != nil {
+			return fmt.Errorf("values must be an object: %w", err)
+		}
+
+		return nil
+	}
+
+	if _, err := jsonpatch.DecodePatch(values.Raw); err != nil {
+		return fmt.Errorf("values are not a valid JSON patch: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestValidateValuesMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      string
+		strategy    ValuesMergeStrategy
+		mergeKey    string
+		expectedErr string
+	}{
+		{
+			name:   "Default strategy",
+			values: `{"replicas": 2}`,
+		},
+		{
+			name:     "Strategic merge with merge key",
+			values:   `{"env": [{"key": "LOG_LEVEL", "value": "debug"}]}`,
+			strategy: ValuesMergeStrategyStrategicMerge,
+			mergeKey: "key",
+		},
+		{
+			name:     "JSON patch",
+			values:   `[{"op": "remove", "path": "/legacy"}, {"op": "add", "path": "/env/-", "value": {"name": "A"}}]`,
+			strategy: ValuesMergeStrategyJSONPatch,
+		},
+		{
+			name:     "No values",
+			strategy: ValuesMergeStrategyJSONPatch,
+		},
+		{
+			name:        "Unsupported strategy",
+			values:      `{}`,
+			strategy:    "Replace",
+			expectedErr: "unsupported merge strategy 'Replace'",
+		},
+		{
+			name:        "Merge key without strategic merge",
+			values:      `{}`,
+			strategy:    ValuesMergeStrategyDeepMerge,
+			mergeKey:    "key",
+			expectedErr: "merge key is only supported by the StrategicMerge strategy",
+		},
+		{
+			name:        "List values without JSON patch",
+			values:      `[{"op": "remove", "path": "/legacy"}]`,
+			expectedErr: "values must be an object",
+		},
+		{
+			name:        "Object values with JSON patch",
+			values:      `{"replicas": 2}`,
+			strategy:    ValuesMergeStrategyJSONPatch,
+			expectedErr: "values are not a valid JSON patch",
+		},
+		{
+			name:        "Unsupported JSON patch operation",
+			values:      `[{"op": "merge", "path": "/image"}]`,
+			strategy:    ValuesMergeStrategyJSONPatch,
+			expectedErr: "values are not a valid JSON patch: invalid operation {\"op\":\"merge\",\"path\":\"/image\"}: unsupported operation",
+		},
+		{
+			name:        "JSON patch operation without path",
+			values:      `[{"op": "remove", "path": "/legacy"}, {"op": "remove"}]`,
+			strategy:    ValuesMergeStrategyJSONPatch,
+			expectedErr: "operation missing path field",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var values *apiextensionsv1.JSON
+			if test.values != "" {
+				values = &apiextensionsv1.JSON{Raw: []byte(test.values)}
+			}
+
+			err := ValidateValuesMerge(values, test.strategy, test.mergeKey)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
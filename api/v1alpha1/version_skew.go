@@ -0,0 +1,35 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// ValidateKubernetesVersionSkew verifies that upgrading the kubelets of the given nodes to the target version
+// neither downgrades them nor skips a minor version.
+func ValidateKubernetesVersionSkew(nodes []corev1.Node, targetVersion string) error {
+	target, err := version.ParseSemantic(targetVersion)
+	if err != nil {
+		return fmt.Errorf("parsing target kubernetes version '%s': %w", targetVersion, err)
+	}
+
+	for _, node := range nodes {
+		kubeletVersion := node.Status.NodeInfo.KubeletVersion
+
+		current, err := version.ParseSemantic(kubeletVersion)
+		if err != nil {
+			return fmt.Errorf("parsing kubelet version '%s' of node '%s': %w", kubeletVersion, node.Name, err)
+		}
+
+		switch {
+		case target.LessThan(current):
+			return fmt.Errorf("kubernetes version %s is a downgrade from version %s of node '%s'", targetVersion, kubeletVersion, node.Name)
+		case target.Major() != current.Major() || target.Minor() > current.Minor()+1:
+			return fmt.Errorf("kubernetes version %s skips minor versions of version %s of node '%s'", targetVersion, kubeletVersion, node.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateKubernetesVersionSkew(t *testing.T) {
+	newNode := func(name, kubeletVersion string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KubeletVersion: kubeletVersion}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		nodes         []corev1.Node
+		targetVersion string
+		expectedErr   string
+	}{
+		{
+			name:          "Patch upgrade",
+			nodes:         []corev1.Node{newNode("node-1", "v1.30.3+k3s1"), newNode("node-2", "v1.30.3+k3s1")},
+			targetVersion: "v1.30.5+k3s1",
+		},
+		{
+			name:          "Minor upgrade",
+			nodes:         []corev1.Node{newNode("node-1", "v1.30.3+rke2r1"), newNode("node-2", "v1.31.1+rke2r1")},
+			targetVersion: "v1.31.1+rke2r1",
+		},
+		{
+			name:          "Same version with different build",
+			nodes:         []corev1.Node{newNode("node-1", "v1.30.3+k3s2")},
+			targetVersion: "v1.30.3+k3s1",
+		},
+		{
+			name:          "Skipped minor version",
+			nodes:         []corev1.Node{newNode("node-1", "v1.30.3+k3s1"), newNode("node-2", "v1.29.8+k3s1")},
+			targetVersion: "v1.31.1+k3s1",
+			expectedErr:   "kubernetes version v1.31.1+k3s1 skips minor versions of version v1.29.8+k3s1 of node 'node-2'",
+		},
+		{
+			name:          "Major upgrade",
+			nodes:         []corev1.Node{newNode("node-1", "v1.30.3+k3s1")},
+			targetVersion: "v2.0.0+k3s1",
+			expectedErr:   "kubernetes version v2.0.0+k3s1 skips minor versions of version v1.30.3+k3s1 of node 'node-1'",
+		},
+		{
+			name:          "Downgrade",
+			nodes:         []corev1.Node{newNode("node-1", "v1.30.3+k3s1")},
+			targetVersion: "v1.30.2+k3s1",
+			expectedErr:   "kubernetes version v1.30.2+k3s1 is a downgrade from version v1.30.3+k3s1 of node 'node-1'",
+		},
+		{
+			name:          "Invalid kubelet version",
+			nodes:         []corev1.Node{newNode("node-1", "latest")},
+			targetVersion: "v1.30.3+k3s1",
+			expectedErr:   "parsing kubelet version 'latest' of node 'node-1'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateKubernetesVersionSkew(test.nodes, test.targetVersion)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUpgradePlanValidator_VersionSkew(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, AddToScheme(scheme))
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.29.8+k3s1"}},
+	}
+	newManifest := func(name, namespace, releaseVersion, k3sVersion string) *ReleaseManifest {
+		return &ReleaseManifest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: ReleaseManifestSpec{
+				ReleaseVersion: releaseVersion,
+				Components:     Components{Kubernetes: Kubernetes{K3S: KubernetesDistribution{Version: k3sVersion}}},
+			},
+		}
+	}
+
+	validator := &UpgradePlanValidator{
+		Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			node,
+			newManifest("release-3-1-0", "default", "3.1.0", "v1.30.3+k3s1"),
+			newManifest("release-3-2-0", "catalog", "3.2.0", "v1.31.1+k3s1"),
+			newManifest("release-3-3-0", "default", "3.3.0", "v1.31.1+k3s1"),
+			newManifest("release-3-3-0-rc", "default", "3.3.0", "v1.32.0+k3s1"),
+		).Build(),
+		CatalogNamespace: "catalog",
+	}
+
+	tests := []struct {
+		name        string
+		plan        *UpgradePlan
+		expectedErr string
+	}{
+		{
+			name: "Supported skew",
+			plan: &UpgradePlan{Spec: UpgradePlanSpec{ReleaseVersion: "3.1.0"}},
+		},
+		{
+			name:        "Skipped minor version in catalog manifest",
+			plan:        &UpgradePlan{Spec: UpgradePlanSpec{ReleaseVersion: "3.2.0"}},
+			expectedErr: "kubernetes version v1.31.1+k3s1 skips minor versions of version v1.29.8+k3s1 of node 'node-1'; set the 'lifecycle.suse.com/skip-version-skew-check' annotation to 'true' in order to skip this check",
+		},
+		{
+			name: "Referenced manifest",
+			plan: &UpgradePlan{Spec: UpgradePlanSpec{
+				ReleaseVersion:     "3.3.0",
+				ReleaseManifestRef: &ReleaseManifestReference{Name: "release-3-3-0-rc"},
+			}},
+			expectedErr: "kubernetes version v1.32.0+k3s1 skips minor versions",
+		},
+		{
+			name: "Skipped check",
+			plan: &UpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{SkipVersionSkewCheckAnnotation: "true"}},
+				Spec:       UpgradePlanSpec{ReleaseVersion: "3.2.0"},
+			},
+		},
+		{
+			name: "Intermediate releases",
+			plan: &UpgradePlan{Spec: UpgradePlanSpec{ReleaseVersion: "3.2.0", IntermediateReleases: true}},
+		},
+		{
+			name: "Ambiguous manifests",
+			plan: &UpgradePlan{Spec: UpgradePlanSpec{ReleaseVersion: "3.3.0"}},
+		},
+		{
+			name: "Missing manifest",
+			plan: &UpgradePlan{Spec: UpgradePlanSpec{ReleaseVersion: "3.4.0"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.plan.Namespace = "default"
+
+			_, err := validator.ValidateCreate(context.Background(), test.plan)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -17,16 +17,26 @@ limitations under the License.
 package main
 
 import (
+	"cmp"
+	"context"
 	"crypto/tls"
 	"flag"
+	"net/http"
+	"net/netip"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
@@ -40,6 +50,10 @@ import (
 
 	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
 	"github.com/suse-edge/upgrade-controller/internal/controller"
+	"github.com/suse-edge/upgrade-controller/internal/helmrepo"
+	"github.com/suse-edge/upgrade-controller/internal/notification"
+	"github.com/suse-edge/upgrade-controller/internal/safehttp"
+	"github.com/suse-edge/upgrade-controller/internal/tracing"
 	"github.com/suse-edge/upgrade-controller/internal/upgrade"
 	// +kubebuilder:scaffold:imports
 )
@@ -62,6 +76,10 @@ const (
 	defaultReleaseManifestImage = "registry.opensuse.org/isv/suse/edge/lifecycle/containerfile/release-manifest"
 	defaultKubectlImage         = "registry.opensuse.org/isv/suse/edge/lifecycle/containerfile/kubectl"
 	defaultKubectlVersion       = "1.30.3"
+	catalogRequestTimeout       = 30 * time.Second
+	chartRequestTimeout         = 2 * time.Minute
+	notificationRequestTimeout  = 10 * time.Second
+	tracingShutdownTimeout      = 10 * time.Second
 )
 
 func main() {
@@ -75,6 +93,16 @@ func main() {
 	var kubectlImage string
 	var kubectlVersion string
 	var serviceAccountName string
+	var catalogNamespace string
+	var catalogAdminGroups string
+	var failureLogTailLines int64
+	var failureLogLimitBytes int64
+	var recordUpgrades bool
+	var tracingEndpoint string
+	var tracingProtocol string
+	var validateHelmCharts bool
+	var notificationAllowedNetworks string
+	var catalogAllowedNetworks string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metric endpoint binds to. "+
 		"Use the port :8080. If not set, it will be 0 in order to disable the metrics server")
@@ -96,6 +124,32 @@ func main() {
 		"Version of the kubectl container image")
 	flag.StringVar(&serviceAccountName, "service-account-name", os.Getenv("SERVICE_ACCOUNT_NAME"),
 		"Service account of the controller")
+	flag.StringVar(&catalogNamespace, "catalog-namespace", os.Getenv("CATALOG_NAMESPACE"),
+		"Namespace holding release manifests shared across all namespaces")
+	flag.StringVar(&catalogAdminGroups, "catalog-admin-groups",
+		cmp.Or(os.Getenv("CATALOG_ADMIN_GROUPS"), strings.Join(lifecyclev1alpha1.DefaultCatalogAdminGroups, ",")),
+		"Comma separated groups allowed to manage release manifests in the catalog namespace")
+	flag.Int64Var(&failureLogTailLines, "failure-log-tail-lines", 200,
+		"Number of log lines collected from the pods of failed upgrade jobs. Unlimited if zero")
+	flag.Int64Var(&failureLogLimitBytes, "failure-log-limit-bytes", 16*1024,
+		"Maximum size in bytes of the logs collected from the pods of failed upgrade jobs. Unlimited if zero")
+	flag.BoolVar(&recordUpgrades, "record-upgrades", false,
+		"If set, each finished upgrade run is exported to an UpgradeRecord resource")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "",
+		"URL of the OTLP receiver the reconciliation spans are exported to, e.g. http://otel-collector:4317. "+
+			"Tracing is disabled if not set")
+	flag.StringVar(&tracingProtocol, "tracing-protocol", tracing.ProtocolGRPC,
+		"Protocol of the OTLP receiver, either 'grpc' or 'http/protobuf'")
+	flag.StringVar(&notificationAllowedNetworks, "notification-allowed-networks", "",
+		"Comma separated list of networks in CIDR notation, e.g. 10.43.0.0/16, which notification targets may resolve to "+
+			"in addition to public addresses. Loopback, private and link-local addresses are refused otherwise")
+	flag.StringVar(&catalogAllowedNetworks, "catalog-allowed-networks", "",
+		"Comma separated list of networks in CIDR notation, e.g. 10.0.0.0/8, which release catalog index files, registries "+
+			"and their token endpoints may resolve to in addition to public addresses. Loopback, private and link-local "+
+			"addresses are refused otherwise")
+	flag.BoolVar(&validateHelmCharts, "validate-helm-charts", true,
+		"Fetch the target versions of Helm charts from their repositories in order to validate "+
+			"their Kubernetes version constraints and values schemas before upgrading them")
 
 	opts := zap.Options{
 		Development: true,
@@ -132,6 +186,10 @@ func main() {
 			upgrade.KubeSystemNamespace: {},
 			upgrade.SUCNamespace:        {},
 		}
+
+		if catalogNamespace != "" {
+			watchNamespaces[catalogNamespace] = cache.Config{}
+		}
 	}
 
 	if releaseManifestImage == "" {
@@ -175,6 +233,49 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctx := ctrl.SetupSignalHandler()
+
+	var tracer trace.Tracer
+	var tracerProvider *sdktrace.TracerProvider
+	if tracingEndpoint != "" {
+		tracerProvider, err = tracing.NewTracerProvider(ctx, tracing.Options{Endpoint: tracingEndpoint, Protocol: tracingProtocol})
+		if err != nil {
+			setupLog.Error(err, "unable to set up tracing")
+			os.Exit(1)
+		}
+		tracer = tracerProvider.Tracer(tracing.TracerName)
+	}
+
+	var adminGroups []string
+	for _, group := range strings.Split(catalogAdminGroups, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			adminGroups = append(adminGroups, group)
+		}
+	}
+
+	var notificationNetworks []netip.Prefix
+	if notificationAllowedNetworks != "" {
+		notificationNetworks, err = safehttp.ParseNetworks(strings.Split(notificationAllowedNetworks, ","))
+		if err != nil {
+			setupLog.Error(err, "invalid notification allowed networks")
+			os.Exit(1)
+		}
+	}
+
+	var catalogNetworks []netip.Prefix
+	if catalogAllowedNetworks != "" {
+		catalogNetworks, err = safehttp.ParseNetworks(strings.Split(catalogAllowedNetworks, ","))
+		if err != nil {
+			setupLog.Error(err, "invalid catalog allowed networks")
+			os.Exit(1)
+		}
+	}
+
+	var chartFetcher controller.ChartFetcher
+	if validateHelmCharts {
+		chartFetcher = &helmrepo.Fetcher{Client: &http.Client{Timeout: chartRequestTimeout}}
+	}
+
 	if err = (&controller.UpgradePlanReconciler{
 		Client:               mgr.GetClient(),
 		Scheme:               mgr.GetScheme(),
@@ -185,13 +286,59 @@ func main() {
 			Name:    kubectlImage,
 			Version: kubectlVersion,
 		},
+		CatalogNamespace: catalogNamespace,
+		FailureLogs: &controller.FailureLogCollector{
+			Clientset:  kubernetes.NewForConfigOrDie(mgr.GetConfig()),
+			TailLines:  failureLogTailLines,
+			LimitBytes: failureLogLimitBytes,
+		},
+		Notifier: &notification.Sender{
+			Client:  safehttp.NewClient(notificationRequestTimeout, notificationNetworks),
+			Backoff: wait.Backoff{Duration: time.Second, Factor: 2, Jitter: 0.1, Steps: 5},
+		},
+		RecordUpgrades:    recordUpgrades,
+		Tracer:            tracer,
+		PreflightChecks:   controller.DefaultPreflightChecks(mgr.GetAPIReader()),
+		DrainAnalyzer:     &controller.DrainAnalyzer{Reader: mgr.GetAPIReader()},
+		RemovedAPIScanner: &controller.RemovedAPIScanner{Reader: mgr.GetAPIReader()},
+		ChartFetcher:      chartFetcher,
+		APIReader:         mgr.GetAPIReader(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "UpgradePlan")
 		os.Exit(1)
 	}
+	if err = (&controller.ReleaseManifestReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("release-manifest-controller"),
+		CatalogNamespace: catalogNamespace,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ReleaseManifest")
+		os.Exit(1)
+	}
+	if err = (&controller.ReleaseCatalogReconciler{
+		Client:               mgr.GetClient(),
+		Scheme:               mgr.GetScheme(),
+		Recorder:             mgr.GetEventRecorderFor("release-catalog-controller"),
+		HTTPClient:           safehttp.NewClient(catalogRequestTimeout, catalogNetworks),
+		ReleaseManifestImage: releaseManifestImage,
+		CatalogNamespace:     catalogNamespace,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ReleaseCatalog")
+		os.Exit(1)
+	}
+	if err = (&controller.UpgradeChannelReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("upgrade-channel-controller"),
+		CatalogNamespace: catalogNamespace,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "UpgradeChannel")
+		os.Exit(1)
+	}
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
-		if err = lifecyclev1alpha1.SetupWebhookWithManager(mgr); err != nil {
-			setupLog.Error(err, "unable to create webhook", "webhook", "UpgradePlan")
+		if err = lifecyclev1alpha1.SetupWebhookWithManager(mgr, catalogNamespace, adminGroups); err != nil {
+			setupLog.Error(err, "unable to create webhooks")
 			os.Exit(1)
 		}
 	}
@@ -207,8 +354,18 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	if tracerProvider != nil {
+		// Flush the spans which have not been exported yet.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+
+		if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+			setupLog.Error(err, "problem shutting down tracing")
+		}
+	}
 }
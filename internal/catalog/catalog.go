@@ -0,0 +1,65 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Source lists the release versions published by a release catalog.
+type Source interface {
+	ListVersions(ctx context.Context) ([]string, error)
+}
+
+// NewSource creates the source for the given catalog specification.
+// The defaultRepository is used if neither a repository nor an index file is specified.
+func NewSource(client *http.Client, spec *lifecyclev1alpha1.ReleaseCatalogSpec, defaultRepository string) (Source, error) {
+	switch {
+	case spec.IndexURL != "":
+		return &IndexSource{Client: client, URL: spec.IndexURL}, nil
+	case spec.Repository != "":
+		return NewRegistrySource(client, spec.Repository)
+	case defaultRepository != "":
+		return NewRegistrySource(client, defaultRepository)
+	default:
+		return nil, fmt.Errorf("neither repository nor index URL is specified")
+	}
+}
+
+// SortVersions filters out any entries which are not semantic versions
+// and returns the remaining ones in ascending order.
+func SortVersions(entries []string) []string {
+	parsed := map[string]*version.Version{}
+
+	for _, entry := range entries {
+		v, err := lifecyclev1alpha1.ValidateReleaseVersion(entry)
+		if err != nil {
+			continue
+		}
+
+		parsed[entry] = v
+	}
+
+	versions := make([]string, 0, len(parsed))
+	for entry := range parsed {
+		versions = append(versions, entry)
+	}
+
+	slices.SortFunc(versions, func(a, b string) int {
+		switch {
+		case parsed[a].LessThan(parsed[b]):
+			return -1
+		case parsed[a].GreaterThan(parsed[b]):
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	})
+
+	return versions
+}
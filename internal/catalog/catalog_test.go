@@ -0,0 +1,86 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/safehttp"
+)
+
+func TestNewSource(t *testing.T) {
+	source, err := NewSource(http.DefaultClient, &lifecyclev1alpha1.ReleaseCatalogSpec{IndexURL: "https://example.com/index.yaml"}, "")
+	require.NoError(t, err)
+	assert.IsType(t, &IndexSource{}, source)
+
+	source, err = NewSource(http.DefaultClient, &lifecyclev1alpha1.ReleaseCatalogSpec{Repository: "registry.example.com/release-manifest"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com", source.(*RegistrySource).Host)
+
+	source, err = NewSource(http.DefaultClient, &lifecyclev1alpha1.ReleaseCatalogSpec{}, "registry.opensuse.org/release-manifest")
+	require.NoError(t, err)
+	assert.Equal(t, "registry.opensuse.org", source.(*RegistrySource).Host)
+
+	_, err = NewSource(http.DefaultClient, &lifecyclev1alpha1.ReleaseCatalogSpec{}, "")
+	assert.EqualError(t, err, "neither repository nor index URL is specified")
+}
+
+func TestSortVersions(t *testing.T) {
+	versions := SortVersions([]string{"3.1.0", "latest", "3.0.2", "3.10.0", "3.2.0-rc1", "3.2.0", "3.1.0", "v3.0.1"})
+	assert.Equal(t, []string{"v3.0.1", "3.0.2", "3.1.0", "3.2.0-rc1", "3.2.0", "3.10.0"}, versions)
+}
+
+func TestIndexSource_ListVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.yaml":
+			fmt.Fprint(w, "versions:\n  - 3.0.2\n  - 3.1.0\n")
+		case "/index.json":
+			fmt.Fprint(w, `{"versions": ["3.1.1"]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := &IndexSource{Client: server.Client(), URL: server.URL + "/index.yaml"}
+	versions, err := source.ListVersions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3.0.2", "3.1.0"}, versions)
+
+	source.URL = server.URL + "/index.json"
+	versions, err = source.ListVersions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3.1.1"}, versions)
+
+	source.URL = server.URL + "/missing.yaml"
+	_, err = source.ListVersions(context.Background())
+	assert.EqualError(t, err, "fetching index file: unexpected status code 404")
+}
+
+func TestIndexSource_ListVersionsRefusedAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "versions:\n  - 3.0.2\n")
+	}))
+	defer server.Close()
+
+	client := safehttp.NewClient(time.Second, nil)
+
+	for _, url := range []string{
+		server.URL + "/index.yaml",
+		"http://10.0.0.1/index.yaml",
+		"http://169.254.169.254/latest/meta-data/",
+	} {
+		t.Run(url, func(t *testing.T) {
+			source := &IndexSource{Client: client, URL: url}
+			_, err := source.ListVersions(context.Background())
+			assert.ErrorIs(t, err, safehttp.ErrAddressNotAllowed)
+		})
+	}
+}
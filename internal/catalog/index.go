@@ -0,0 +1,56 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxIndexSize limits the size of the index files being read.
+const maxIndexSize = 1 << 20
+
+// IndexSource lists release versions from a YAML or JSON index file, for example:
+//
+//	versions:
+//	  - 3.0.2
+//	  - 3.1.0
+type IndexSource struct {
+	Client *http.Client
+	URL    string
+}
+
+type index struct {
+	Versions []string `yaml:"versions"`
+}
+
+func (s *IndexSource) ListVersions(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching index file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching index file: unexpected status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIndexSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading index file: %w", err)
+	}
+
+	var i index
+	if err = yaml.Unmarshal(data, &i); err != nil {
+		return nil, fmt.Errorf("parsing index file: %w", err)
+	}
+
+	return i.Versions, nil
+}
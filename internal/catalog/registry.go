@@ -0,0 +1,226 @@
+package catalog
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+
+	// maxTagPages limits the number of paginated tag list requests sent to a registry.
+	maxTagPages = 100
+)
+
+var (
+	challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	nextLinkRegex       = regexp.MustCompile(`<([^>]+)>;\s*rel="?next"?`)
+)
+
+// RegistrySource lists release versions from the tags of an OCI repository.
+// Anonymous access is used unless credentials are configured.
+type RegistrySource struct {
+	Client     *http.Client
+	Host       string
+	Repository string
+
+	// Username and Password authenticate to the registry, either directly or when requesting a token.
+	Username string
+	Password string
+
+	authorization string
+}
+
+// NewRegistrySource creates a registry source from a repository reference
+// such as "registry.opensuse.org/isv/suse/edge/lifecycle/containerfile/release-manifest".
+func NewRegistrySource(client *http.Client, repository string) (*RegistrySource, error) {
+	host, path, found := strings.Cut(repository, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		host, path = dockerHubRegistry, repository
+		if !strings.Contains(path, "/") {
+			path = "library/" + path
+		}
+	} else if host == "docker.io" {
+		host = dockerHubRegistry
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("invalid repository reference '%s'", repository)
+	}
+
+	return &RegistrySource{Client: client, Host: host, Repository: path}, nil
+}
+
+type tagList struct {
+	Tags []string `json:"tags"`
+}
+
+func (s *RegistrySource) ListVersions(ctx context.Context) ([]string, error) {
+	var tags []string
+
+	next := fmt.Sprintf("https://%s/v2/%s/tags/list", s.Host, s.Repository)
+
+	for page := 0; next != "" && page < maxTagPages; page++ {
+		resp, err := s.Get(ctx, next, "application/json")
+		if err != nil {
+			return nil, err
+		}
+
+		list, link, err := decodeTagList(resp)
+		if err != nil {
+			return nil, fmt.Errorf("listing tags: %w", err)
+		}
+
+		tags = append(tags, list.Tags...)
+
+		if next, err = resolveNextLink(next, link); err != nil {
+			return nil, fmt.Errorf("parsing pagination link: %w", err)
+		}
+	}
+
+	return tags, nil
+}
+
+// Get requests the given registry URL accepting the given media type. If the registry challenges the request,
+// an anonymous pull token is requested and reused for any subsequent requests.
+func (s *RegistrySource) Get(ctx context.Context, url, accept string) (*http.Response, error) {
+	resp, err := s.get(ctx, url, accept, s.authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized || s.authorization != "" {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	if s.authorization, err = s.authenticate(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("authenticating to registry: %w", err)
+	}
+
+	return s.get(ctx, url, accept, s.authorization)
+}
+
+func (s *RegistrySource) get(ctx context.Context, url, accept, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+
+	req.Header.Set("Accept", accept)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	return s.Client.Do(req)
+}
+
+func (s *RegistrySource) basicAuthorization() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(s.Username+":"+s.Password))
+}
+
+// authenticate returns the authorization header as per the given "WWW-Authenticate" challenge.
+// Pull tokens are requested anonymously unless credentials are configured.
+func (s *RegistrySource) authenticate(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if strings.EqualFold(scheme, "Basic") && s.Username != "" {
+		return s.basicAuthorization(), nil
+	} else if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication scheme '%s'", scheme)
+	}
+
+	values := map[string]string{}
+	for _, match := range challengeParamRegex.FindAllStringSubmatch(params, -1) {
+		values[match[1]] = match[2]
+	}
+
+	realm, err := url.Parse(values["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid authentication realm '%s'", values["realm"])
+	}
+
+	scope := values["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", s.Repository)
+	}
+
+	query := realm.Query()
+	query.Set("scope", scope)
+	if service := values["service"]; service != "" {
+		query.Set("service", service)
+	}
+	realm.RawQuery = query.Encode()
+
+	var authorization string
+	if s.Username != "" {
+		authorization = s.basicAuthorization()
+	}
+
+	resp, err := s.get(ctx, realm.String(), "application/json", authorization)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding token: %w", err)
+	}
+
+	if token.Token != "" {
+		return "Bearer " + token.Token, nil
+	} else if token.AccessToken != "" {
+		return "Bearer " + token.AccessToken, nil
+	}
+
+	return "", fmt.Errorf("empty token")
+}
+
+func decodeTagList(resp *http.Response) (*tagList, string, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	list := &tagList{}
+	if err := json.NewDecoder(resp.Body).Decode(list); err != nil {
+		return nil, "", fmt.Errorf("decoding tag list: %w", err)
+	}
+
+	return list, resp.Header.Get("Link"), nil
+}
+
+func resolveNextLink(current, link string) (string, error) {
+	match := nextLinkRegex.FindStringSubmatch(link)
+	if match == nil {
+		return "", nil
+	}
+
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+
+	next, err := url.Parse(match[1])
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(next).String(), nil
+}
@@ -0,0 +1,199 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suse-edge/upgrade-controller/internal/safehttp"
+)
+
+func TestNewRegistrySource(t *testing.T) {
+	tests := []struct {
+		repository         string
+		expectedHost       string
+		expectedRepository string
+	}{
+		{
+			repository:         "registry.opensuse.org/isv/suse/edge/lifecycle/containerfile/release-manifest",
+			expectedHost:       "registry.opensuse.org",
+			expectedRepository: "isv/suse/edge/lifecycle/containerfile/release-manifest",
+		},
+		{
+			repository:         "localhost:5000/release-manifest",
+			expectedHost:       "localhost:5000",
+			expectedRepository: "release-manifest",
+		},
+		{
+			repository:         "suse/release-manifest",
+			expectedHost:       dockerHubRegistry,
+			expectedRepository: "suse/release-manifest",
+		},
+		{
+			repository:         "release-manifest",
+			expectedHost:       dockerHubRegistry,
+			expectedRepository: "library/release-manifest",
+		},
+		{
+			repository:         "docker.io/suse/release-manifest",
+			expectedHost:       dockerHubRegistry,
+			expectedRepository: "suse/release-manifest",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.repository, func(t *testing.T) {
+			source, err := NewRegistrySource(http.DefaultClient, test.repository)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedHost, source.Host)
+			assert.Equal(t, test.expectedRepository, source.Repository)
+		})
+	}
+}
+
+func TestRegistrySource_ListVersions(t *testing.T) {
+	const token = "abc"
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			assert.Equal(t, "repository:edge/release-manifest:pull", r.URL.Query().Get("scope"))
+			assert.Equal(t, "test-registry", r.URL.Query().Get("service"))
+			fmt.Fprintf(w, `{"token": "%s"}`, token)
+		case "/v2/edge/release-manifest/tags/list":
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/edge/release-manifest/tags/list?last=3.0.2&n=2>; rel="next"`)
+				fmt.Fprint(w, `{"name": "edge/release-manifest", "tags": ["3.0.1", "3.0.2"]}`)
+				return
+			}
+
+			fmt.Fprint(w, `{"name": "edge/release-manifest", "tags": ["3.1.0", "latest"]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewRegistrySource(server.Client(), strings.TrimPrefix(server.URL, "https://")+"/edge/release-manifest")
+	require.NoError(t, err)
+
+	versions, err := source.ListVersions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3.0.1", "3.0.2", "3.1.0", "latest"}, versions)
+}
+
+func TestRegistrySource_ListVersionsUnauthorized(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	source, err := NewRegistrySource(server.Client(), strings.TrimPrefix(server.URL, "https://")+"/edge/release-manifest")
+	require.NoError(t, err)
+
+	_, err = source.ListVersions(context.Background())
+	assert.EqualError(t, err, "authenticating to registry: unsupported authentication scheme 'Basic'")
+}
+
+func TestRegistrySource_ListVersionsWithCredentials(t *testing.T) {
+	const token = "abc"
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token": "%s"}`, token)
+		case "/v2/edge/release-manifest/tags/list":
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			fmt.Fprint(w, `{"name": "edge/release-manifest", "tags": ["3.1.0"]}`)
+		case "/v2/edge/basic/tags/list":
+			if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			fmt.Fprint(w, `{"name": "edge/basic", "tags": ["3.2.0"]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	source, err := NewRegistrySource(server.Client(), host+"/edge/release-manifest")
+	require.NoError(t, err)
+	source.Username, source.Password = "user", "pass"
+
+	versions, err := source.ListVersions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3.1.0"}, versions)
+
+	source, err = NewRegistrySource(server.Client(), host+"/edge/basic")
+	require.NoError(t, err)
+	source.Username, source.Password = "user", "pass"
+
+	versions, err = source.ListVersions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3.2.0"}, versions)
+}
+
+func TestRegistrySource_ListVersionsRefusedRealm(t *testing.T) {
+	var realm string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s"`, realm))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	// Only the registry itself is allowed.
+	client := safehttp.NewClient(time.Second, []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")})
+	client.Transport.(*http.Transport).TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	for _, realm = range []string{
+		"https://127.0.0.2/token",
+		"https://192.168.1.1/token",
+		"http://169.254.169.254/latest/meta-data/",
+	} {
+		t.Run(realm, func(t *testing.T) {
+			source, err := NewRegistrySource(client, strings.TrimPrefix(server.URL, "https://")+"/edge/release-manifest")
+			require.NoError(t, err)
+
+			_, err = source.ListVersions(context.Background())
+			assert.ErrorContains(t, err, "authenticating to registry")
+			assert.ErrorIs(t, err, safehttp.ErrAddressNotAllowed)
+		})
+	}
+
+	source, err := NewRegistrySource(safehttp.NewClient(time.Second, nil), strings.TrimPrefix(server.URL, "https://")+"/edge/release-manifest")
+	require.NoError(t, err)
+
+	_, err = source.ListVersions(context.Background())
+	assert.ErrorIs(t, err, safehttp.ErrAddressNotAllowed)
+}
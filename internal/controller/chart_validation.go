@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/helmrepo"
+	"gopkg.in/yaml.v3"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ChartFetcher retrieves the given version of a Helm chart from its repository.
+type ChartFetcher interface {
+	Fetch(ctx context.Context, repository, name, version string, credentials helmrepo.Credentials) (*helmchart.Chart, error)
+}
+
+// chartValidationError indicates that a chart cannot be upgraded, e.g. as the helm-controller job would fail to install it
+// or as its values cannot be merged.
+type chartValidationError struct {
+	message string
+}
+
+func (e *chartValidationError) Error() string {
+	return e.message
+}
+
+// validateHelmChart fetches the target version of the given chart and verifies that it supports the target Kubernetes version
+// and that the merged values comply with its values schema. Returns a chartValidationError if either check fails.
+// The chart is fetched with the repository settings of the HelmChart performing the upgrade. The validation is skipped
+// if the chart cannot be fetched, e.g. in air-gapped environments, leaving it to the upgrade job to report any failure.
+func (r *UpgradePlanReconciler) validateHelmChart(
+	ctx context.Context,
+	upgradePlan *lifecyclev1alpha1.UpgradePlan,
+	helmChart *helmcattlev1.HelmChart,
+	releaseChart *lifecyclev1alpha1.HelmChart,
+	values []byte,
+	kubernetesVersion string,
+) error {
+	if r.ChartFetcher == nil {
+		return nil
+	}
+
+	chart, err := r.fetchChart(ctx, helmChart, releaseChart)
+	if err != nil {
+		logger := log.FromContext(ctx)
+		logger.Info("Skipping Helm chart validation", "helmChart", releaseChart.Name, "error", err.Error())
+
+		r.Recorder.Eventf(upgradePlan, corev1.EventTypeWarning, lifecyclev1alpha1.ChartValidationSkippedReason,
+			"Validation of chart %s version %s skipped: %v", releaseChart.ReleaseName, releaseChart.Version, err)
+		return nil
+	}
+
+	if constraint := chart.Metadata.KubeVersion; constraint != "" && !chartutil.IsCompatibleRange(constraint, kubernetesVersion) {
+		return &chartValidationError{
+			message: fmt.Sprintf("Chart %s version %s requires Kubernetes '%s', target Kubernetes version is %s",
+				releaseChart.ReleaseName, releaseChart.Version, constraint, kubernetesVersion),
+		}
+	}
+
+	userValues := map[string]any{}
+	if err = yaml.Unmarshal(values, &userValues); err != nil {
+		return fmt.Errorf("unmarshaling chart values: %w", err)
+	}
+
+	// Validate the values the same way Helm does, i.e. including the defaults of the chart.
+	chartValues, err := chartutil.CoalesceValues(chart, userValues)
+	if err != nil {
+		return fmt.Errorf("coalescing chart values: %w", err)
+	}
+
+	if err = chartutil.ValidateAgainstSchema(chart, chartValues); err != nil {
+		return &chartValidationError{
+			message: fmt.Sprintf("Values of chart %s version %s do not match its schema: %s",
+				releaseChart.ReleaseName, releaseChart.Version, strings.TrimSpace(err.Error())),
+		}
+	}
+
+	return nil
+}
+
+// fetchChart retrieves the target version of the given chart using the repository credentials and CA of the HelmChart.
+func (r *UpgradePlanReconciler) fetchChart(
+	ctx context.Context,
+	helmChart *helmcattlev1.HelmChart,
+	releaseChart *lifecyclev1alpha1.HelmChart,
+) (*helmchart.Chart, error) {
+	credentials := helmrepo.Credentials{
+		PassCredentials:       helmChart.Spec.AuthPassCredentials,
+		CAData:                []byte(helmChart.Spec.RepoCA),
+		InsecureSkipTLSVerify: helmChart.Spec.InsecureSkipTLSVerify,
+		PlainHTTP:             helmChart.Spec.PlainHTTP,
+	}
+
+	if ref := helmChart.Spec.AuthSecret; ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: helmChart.Namespace, Name: ref.Name}, secret); err != nil {
+			return nil, fmt.Errorf("retrieving auth secret %s: %w", ref.Name, err)
+		}
+
+		credentials.Username = string(secret.Data[corev1.BasicAuthUsernameKey])
+		credentials.Password = string(secret.Data[corev1.BasicAuthPasswordKey])
+	}
+
+	if ref := helmChart.Spec.DockerRegistrySecret; ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: helmChart.Namespace, Name: ref.Name}, secret); err != nil {
+			return nil, fmt.Errorf("retrieving docker registry secret %s: %w", ref.Name, err)
+		}
+
+		credentials.DockerConfig = secret.Data[corev1.DockerConfigJsonKey]
+	}
+
+	if ref := helmChart.Spec.RepoCAConfigMap; ref != nil {
+		configMap := &corev1.ConfigMap{}
+		if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: helmChart.Namespace, Name: ref.Name}, configMap); err != nil {
+			return nil, fmt.Errorf("retrieving repository CA config map %s: %w", ref.Name, err)
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(configMap.Data)) {
+			credentials.CAData = append(credentials.CAData, "\n"+configMap.Data[key]...)
+		}
+	}
+
+	chart, err := r.ChartFetcher.Fetch(ctx, releaseChart.Repository, releaseChart.Name, releaseChart.Version, credentials)
+	if err != nil {
+		return nil, fmt.Errorf("fetching chart %s: %w", releaseChart.Name, err)
+	}
+
+	return chart, nil
+}
@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/helmrepo"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeChartFetcher struct {
+	charts      map[string]*helmchart.Chart
+	credentials helmrepo.Credentials
+}
+
+func (f *fakeChartFetcher) Fetch(_ context.Context, _, name, version string, credentials helmrepo.Credentials) (*helmchart.Chart, error) {
+	f.credentials = credentials
+
+	chart, ok := f.charts[name+":"+version]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+
+	return chart, nil
+}
+
+func TestValidateHelmChart(t *testing.T) {
+	recorder := record.NewFakeRecorder(5)
+	r := &UpgradePlanReconciler{
+		Recorder: recorder,
+		ChartFetcher: &fakeChartFetcher{charts: map[string]*helmchart.Chart{
+			"rancher:2.9.1": {
+				Metadata: &helmchart.Metadata{Name: "rancher", Version: "2.9.1", KubeVersion: "< 1.31.0-0"},
+			},
+			"metal3-chart:0.8.1": {
+				Metadata: &helmchart.Metadata{Name: "metal3", Version: "0.8.1", KubeVersion: ">= 1.28.0-0"},
+				Values:   map[string]any{"global": map[string]any{"ironicIP": ""}},
+				Schema:   []byte(`{"type": "object", "required": ["global", "hostname"]}`),
+			},
+		}},
+	}
+
+	tests := []struct {
+		name              string
+		chart             lifecyclev1alpha1.HelmChart
+		values            string
+		kubernetesVersion string
+		expectedMessage   string
+		expectedEvent     string
+	}{
+		{
+			name:              "Compatible Kubernetes version",
+			chart:             lifecyclev1alpha1.HelmChart{Name: "rancher", ReleaseName: "rancher", Version: "2.9.1"},
+			kubernetesVersion: "v1.30.5+k3s1",
+		},
+		{
+			name:              "Incompatible Kubernetes version",
+			chart:             lifecyclev1alpha1.HelmChart{Name: "rancher", ReleaseName: "rancher", Version: "2.9.1"},
+			kubernetesVersion: "v1.31.1+rke2r1",
+			expectedMessage:   "Chart rancher version 2.9.1 requires Kubernetes '< 1.31.0-0', target Kubernetes version is v1.31.1+rke2r1",
+		},
+		{
+			name:              "Values matching the schema",
+			chart:             lifecyclev1alpha1.HelmChart{Name: "metal3-chart", ReleaseName: "metal3", Version: "0.8.1"},
+			values:            "hostname: metal3.example.com\n",
+			kubernetesVersion: "v1.30.5+k3s1",
+		},
+		{
+			name:              "Values not matching the schema",
+			chart:             lifecyclev1alpha1.HelmChart{Name: "metal3-chart", ReleaseName: "metal3", Version: "0.8.1"},
+			values:            "global:\n  ironicIP: 192.168.1.10\n",
+			kubernetesVersion: "v1.30.5+k3s1",
+			expectedMessage:   "Values of chart metal3 version 0.8.1 do not match its schema",
+		},
+		{
+			name:              "Chart not fetched",
+			chart:             lifecyclev1alpha1.HelmChart{Name: "rancher", ReleaseName: "rancher", Version: "2.9.2"},
+			kubernetesVersion: "v1.30.5+k3s1",
+			expectedEvent:     "Warning ChartValidationSkipped Validation of chart rancher version 2.9.2 skipped: fetching chart rancher: not found",
+		},
+	}
+
+	plan := &lifecyclev1alpha1.UpgradePlan{}
+	helmChart := &helmcattlev1.HelmChart{}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := r.validateHelmChart(context.Background(), plan, helmChart, &test.chart, []byte(test.values), test.kubernetesVersion)
+
+			if test.expectedMessage != "" {
+				var validationErr *chartValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Contains(t, validationErr.Error(), test.expectedMessage)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if test.expectedEvent != "" {
+				require.Len(t, recorder.Events, 1)
+				assert.Equal(t, test.expectedEvent, <-recorder.Events)
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+
+	// Validation is disabled without a fetcher.
+	r.ChartFetcher = nil
+	assert.NoError(t, r.validateHelmChart(context.Background(), plan, helmChart,
+		&lifecyclev1alpha1.HelmChart{Name: "rancher", Version: "2.9.2"}, nil, "v1.30.5+k3s1"))
+	assert.Empty(t, recorder.Events)
+}
+
+func TestFetchChartCredentials(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "repo-auth", Namespace: "kube-system"},
+			Data:       map[string][]byte{corev1.BasicAuthUsernameKey: []byte("user"), corev1.BasicAuthPasswordKey: []byte("pass")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry-auth", Namespace: "kube-system"},
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths": {}}`)},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "repo-ca", Namespace: "kube-system"},
+			Data:       map[string]string{"ca.crt": "ca-cert"},
+		},
+	).Build()
+
+	fetcher := &fakeChartFetcher{charts: map[string]*helmchart.Chart{
+		"rancher:2.9.1": {Metadata: &helmchart.Metadata{Name: "rancher", Version: "2.9.1"}},
+	}}
+	r := &UpgradePlanReconciler{APIReader: c, ChartFetcher: fetcher}
+
+	helmChart := &helmcattlev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Name: "rancher", Namespace: "kube-system"},
+		Spec: helmcattlev1.HelmChartSpec{
+			RepoCA:               "inline-cert",
+			RepoCAConfigMap:      &corev1.LocalObjectReference{Name: "repo-ca"},
+			AuthSecret:           &corev1.LocalObjectReference{Name: "repo-auth"},
+			AuthPassCredentials:  true,
+			DockerRegistrySecret: &corev1.LocalObjectReference{Name: "registry-auth"},
+			PlainHTTP:            true,
+		},
+	}
+	releaseChart := &lifecyclev1alpha1.HelmChart{Name: "rancher", Version: "2.9.1"}
+
+	_, err := r.fetchChart(context.Background(), helmChart, releaseChart)
+	require.NoError(t, err)
+
+	assert.Equal(t, helmrepo.Credentials{
+		Username:        "user",
+		Password:        "pass",
+		PassCredentials: true,
+		DockerConfig:    []byte(`{"auths": {}}`),
+		CAData:          []byte("inline-cert\nca-cert"),
+		PlainHTTP:       true,
+	}, fetcher.credentials)
+
+	helmChart.Spec.AuthSecret.Name = "missing"
+	_, err = r.fetchChart(context.Background(), helmChart, releaseChart)
+	assert.ErrorContains(t, err, "retrieving auth secret missing")
+}
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	upgradecattlev1 "github.com/rancher/system-upgrade-controller/pkg/apis/upgrade.cattle.io/v1"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// drainRefusedRetryInterval is the delay before the nodes of a refused drain are analyzed again.
+	drainRefusedRetryInterval = time.Minute
+
+	// maxDrainBlockers limits the number of drain blockers listed in the status of a plan.
+	maxDrainBlockers = 50
+)
+
+// DrainAnalyzer simulates the eviction of the pods running on nodes which are about to be drained.
+type DrainAnalyzer struct {
+	Reader client.Reader
+}
+
+// Analyze returns the pods which would block or be disrupted by draining the given nodes.
+// SUC drains a single node at a time, hence the disruption budgets are evaluated per node.
+func (a *DrainAnalyzer) Analyze(ctx context.Context, nodes []corev1.Node) ([]lifecyclev1alpha1.DrainBlocker, error) {
+	pods := &corev1.PodList{}
+	if err := a.Reader.List(ctx, pods); err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+
+	budgets := &policyv1.PodDisruptionBudgetList{}
+	if err := a.Reader.List(ctx, budgets); err != nil {
+		return nil, fmt.Errorf("listing pod disruption budgets: %w", err)
+	}
+
+	var blockers []lifecyclev1alpha1.DrainBlocker
+
+	for _, node := range nodes {
+		allowedDisruptions := map[string]int32{}
+		for _, budget := range budgets.Items {
+			allowedDisruptions[client.ObjectKeyFromObject(&budget).String()] = budget.Status.DisruptionsAllowed
+		}
+
+		for _, pod := range pods.Items {
+			if pod.Spec.NodeName != node.Name || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+
+			if !isPodEvicted(&pod) {
+				continue
+			}
+
+			blocker := lifecyclev1alpha1.DrainBlocker{Node: node.Name, Pod: client.ObjectKeyFromObject(&pod).String()}
+
+			for _, budget := range matchingDisruptionBudgets(&pod, budgets) {
+				key := client.ObjectKeyFromObject(budget).String()
+				if allowedDisruptions[key] > 0 {
+					allowedDisruptions[key]--
+					continue
+				}
+
+				blocker.Reason = lifecyclev1alpha1.DrainBlockerPodDisruptionBudget
+				blocker.Message = fmt.Sprintf("PodDisruptionBudget %s does not allow further disruptions", budget.Name)
+				blockers = append(blockers, blocker)
+			}
+
+			if metav1.GetControllerOf(&pod) == nil {
+				blocker.Reason = lifecyclev1alpha1.DrainBlockerUnmanaged
+				blocker.Message = "Pod is not managed by a controller and will not be recreated"
+				blockers = append(blockers, blocker)
+			}
+
+			if slices.ContainsFunc(pod.Spec.Volumes, func(volume corev1.Volume) bool {
+				return volume.EmptyDir != nil
+			}) {
+				blocker.Reason = lifecyclev1alpha1.DrainBlockerLocalStorage
+				blocker.Message = "Data of the emptyDir volumes of the pod will be deleted"
+				blockers = append(blockers, blocker)
+			}
+		}
+	}
+
+	return blockers, nil
+}
+
+// matchingDisruptionBudgets returns the budgets selecting the given pod.
+func matchingDisruptionBudgets(pod *corev1.Pod, budgets *policyv1.PodDisruptionBudgetList) []*policyv1.PodDisruptionBudget {
+	var matching []*policyv1.PodDisruptionBudget
+
+	for i := range budgets.Items {
+		budget := &budgets.Items[i]
+		if budget.Namespace != pod.Namespace || budget.Spec.Selector == nil {
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(budget.Spec.Selector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+
+		matching = append(matching, budget)
+	}
+
+	return matching
+}
+
+// analyzeDrain inspects the nodes targeted by the given SUC Plan before its creation and reports
+// whether the plan may be created. Depending on the drain blocker policy, drain is disabled on the SUC Plan instead.
+// SUC Plans target all nodes of a role, hence drain is disabled for all of them, including the nodes without blockers.
+func (r *UpgradePlanReconciler) analyzeDrain(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, sucPlan *upgradecattlev1.Plan, nodeList *corev1.NodeList) (bool, error) {
+	if r.DrainAnalyzer == nil || sucPlan.Spec.Drain == nil {
+		return true, nil
+	}
+
+	nodes, err := findMatchingNodes(nodeList, sucPlan.Spec.NodeSelector)
+	if err != nil {
+		return false, err
+	}
+
+	blockers, err := r.DrainAnalyzer.Analyze(ctx, nodes)
+	if err != nil {
+		return false, fmt.Errorf("analyzing drain of nodes: %w", err)
+	}
+
+	updateDrainBlockers(plan, nodes, blockers)
+
+	if len(blockers) == 0 {
+		setDrainBlockedCondition(plan, metav1.ConditionFalse, lifecyclev1alpha1.DrainBlockersNotFoundReason,
+			fmt.Sprintf("Pods of the nodes targeted by SUC Plan %s can be evicted", sucPlan.Name))
+		return true, nil
+	}
+
+	var blockedNodes []string
+	for _, blocker := range blockers {
+		if !slices.Contains(blockedNodes, blocker.Node) {
+			blockedNodes = append(blockedNodes, blocker.Node)
+		}
+	}
+
+	summary := fmt.Sprintf("Found %d drain blockers on nodes %s", len(blockers), strings.Join(blockedNodes, ", "))
+
+	proceed := true
+	var reason, message string
+
+	switch plan.Spec.DrainBlockerPolicy {
+	case lifecyclev1alpha1.DrainBlockerPolicyRefuse:
+		proceed = false
+		reason = lifecyclev1alpha1.DrainRefusedReason
+		message = fmt.Sprintf("%s. SUC Plan %s is not created until they are resolved", summary, sucPlan.Name)
+	case lifecyclev1alpha1.DrainBlockerPolicyDisableRoleDrain:
+		sucPlan.Spec.Drain = nil
+		reason = lifecyclev1alpha1.DrainDisabledReason
+		message = fmt.Sprintf("%s. SUC Plan %s is created without draining any of its %d nodes", summary, sucPlan.Name, len(nodes))
+	default:
+		reason = lifecyclev1alpha1.DrainBlockersReportedReason
+		message = fmt.Sprintf("%s. SUC Plan %s drains the nodes regardless", summary, sucPlan.Name)
+	}
+
+	previous := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.DrainBlockedCondition)
+	setDrainBlockedCondition(plan, metav1.ConditionTrue, reason, message)
+
+	if previous == nil || previous.Reason != reason || previous.Message != message {
+		r.Recorder.Eventf(plan, corev1.EventTypeWarning, reason, "%s", message)
+	}
+
+	return proceed, nil
+}
+
+// updateDrainBlockers replaces the drain blockers of the given nodes in the plan status.
+func updateDrainBlockers(plan *lifecyclev1alpha1.UpgradePlan, nodes []corev1.Node, blockers []lifecyclev1alpha1.DrainBlocker) {
+	analyzed := sets.New[string]()
+	for _, node := range nodes {
+		analyzed.Insert(node.Name)
+	}
+
+	plan.Status.DrainBlockers = slices.DeleteFunc(plan.Status.DrainBlockers, func(blocker lifecyclev1alpha1.DrainBlocker) bool {
+		return analyzed.Has(blocker.Node)
+	})
+	plan.Status.DrainBlockers = append(plan.Status.DrainBlockers, blockers...)
+
+	if len(plan.Status.DrainBlockers) > maxDrainBlockers {
+		plan.Status.DrainBlockers = plan.Status.DrainBlockers[:maxDrainBlockers]
+	}
+}
+
+func setDrainBlockedCondition(plan *lifecyclev1alpha1.UpgradePlan, status metav1.ConditionStatus, reason, message string) {
+	condition := metav1.Condition{Type: lifecyclev1alpha1.DrainBlockedCondition, Status: status, Reason: reason, Message: message}
+	meta.SetStatusCondition(&plan.Status.Conditions, condition)
+}
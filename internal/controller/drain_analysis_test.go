@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	upgradecattlev1 "github.com/rancher/system-upgrade-controller/pkg/apis/upgrade.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newDrainAnalyzer(t *testing.T) *DrainAnalyzer {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	replicaSet := metav1.OwnerReference{Kind: "ReplicaSet", Name: "web", Controller: ptr.To(true)}
+	daemonSet := metav1.OwnerReference{Kind: "DaemonSet", Name: "agent", Controller: ptr.To(true)}
+
+	newPod := func(name, nodeName string, owner *metav1.OwnerReference, volumes ...corev1.Volume) client.Object {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": name}},
+			Spec:       corev1.PodSpec{NodeName: nodeName, Volumes: volumes},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		if owner != nil {
+			pod.OwnerReferences = []metav1.OwnerReference{*owner}
+		}
+		return pod
+	}
+
+	newBudget := func(name, app string, allowed int32) client.Object {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+
+	cache := corev1.Volume{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newPod("web", "worker-1", &replicaSet),
+		newPod("db", "worker-1", &replicaSet),
+		newPod("debug", "worker-1", nil),
+		newPod("agent", "worker-1", &daemonSet),
+		newPod("cache", "worker-2", &replicaSet, cache),
+		newBudget("web", "web", 1),
+		newBudget("db", "db", 0),
+	).Build()
+
+	return &DrainAnalyzer{Reader: c}
+}
+
+func newDrainNodeList() *corev1.NodeList {
+	newNode := func(name string, controlPlane bool) corev1.Node {
+		node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+		if controlPlane {
+			node.Labels[upgrade.ControlPlaneLabel] = "true"
+		}
+		return node
+	}
+
+	return &corev1.NodeList{Items: []corev1.Node{
+		newNode("control-plane", true),
+		newNode("worker-1", false),
+		newNode("worker-2", false),
+	}}
+}
+
+func TestDrainAnalyzer(t *testing.T) {
+	analyzer := newDrainAnalyzer(t)
+	nodeList := newDrainNodeList()
+
+	blockers, err := analyzer.Analyze(context.Background(), nodeList.Items[1:])
+	require.NoError(t, err)
+
+	assert.Equal(t, []lifecyclev1alpha1.DrainBlocker{
+		{
+			Node:    "worker-1",
+			Pod:     "default/db",
+			Reason:  lifecyclev1alpha1.DrainBlockerPodDisruptionBudget,
+			Message: "PodDisruptionBudget db does not allow further disruptions",
+		},
+		{
+			Node:    "worker-1",
+			Pod:     "default/debug",
+			Reason:  lifecyclev1alpha1.DrainBlockerUnmanaged,
+			Message: "Pod is not managed by a controller and will not be recreated",
+		},
+		{
+			Node:    "worker-2",
+			Pod:     "default/cache",
+			Reason:  lifecyclev1alpha1.DrainBlockerLocalStorage,
+			Message: "Data of the emptyDir volumes of the pod will be deleted",
+		},
+	}, blockers)
+}
+
+func TestAnalyzeDrain(t *testing.T) {
+	newSUCPlan := func() *upgradecattlev1.Plan {
+		plan := baseSUCPlanWithDrain()
+		plan.Spec.NodeSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: upgrade.ControlPlaneLabel, Operator: metav1.LabelSelectorOpDoesNotExist},
+			},
+		}
+		return plan
+	}
+
+	tests := []struct {
+		name            string
+		policy          lifecyclev1alpha1.DrainBlockerPolicy
+		expectedProceed bool
+		expectedDrain   bool
+		expectedReason  string
+	}{
+		{
+			name:            "Report",
+			expectedProceed: true,
+			expectedDrain:   true,
+			expectedReason:  lifecyclev1alpha1.DrainBlockersReportedReason,
+		},
+		{
+			name:            "Refuse",
+			policy:          lifecyclev1alpha1.DrainBlockerPolicyRefuse,
+			expectedProceed: false,
+			expectedDrain:   true,
+			expectedReason:  lifecyclev1alpha1.DrainRefusedReason,
+		},
+		{
+			name:            "Disable drain of the role",
+			policy:          lifecyclev1alpha1.DrainBlockerPolicyDisableRoleDrain,
+			expectedProceed: true,
+			expectedDrain:   false,
+			expectedReason:  lifecyclev1alpha1.DrainDisabledReason,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(5)
+			r := &UpgradePlanReconciler{Recorder: recorder, DrainAnalyzer: newDrainAnalyzer(t)}
+
+			plan := newPlanWithConditions(nil)
+			plan.Spec.DrainBlockerPolicy = test.policy
+			plan.Status.DrainBlockers = []lifecyclev1alpha1.DrainBlocker{
+				{Node: "control-plane", Pod: "default/db", Reason: lifecyclev1alpha1.DrainBlockerUnmanaged},
+				{Node: "worker-1", Pod: "default/web", Reason: lifecyclev1alpha1.DrainBlockerPodDisruptionBudget},
+			}
+
+			sucPlan := newSUCPlan()
+			proceed, err := r.analyzeDrain(context.Background(), plan, sucPlan, newDrainNodeList())
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedProceed, proceed)
+			assert.Equal(t, test.expectedDrain, sucPlan.Spec.Drain != nil)
+
+			// Blockers of nodes which are not analyzed are retained.
+			require.Len(t, plan.Status.DrainBlockers, 4)
+			assert.Equal(t, "control-plane", plan.Status.DrainBlockers[0].Node)
+
+			condition := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.DrainBlockedCondition)
+			require.NotNil(t, condition)
+			assert.Equal(t, metav1.ConditionTrue, condition.Status)
+			assert.Equal(t, test.expectedReason, condition.Reason)
+			assert.Contains(t, condition.Message, "Found 3 drain blockers on nodes worker-1, worker-2")
+
+			require.Len(t, recorder.Events, 1)
+			assert.Contains(t, <-recorder.Events, "Warning "+test.expectedReason)
+
+			// Unchanged blockers are not reported again.
+			_, err = r.analyzeDrain(context.Background(), plan, newSUCPlan(), newDrainNodeList())
+			require.NoError(t, err)
+			assert.Empty(t, recorder.Events)
+		})
+	}
+}
+
+func TestAnalyzeDrainWithoutBlockers(t *testing.T) {
+	r := &UpgradePlanReconciler{Recorder: record.NewFakeRecorder(5), DrainAnalyzer: newDrainAnalyzer(t)}
+
+	plan := newPlanWithConditions(nil)
+	plan.Spec.DrainBlockerPolicy = lifecyclev1alpha1.DrainBlockerPolicyRefuse
+
+	sucPlan := baseSUCPlanWithDrain()
+	sucPlan.Spec.NodeSelector = &metav1.LabelSelector{MatchLabels: map[string]string{upgrade.ControlPlaneLabel: "true"}}
+
+	proceed, err := r.analyzeDrain(context.Background(), plan, sucPlan, newDrainNodeList())
+	require.NoError(t, err)
+	assert.True(t, proceed)
+	assert.Empty(t, plan.Status.DrainBlockers)
+	assert.True(t, meta.IsStatusConditionFalse(plan.Status.Conditions, lifecyclev1alpha1.DrainBlockedCondition))
+
+	// SUC Plans without drain are not analyzed.
+	plan.Status.Conditions = nil
+	proceed, err = r.analyzeDrain(context.Background(), plan, &upgradecattlev1.Plan{}, newDrainNodeList())
+	require.NoError(t, err)
+	assert.True(t, proceed)
+	assert.Empty(t, plan.Status.Conditions)
+}
+
+func baseSUCPlanWithDrain() *upgradecattlev1.Plan {
+	return &upgradecattlev1.Plan{
+		ObjectMeta: metav1.ObjectMeta{Name: "workers"},
+		Spec:       upgradecattlev1.PlanSpec{Drain: &upgradecattlev1.DrainSpec{Force: true}},
+	}
+}
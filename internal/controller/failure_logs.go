@@ -0,0 +1,275 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// maxFailureLogsSize keeps the failure logs ConfigMap well below the object size limit of 1MiB.
+	maxFailureLogsSize = 512 * 1024
+
+	releaseManifestComponent = "ReleaseManifest"
+)
+
+var errNoJobPods = errors.New("no pods found")
+
+// FailureLogCollector retrieves the log tails of failed upgrade pods.
+// Pods and ConfigMaps are accessed directly via the API server in order to avoid caching them cluster-wide.
+type FailureLogCollector struct {
+	Clientset kubernetes.Interface
+	// TailLines is the maximum number of log lines collected per pod. Unlimited if zero.
+	TailLines int64
+	// LimitBytes is the maximum size of the logs collected per pod. Unlimited if zero.
+	LimitBytes int64
+}
+
+// collectFailureLogs stores the log tail of the given failed job in the failure logs ConfigMap of the plan.
+// Collection is best effort and errors are only logged in order to not interfere with the upgrade.
+func (r *UpgradePlanReconciler) collectFailureLogs(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, component string, job *batchv1.Job) {
+	if r.FailureLogs == nil {
+		return
+	}
+
+	key := failureLogsKey(job)
+	if isFailureLogCollected(plan, key, job) {
+		return
+	}
+
+	jobName := fmt.Sprintf("%s/%s", job.Namespace, job.Name)
+	logger := log.FromContext(ctx).WithValues("job", jobName)
+
+	logs, err := r.FailureLogs.tail(ctx, job)
+	if err != nil {
+		logger.Error(err, "failed to retrieve logs of failed job")
+		return
+	}
+
+	order := make([]string, 0, len(plan.Status.FailureLogs))
+	for _, entry := range plan.Status.FailureLogs {
+		order = append(order, entry.Key)
+	}
+
+	configMapName := failureLogsConfigMapName(plan)
+	evicted, err := r.FailureLogs.store(ctx, plan, r.Scheme, configMapName, key, logs, order)
+	if err != nil {
+		logger.Error(err, "failed to store logs of failed job")
+		return
+	}
+
+	plan.Status.FailureLogsConfigMap = configMapName
+	plan.Status.FailureLogs = slices.DeleteFunc(plan.Status.FailureLogs, func(entry lifecyclev1alpha1.FailedJobLogs) bool {
+		return entry.Key == key || slices.Contains(evicted, entry.Key)
+	})
+	plan.Status.FailureLogs = append(plan.Status.FailureLogs, lifecyclev1alpha1.FailedJobLogs{
+		Key:            key,
+		Job:            jobName,
+		Component:      component,
+		CollectionTime: metav1.Now(),
+	})
+
+	r.Recorder.Eventf(plan, corev1.EventTypeWarning, lifecyclev1alpha1.FailureLogsCollectedReason,
+		"Logs of failed %s job %s stored in ConfigMap %s under key %s", component, jobName, configMapName, key)
+}
+
+// collectSUCFailureLogs collects the logs of all failed jobs of the given SUC plan.
+func (r *UpgradePlanReconciler) collectSUCFailureLogs(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, component, sucPlanName string) {
+	if r.FailureLogs == nil {
+		return
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(upgrade.SUCNamespace), client.MatchingLabels{upgrade.SUCPlanLabel: sucPlanName}); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list SUC jobs", "plan", sucPlanName)
+		return
+	}
+
+	for _, job := range jobs.Items {
+		if isJobFailed(job.Status.Conditions) {
+			r.collectFailureLogs(ctx, plan, component, &job)
+		}
+	}
+}
+
+// isFailureLogCollected reports whether the logs of the given job have already been collected.
+// Jobs recreated under the same name after the collection are collected again.
+func isFailureLogCollected(plan *lifecyclev1alpha1.UpgradePlan, key string, job *batchv1.Job) bool {
+	return slices.ContainsFunc(plan.Status.FailureLogs, func(entry lifecyclev1alpha1.FailedJobLogs) bool {
+		return entry.Key == key && !entry.CollectionTime.Before(&job.CreationTimestamp)
+	})
+}
+
+func failureLogsConfigMapName(plan *lifecyclev1alpha1.UpgradePlan) string {
+	return fmt.Sprintf("%s-failure-logs", plan.Name)
+}
+
+func failureLogsKey(job *batchv1.Job) string {
+	return fmt.Sprintf("%s.%s", job.Namespace, job.Name)
+}
+
+// tail retrieves the log tail of the failing container of the most recent failed pod of the given job.
+func (c *FailureLogCollector) tail(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods, err := c.Clientset.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", batchv1.JobNameLabel, job.Name),
+	})
+	if err != nil {
+		return "", fmt.Errorf("listing pods: %w", err)
+	}
+
+	pod := failedPod(pods.Items)
+	if pod == nil {
+		return "", errNoJobPods
+	}
+
+	container, previous := failedContainer(pod)
+
+	opts := &corev1.PodLogOptions{
+		Container: container,
+		Previous:  previous,
+	}
+	if c.TailLines > 0 {
+		opts.TailLines = &c.TailLines
+	}
+	if c.LimitBytes > 0 {
+		opts.LimitBytes = &c.LimitBytes
+	}
+
+	logs, err := c.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("retrieving logs of pod %s: %w", pod.Name, err)
+	}
+
+	return fmt.Sprintf("# pod: %s, container: %s\n%s", pod.Name, container, logs), nil
+}
+
+// store writes the logs under the given key of the failure logs ConfigMap.
+// The oldest entries are evicted in the given order until the logs fit within the size limit.
+func (c *FailureLogCollector) store(
+	ctx context.Context,
+	plan *lifecyclev1alpha1.UpgradePlan,
+	scheme *runtime.Scheme,
+	name, key, logs string,
+	order []string,
+) (evicted []string, err error) {
+	configMaps := c.Clientset.CoreV1().ConfigMaps(plan.Namespace)
+
+	exists := true
+	configMap, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("retrieving config map: %w", err)
+		}
+
+		exists = false
+
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: plan.Namespace,
+				Labels:    upgrade.PlanIdentifierLabels(plan.Name, plan.Namespace),
+			},
+		}
+
+		if err = controllerutil.SetControllerReference(plan, configMap, scheme); err != nil {
+			return nil, fmt.Errorf("setting controller reference: %w", err)
+		}
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+
+	delete(configMap.Data, key)
+
+	size := len(logs)
+	for _, value := range configMap.Data {
+		size += len(value)
+	}
+
+	for _, k := range order {
+		if size <= maxFailureLogsSize {
+			break
+		}
+
+		if value, ok := configMap.Data[k]; ok {
+			size -= len(value)
+			delete(configMap.Data, k)
+			evicted = append(evicted, k)
+		}
+	}
+
+	configMap.Data[key] = logs
+
+	if exists {
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+	} else {
+		_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("writing config map: %w", err)
+	}
+
+	return evicted, nil
+}
+
+// failedPod returns the most recently created failed pod.
+// Falls back to the most recently created pod, e.g. for containers restarted in place.
+func failedPod(pods []corev1.Pod) *corev1.Pod {
+	var latest, latestFailed *corev1.Pod
+
+	for i := range pods {
+		pod := &pods[i]
+
+		if latest == nil || latest.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			latest = pod
+		}
+
+		if pod.Status.Phase == corev1.PodFailed && (latestFailed == nil || latestFailed.CreationTimestamp.Before(&pod.CreationTimestamp)) {
+			latestFailed = pod
+		}
+	}
+
+	if latestFailed != nil {
+		return latestFailed
+	}
+
+	return latest
+}
+
+// failedContainer returns the name of the first container of the pod which terminated unsuccessfully
+// and whether the logs of its previous instance should be retrieved. Init containers are inspected first.
+func failedContainer(pod *corev1.Pod) (name string, previous bool) {
+	statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+
+	for _, status := range statuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return status.Name, false
+		}
+	}
+
+	for _, status := range statuses {
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return status.Name, true
+		}
+	}
+
+	if len(pod.Spec.Containers) != 0 {
+		return pod.Spec.Containers[0].Name, false
+	}
+
+	return "", false
+}
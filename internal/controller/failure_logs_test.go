@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestFailedPod(t *testing.T) {
+	now := time.Now()
+	newPod := func(name string, phase corev1.PodPhase, created time.Time) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	assert.Nil(t, failedPod(nil))
+
+	pods := []corev1.Pod{
+		newPod("failed-old", corev1.PodFailed, now.Add(-time.Hour)),
+		newPod("failed-new", corev1.PodFailed, now.Add(-time.Minute)),
+		newPod("running", corev1.PodRunning, now),
+	}
+	assert.Equal(t, "failed-new", failedPod(pods).Name)
+
+	pods = []corev1.Pod{
+		newPod("pending", corev1.PodPending, now.Add(-time.Hour)),
+		newPod("running", corev1.PodRunning, now),
+	}
+	assert.Equal(t, "running", failedPod(pods).Name)
+}
+
+func TestFailedContainer(t *testing.T) {
+	terminated := func(exitCode int32) corev1.ContainerState {
+		return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode}}
+	}
+
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "upgrade"}, {Name: "sidecar"}}},
+	}
+
+	name, previous := failedContainer(pod)
+	assert.Equal(t, "upgrade", name)
+	assert.False(t, previous)
+
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{Name: "prepare", State: terminated(0)}}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "upgrade", State: terminated(0)},
+		{Name: "sidecar", State: terminated(1)},
+	}
+	name, previous = failedContainer(pod)
+	assert.Equal(t, "sidecar", name)
+	assert.False(t, previous)
+
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{Name: "prepare", State: terminated(2)}}
+	name, previous = failedContainer(pod)
+	assert.Equal(t, "prepare", name)
+	assert.False(t, previous)
+
+	// Containers restarted in place expose the failure in their last termination state.
+	pod.Status.InitContainerStatuses = nil
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "upgrade", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}, LastTerminationState: terminated(1)},
+	}
+	name, previous = failedContainer(pod)
+	assert.Equal(t, "upgrade", name)
+	assert.True(t, previous)
+}
+
+func TestCollectFailureLogs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, lifecyclev1alpha1.AddToScheme(scheme))
+
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgrade-plan", Namespace: "upgrade-controller-system", UID: "plan-uid"},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "helm-install-rancher",
+			Namespace:         "kube-system",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Minute)),
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "helm-install-rancher-abcde",
+			Namespace: "kube-system",
+			Labels:    map[string]string{batchv1.JobNameLabel: "helm-install-rancher"},
+		},
+		Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "helm"}}},
+		Status: corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+
+	recorder := record.NewFakeRecorder(5)
+	clientset := fake.NewClientset(pod)
+	r := &UpgradePlanReconciler{
+		Scheme:      scheme,
+		Recorder:    recorder,
+		FailureLogs: &FailureLogCollector{Clientset: clientset, TailLines: 100},
+	}
+
+	r.collectFailureLogs(context.Background(), plan, "rancher", job)
+
+	assert.Equal(t, "upgrade-plan-failure-logs", plan.Status.FailureLogsConfigMap)
+	require.Len(t, plan.Status.FailureLogs, 1)
+	assert.Equal(t, "kube-system.helm-install-rancher", plan.Status.FailureLogs[0].Key)
+	assert.Equal(t, "kube-system/helm-install-rancher", plan.Status.FailureLogs[0].Job)
+	assert.Equal(t, "rancher", plan.Status.FailureLogs[0].Component)
+
+	configMap, err := clientset.CoreV1().ConfigMaps(plan.Namespace).Get(context.Background(), "upgrade-plan-failure-logs", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(configMap.Data["kube-system.helm-install-rancher"], "# pod: helm-install-rancher-abcde, container: helm\n"))
+	require.Len(t, configMap.OwnerReferences, 1)
+	assert.Equal(t, "upgrade-plan", configMap.OwnerReferences[0].Name)
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Warning FailureLogsCollected Logs of failed rancher job kube-system/helm-install-rancher")
+
+	// Logs of the same job are only collected once.
+	r.collectFailureLogs(context.Background(), plan, "rancher", job)
+	assert.Empty(t, recorder.Events)
+
+	// Jobs recreated under the same name are collected again.
+	job.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Minute))
+	r.collectFailureLogs(context.Background(), plan, "rancher", job)
+	require.Len(t, plan.Status.FailureLogs, 1)
+	assert.Len(t, recorder.Events, 1)
+}
+
+func TestStoreFailureLogsEviction(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, lifecyclev1alpha1.AddToScheme(scheme))
+
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgrade-plan", Namespace: "upgrade-controller-system"},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgrade-plan-failure-logs", Namespace: "upgrade-controller-system"},
+		Data: map[string]string{
+			"cattle-system.oldest":  strings.Repeat("a", maxFailureLogsSize/2),
+			"cattle-system.older":   strings.Repeat("b", maxFailureLogsSize/4),
+			"cattle-system.replace": strings.Repeat("c", maxFailureLogsSize/4),
+		},
+	}
+
+	clientset := fake.NewClientset(configMap)
+	collector := &FailureLogCollector{Clientset: clientset}
+
+	order := []string{"cattle-system.oldest", "cattle-system.older", "cattle-system.replace"}
+	evicted, err := collector.store(context.Background(), plan, scheme, configMap.Name, "cattle-system.replace", strings.Repeat("d", maxFailureLogsSize/2), order)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cattle-system.oldest"}, evicted)
+
+	configMap, err = clientset.CoreV1().ConfigMaps(plan.Namespace).Get(context.Background(), configMap.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Len(t, configMap.Data, 2)
+	assert.Contains(t, configMap.Data, "cattle-system.older")
+	assert.Equal(t, strings.Repeat("d", maxFailureLogsSize/2), configMap.Data["cattle-system.replace"])
+}
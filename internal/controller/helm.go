@@ -21,7 +21,6 @@ import (
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
-	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -79,22 +78,40 @@ func compareChartReleaseWithVersion(releaseName string, version string) (bool, e
 }
 
 // Updates an existing HelmChart resource in order to trigger an upgrade.
-func (r *UpgradePlanReconciler) updateHelmChart(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan, chart *helmcattlev1.HelmChart, releaseChart *lifecyclev1alpha1.HelmChart) error {
+func (r *UpgradePlanReconciler) updateHelmChart(
+	ctx context.Context,
+	upgradePlan *lifecyclev1alpha1.UpgradePlan,
+	chart *helmcattlev1.HelmChart,
+	releaseChart *lifecyclev1alpha1.HelmChart,
+	kubernetesVersion string,
+) error {
 	backoffLimit := int32(6)
 
-	var userValues *apiextensionsv1.JSON
-	for _, h := range upgradePlan.Spec.Helm {
-		if releaseChart.Name == h.Chart {
-			userValues = h.Values
-			break
-		}
+	sources, secretValues, err := r.resolveHelmValues(ctx, upgradePlan, releaseChart)
+	if err != nil {
+		return fmt.Errorf("resolving chart values: %w", err)
 	}
 
-	values, err := mergeHelmValues(chart.Spec.ValuesContent, releaseChart.Values, userValues)
+	values, err := mergeHelmValues(chart.Spec.ValuesContent, sources...)
 	if err != nil {
 		return fmt.Errorf("merging chart values: %w", err)
 	}
 
+	validationValues, err := withSecretValues(values, secretValues)
+	if err != nil {
+		return fmt.Errorf("merging secret values: %w", err)
+	}
+
+	if err = r.validateHelmChart(ctx, upgradePlan, chart, releaseChart, validationValues, kubernetesVersion); err != nil {
+		return err
+	}
+
+	r.recordValuesDiff(ctx, upgradePlan, releaseChart, chart.Spec.ValuesContent, values)
+
+	if err = r.storeValuesSecret(ctx, upgradePlan, chart, secretValues); err != nil {
+		return err
+	}
+
 	if chart.Labels == nil {
 		chart.Labels = map[string]string{}
 	}
@@ -105,7 +122,7 @@ func (r *UpgradePlanReconciler) updateHelmChart(ctx context.Context, upgradePlan
 
 	chart.Labels[upgrade.PlanNameLabel] = upgradePlan.Name
 	chart.Labels[upgrade.PlanNamespaceLabel] = upgradePlan.Namespace
-	chart.Annotations[upgrade.ReleaseAnnotation] = upgradePlan.Spec.ReleaseVersion
+	chart.Annotations[upgrade.ReleaseAnnotation] = currentReleaseVersion(upgradePlan)
 	chart.Spec.ChartContent = ""
 	chart.Spec.Chart = releaseChart.Name
 	chart.Spec.Version = releaseChart.Version
@@ -118,25 +135,33 @@ func (r *UpgradePlanReconciler) updateHelmChart(ctx context.Context, upgradePlan
 
 // Creates a HelmChart resource in order to trigger an upgrade
 // using the information from an existing Helm release.
-func (r *UpgradePlanReconciler) createHelmChart(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan, installedChart *helmrelease.Release, releaseChart *lifecyclev1alpha1.HelmChart) error {
+func (r *UpgradePlanReconciler) createHelmChart(
+	ctx context.Context,
+	upgradePlan *lifecyclev1alpha1.UpgradePlan,
+	installedChart *helmrelease.Release,
+	releaseChart *lifecyclev1alpha1.HelmChart,
+	kubernetesVersion string,
+) error {
 	backoffLimit := int32(6)
 
-	var userValues *apiextensionsv1.JSON
-	for _, h := range upgradePlan.Spec.Helm {
-		if releaseChart.Name == h.Chart {
-			userValues = h.Values
-			break
-		}
+	sources, secretValues, err := r.resolveHelmValues(ctx, upgradePlan, releaseChart)
+	if err != nil {
+		return fmt.Errorf("resolving chart values: %w", err)
 	}
 
-	values, err := mergeHelmValues(installedChart.Config, releaseChart.Values, userValues)
+	values, err := mergeHelmValues(installedChart.Config, sources...)
 	if err != nil {
 		return fmt.Errorf("merging chart values: %w", err)
 	}
 
+	validationValues, err := withSecretValues(values, secretValues)
+	if err != nil {
+		return fmt.Errorf("merging secret values: %w", err)
+	}
+
 	labels := upgrade.PlanIdentifierLabels(upgradePlan.Name, upgradePlan.Namespace)
 	annotations := map[string]string{
-		upgrade.ReleaseAnnotation: upgradePlan.Spec.ReleaseVersion,
+		upgrade.ReleaseAnnotation: currentReleaseVersion(upgradePlan),
 	}
 
 	chart := &helmcattlev1.HelmChart{
@@ -160,45 +185,55 @@ func (r *UpgradePlanReconciler) createHelmChart(ctx context.Context, upgradePlan
 		},
 	}
 
+	if err = r.validateHelmChart(ctx, upgradePlan, chart, releaseChart, validationValues, kubernetesVersion); err != nil {
+		return err
+	}
+
+	r.recordValuesDiff(ctx, upgradePlan, releaseChart, installedChart.Config, values)
+
+	// The values Secret is stored first so that the upgrade job does not start without it.
+	if err = r.storeValuesSecret(ctx, upgradePlan, chart, secretValues); err != nil {
+		return err
+	}
+
 	return r.createObject(ctx, upgradePlan, chart)
 }
 
-func mergeHelmValues(installedValues any, releaseValues, userValues *apiextensionsv1.JSON) ([]byte, error) {
-	values := map[string]any{}
+// mergeHelmValues combines the installed values of a chart with the given values sources in order.
+// JSON patches are applied once all other sources have been merged. Returns a chartValidationError if a patch cannot be applied.
+func mergeHelmValues(installedValues any, sources ...valuesSource) ([]byte, error) {
+	values, err := parseInstalledValues(installedValues)
+	if err != nil {
+		return nil, err
+	}
 
-	switch installed := installedValues.(type) {
-	case string:
-		if installed != "" {
-			if err := yaml.Unmarshal([]byte(installed), &values); err != nil {
-				return nil, fmt.Errorf("unmarshaling installed chart values: %w", err)
-			}
+	var patches []valuesSource
+
+	for _, source := range sources {
+		if source.values == nil || len(source.values.Raw) == 0 {
+			continue
 		}
-	case map[string]interface{}:
-		if len(installed) != 0 {
-			maps.Copy(values, installed)
+
+		if source.strategy == lifecyclev1alpha1.ValuesMergeStrategyJSONPatch {
+			patches = append(patches, source)
+			continue
 		}
-	default:
-		return nil, fmt.Errorf("unexpected type %T of installed values", installedValues)
-	}
 
-	if releaseValues != nil && len(releaseValues.Raw) > 0 {
 		var v map[string]any
 
-		if err := json.Unmarshal(releaseValues.Raw, &v); err != nil {
-			return nil, fmt.Errorf("unmarshaling additional release values: %w", err)
+		if err := json.Unmarshal(source.values.Raw, &v); err != nil {
+			return nil, fmt.Errorf("unmarshaling additional %s values: %w", source.name, err)
 		}
 
-		values = mergeMaps(values, v)
+		values = mergeValues(values, v, source.strategy, source.mergeKey)
 	}
 
-	if userValues != nil && len(userValues.Raw) > 0 {
-		var v map[string]any
-
-		if err := json.Unmarshal(userValues.Raw, &v); err != nil {
-			return nil, fmt.Errorf("unmarshaling additional user values: %w", err)
+	for _, source := range patches {
+		if values, err = applyValuesPatch(values, source.values.Raw); err != nil {
+			return nil, &chartValidationError{
+				message: fmt.Sprintf("Applying the %s values patch failed: %s", source.name, err),
+			}
 		}
-
-		values = mergeMaps(values, v)
 	}
 
 	if len(values) == 0 {
@@ -213,6 +248,28 @@ func mergeHelmValues(installedValues any, releaseValues, userValues *apiextensio
 	return v, nil
 }
 
+// parseInstalledValues returns the values of either the ValuesContent of a HelmChart or the config of a Helm release.
+func parseInstalledValues(installedValues any) (map[string]any, error) {
+	values := map[string]any{}
+
+	switch installed := installedValues.(type) {
+	case string:
+		if installed != "" {
+			if err := yaml.Unmarshal([]byte(installed), &values); err != nil {
+				return nil, fmt.Errorf("unmarshaling installed chart values: %w", err)
+			}
+		}
+	case map[string]interface{}:
+		if len(installed) != 0 {
+			maps.Copy(values, installed)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected type %T of installed values", installedValues)
+	}
+
+	return values, nil
+}
+
 func mergeMaps(m1, m2 map[string]any) map[string]any {
 	out := make(map[string]any, len(m1))
 	for k, v := range m1 {
@@ -232,7 +289,15 @@ func mergeMaps(m1, m2 map[string]any) map[string]any {
 	return out
 }
 
-func (r *UpgradePlanReconciler) upgradeHelmChart(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan, releaseChart *lifecyclev1alpha1.HelmChart) (upgrade.HelmChartState, error) {
+func (r *UpgradePlanReconciler) upgradeHelmChart(
+	ctx context.Context,
+	upgradePlan *lifecyclev1alpha1.UpgradePlan,
+	releaseChart *lifecyclev1alpha1.HelmChart,
+	kubernetesVersion string,
+) (_ upgrade.HelmChartState, err error) {
+	ctx, span := r.startSpan(ctx, "upgradeHelmChart", chartAttribute.String(releaseChart.ReleaseName))
+	defer func() { endSpan(span, err) }()
+
 	helmRelease, err := retrieveHelmRelease(releaseChart.ReleaseName)
 	if err != nil {
 		if errors.Is(err, helmdriver.ErrReleaseNotFound) {
@@ -252,15 +317,15 @@ func (r *UpgradePlanReconciler) upgradeHelmChart(ctx context.Context, upgradePla
 			return upgrade.ChartStateVersionAlreadyInstalled, nil
 		}
 
-		return upgrade.ChartStateInProgress, r.createHelmChart(ctx, upgradePlan, helmRelease, releaseChart)
+		return upgrade.ChartStateInProgress, r.createHelmChart(ctx, upgradePlan, helmRelease, releaseChart, kubernetesVersion)
 	}
 
 	if chart.Spec.Version != releaseChart.Version {
-		return upgrade.ChartStateInProgress, r.updateHelmChart(ctx, upgradePlan, chart, releaseChart)
+		return upgrade.ChartStateInProgress, r.updateHelmChart(ctx, upgradePlan, chart, releaseChart, kubernetesVersion)
 	}
 
 	releaseVersion := chart.Annotations[upgrade.ReleaseAnnotation]
-	if releaseVersion != upgradePlan.Spec.ReleaseVersion {
+	if releaseVersion != currentReleaseVersion(upgradePlan) {
 		return upgrade.ChartStateVersionAlreadyInstalled, nil
 	}
 
@@ -290,6 +355,8 @@ func (r *UpgradePlanReconciler) upgradeHelmChart(ctx context.Context, upgradePla
 		"job", fmt.Sprintf("%s/%s", job.Namespace, job.Name),
 		"jobStatus", condition.Message)
 
+	r.collectFailureLogs(ctx, upgradePlan, releaseChart.ReleaseName, job)
+
 	return upgrade.ChartStateFailed, nil
 }
 
@@ -281,7 +281,9 @@ metal3-ironic:
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			values, err := mergeHelmValues(test.installedValues, test.releaseValues, test.userValues)
+			values, err := mergeHelmValues(test.installedValues,
+				valuesSource{name: "release", values: test.releaseValues},
+				valuesSource{name: "user", values: test.userValues})
 			if test.expectedErr != "" {
 				require.Error(t, err)
 				assert.EqualError(t, err, test.expectedErr)
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	nodeStateUpgraded = "upgraded"
+	nodeStatePending  = "pending"
+
+	releaseManifestLookupFailure      = "lookup"
+	releaseManifestJobCreationFailure = "job_creation"
+	releaseManifestJobFailure         = "job_failed"
+)
+
+var componentStates = []string{
+	lifecyclev1alpha1.UpgradePending,
+	lifecyclev1alpha1.UpgradeInProgress,
+	lifecyclev1alpha1.UpgradeError,
+	lifecyclev1alpha1.UpgradeStalled,
+	lifecyclev1alpha1.UpgradeSucceeded,
+	lifecyclev1alpha1.UpgradeFailed,
+	lifecyclev1alpha1.UpgradeSkipped,
+}
+
+var (
+	stageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "upgradeplan_stage_duration_seconds",
+		Help: "Duration of the upgrade stages from start until completion.",
+		// 1m up to ~17h
+		Buckets: prometheus.ExponentialBuckets(60, 2, 11),
+	}, []string{"stage", "result"})
+
+	componentState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "upgradeplan_component_state",
+		Help: "Current upgrade state of the components of an upgrade plan. Set to 1 for the active state and 0 otherwise.",
+	}, []string{"namespace", "name", "component", "state"})
+
+	nodesUpgradeStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "upgradeplan_nodes",
+		Help: "Number of nodes which are upgraded or still pending an upgrade per stage.",
+	}, []string{"namespace", "name", "stage", "state"})
+
+	helmChartUpgrades = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "upgradeplan_helm_chart_upgrades_total",
+		Help: "Number of finished Helm chart upgrades by chart and outcome.",
+	}, []string{"chart", "outcome"})
+
+	releaseManifestFetchFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "release_manifest_fetch_failures_total",
+		Help: "Number of failures while fetching release manifests.",
+	}, []string{"reason"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		stageDuration,
+		componentState,
+		nodesUpgradeStatus,
+		helmChartUpgrades,
+		releaseManifestFetchFailures,
+	)
+}
+
+// recordUpgradeMetrics updates the metrics of the given plan
+// based on the transitions between the previous and the current conditions.
+func recordUpgradeMetrics(plan *lifecyclev1alpha1.UpgradePlan, previousConditions []metav1.Condition, now time.Time) {
+	for _, condition := range plan.Status.Conditions {
+		if !isComponentCondition(&condition) {
+			continue
+		}
+
+		for _, state := range componentStates {
+			value := 0.0
+			if condition.Reason == state {
+				value = 1
+			}
+
+			componentState.WithLabelValues(plan.Namespace, plan.Name, condition.Type, state).Set(value)
+		}
+
+		if condition.Reason != lifecyclev1alpha1.UpgradeSucceeded && condition.Reason != lifecyclev1alpha1.UpgradeFailed {
+			continue
+		}
+
+		previous := meta.FindStatusCondition(previousConditions, condition.Type)
+		if previous == nil || (previous.Reason != lifecyclev1alpha1.UpgradeInProgress && previous.Reason != lifecyclev1alpha1.UpgradeStalled) {
+			continue
+		}
+
+		stageDuration.WithLabelValues(condition.Type, condition.Reason).Observe(now.Sub(previous.LastTransitionTime.Time).Seconds())
+	}
+}
+
+// recordNodeMetrics updates the number of upgraded and pending nodes for the given stage.
+func recordNodeMetrics(plan *lifecyclev1alpha1.UpgradePlan, stage string, nodeList *corev1.NodeList, isUpgraded func(node corev1.Node) bool) {
+	var upgraded, pending int
+
+	for _, node := range nodeList.Items {
+		if isUpgraded(node) {
+			upgraded++
+		} else {
+			pending++
+		}
+	}
+
+	nodesUpgradeStatus.WithLabelValues(plan.Namespace, plan.Name, stage, nodeStateUpgraded).Set(float64(upgraded))
+	nodesUpgradeStatus.WithLabelValues(plan.Namespace, plan.Name, stage, nodeStatePending).Set(float64(pending))
+}
+
+// recordHelmChartOutcome counts the outcome of a Helm chart upgrade
+// once its condition transitions into a final state.
+func recordHelmChartOutcome(plan *lifecyclev1alpha1.UpgradePlan, conditionType, chartName, previousReason string) {
+	condition := meta.FindStatusCondition(plan.Status.Conditions, conditionType)
+	if condition == nil || condition.Reason == previousReason {
+		return
+	}
+
+	switch condition.Reason {
+	case lifecyclev1alpha1.UpgradeSucceeded, lifecyclev1alpha1.UpgradeFailed, lifecyclev1alpha1.UpgradeSkipped:
+		helmChartUpgrades.WithLabelValues(chartName, condition.Reason).Inc()
+	}
+}
+
+// deleteUpgradeMetrics removes all metrics tracked for the given plan.
+func deleteUpgradeMetrics(plan *lifecyclev1alpha1.UpgradePlan) {
+	labels := prometheus.Labels{"namespace": plan.Namespace, "name": plan.Name}
+
+	componentState.DeletePartialMatch(labels)
+	nodesUpgradeStatus.DeletePartialMatch(labels)
+}
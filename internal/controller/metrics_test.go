@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordUpgradeMetrics(t *testing.T) {
+	now := time.Now()
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-plan", Namespace: "default"},
+		Status: lifecyclev1alpha1.UpgradePlanStatus{
+			Conditions: []metav1.Condition{
+				{Type: lifecyclev1alpha1.OperatingSystemUpgradedCondition, Reason: lifecyclev1alpha1.UpgradeSucceeded},
+				{Type: lifecyclev1alpha1.KubernetesUpgradedCondition, Reason: lifecyclev1alpha1.UpgradeInProgress},
+				{Type: lifecyclev1alpha1.ValidationFailedCondition, Reason: lifecyclev1alpha1.InvalidReleaseManifestReason},
+			},
+		},
+	}
+	previousConditions := []metav1.Condition{
+		{
+			Type:               lifecyclev1alpha1.OperatingSystemUpgradedCondition,
+			Reason:             lifecyclev1alpha1.UpgradeInProgress,
+			LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)),
+		},
+		{Type: lifecyclev1alpha1.KubernetesUpgradedCondition, Reason: lifecyclev1alpha1.UpgradePending},
+	}
+
+	samples := testutil.CollectAndCount(stageDuration)
+
+	recordUpgradeMetrics(plan, previousConditions, now)
+
+	assert.Equal(t, samples+1, testutil.CollectAndCount(stageDuration))
+
+	osState := componentState.WithLabelValues("default", "metrics-plan", lifecyclev1alpha1.OperatingSystemUpgradedCondition, lifecyclev1alpha1.UpgradeSucceeded)
+	assert.Equal(t, 1.0, testutil.ToFloat64(osState))
+
+	kubernetesState := componentState.WithLabelValues("default", "metrics-plan", lifecyclev1alpha1.KubernetesUpgradedCondition, lifecyclev1alpha1.UpgradePending)
+	assert.Equal(t, 0.0, testutil.ToFloat64(kubernetesState))
+
+	kubernetesState = componentState.WithLabelValues("default", "metrics-plan", lifecyclev1alpha1.KubernetesUpgradedCondition, lifecyclev1alpha1.UpgradeInProgress)
+	assert.Equal(t, 1.0, testutil.ToFloat64(kubernetesState))
+
+	// ValidationFailed is not tracked.
+	assert.Equal(t, len(componentStates)*2, testutil.CollectAndCount(componentState))
+
+	deleteUpgradeMetrics(plan)
+	assert.Equal(t, 0, testutil.CollectAndCount(componentState))
+}
+
+func TestRecordNodeMetrics(t *testing.T) {
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-plan", Namespace: "default"},
+	}
+	nodeList := &corev1.NodeList{
+		Items: []corev1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-3"}},
+		},
+	}
+
+	recordNodeMetrics(plan, "os", nodeList, func(node corev1.Node) bool {
+		return node.Name != "node-3"
+	})
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(nodesUpgradeStatus.WithLabelValues("default", "metrics-plan", "os", nodeStateUpgraded)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(nodesUpgradeStatus.WithLabelValues("default", "metrics-plan", "os", nodeStatePending)))
+
+	deleteUpgradeMetrics(plan)
+}
+
+func TestRecordHelmChartOutcome(t *testing.T) {
+	conditionType := lifecyclev1alpha1.GetChartConditionType("Metal3")
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		Status: lifecyclev1alpha1.UpgradePlanStatus{
+			Conditions: []metav1.Condition{{Type: conditionType, Reason: lifecyclev1alpha1.UpgradeFailed}},
+		},
+	}
+	counter := helmChartUpgrades.WithLabelValues("metal3", lifecyclev1alpha1.UpgradeFailed)
+
+	recordHelmChartOutcome(plan, conditionType, "metal3", lifecyclev1alpha1.UpgradeInProgress)
+	assert.Equal(t, 1.0, testutil.ToFloat64(counter))
+
+	// Subsequent reconciliations do not count the same outcome again.
+	recordHelmChartOutcome(plan, conditionType, "metal3", lifecyclev1alpha1.UpgradeFailed)
+	assert.Equal(t, 1.0, testutil.ToFloat64(counter))
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"slices"
+	"strings"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// nodeUpgradeStage holds the information necessary to evaluate
+// the upgrade phase of the nodes within the current upgrade stage.
+type nodeUpgradeStage struct {
+	// applying contains the names of the nodes which SUC is currently upgrading.
+	applying          sets.Set[string]
+	drainControlPlane bool
+	drainWorker       bool
+	isUpgraded        func(node corev1.Node) bool
+}
+
+// updateNodeStatuses refreshes the per-node status of the given plan based on the current state of the cluster nodes.
+func updateNodeStatuses(plan *lifecyclev1alpha1.UpgradePlan, nodeList *corev1.NodeList, stage *nodeUpgradeStage, now metav1.Time) {
+	statuses := make([]lifecyclev1alpha1.NodeUpgradeStatus, 0, len(nodeList.Items))
+
+	for _, node := range nodeList.Items {
+		status := lifecyclev1alpha1.NodeUpgradeStatus{
+			Name:                  node.Name,
+			Role:                  nodeRole(&node),
+			Phase:                 lifecyclev1alpha1.NodePhasePending,
+			InitialOSImage:        node.Status.NodeInfo.OSImage,
+			InitialKubeletVersion: node.Status.NodeInfo.KubeletVersion,
+			LastTransitionTime:    now,
+		}
+
+		idx := slices.IndexFunc(plan.Status.Nodes, func(s lifecyclev1alpha1.NodeUpgradeStatus) bool {
+			return s.Name == node.Name
+		})
+		if idx != -1 {
+			status = plan.Status.Nodes[idx]
+		}
+
+		status.OSImage = node.Status.NodeInfo.OSImage
+		status.KubeletVersion = node.Status.NodeInfo.KubeletVersion
+
+		drain := stage.drainWorker
+		if status.Role == lifecyclev1alpha1.NodeRoleControlPlane {
+			drain = stage.drainControlPlane
+		}
+
+		phase := nodePhase(&node, status.Phase, stage.applying.Has(node.Name), stage.isUpgraded(node), drain)
+		if phase != status.Phase {
+			switch phase {
+			case lifecyclev1alpha1.NodePhaseDone, lifecyclev1alpha1.NodePhaseFailed:
+				status.CompletionTime = &now
+			case lifecyclev1alpha1.NodePhasePending:
+				status.StartTime = nil
+				status.CompletionTime = nil
+			default:
+				if !isNodeUpgradeActive(status.Phase) {
+					status.StartTime = &now
+					status.CompletionTime = nil
+				}
+			}
+
+			status.Phase = phase
+			status.LastTransitionTime = now
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	slices.SortFunc(statuses, func(a, b lifecyclev1alpha1.NodeUpgradeStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	plan.Status.Nodes = statuses
+}
+
+// nodePhase evaluates the upgrade phase of a node within the current stage.
+// SUC cordons, optionally drains, upgrades and (for OS upgrades) reboots the nodes it is applying to.
+// A node which is no longer being applied to without being upgraded is considered failed.
+func nodePhase(node *corev1.Node, previous lifecyclev1alpha1.NodePhase, applying, upgraded, drain bool) lifecyclev1alpha1.NodePhase {
+	switch {
+	case upgraded:
+		return lifecyclev1alpha1.NodePhaseDone
+	case applying && !isNodeReady(node):
+		return lifecyclev1alpha1.NodePhaseRebooting
+	case applying && node.Spec.Unschedulable && drain:
+		return lifecyclev1alpha1.NodePhaseDraining
+	case applying && node.Spec.Unschedulable:
+		return lifecyclev1alpha1.NodePhaseCordoned
+	case applying:
+		return lifecyclev1alpha1.NodePhaseUpgrading
+	case isNodeUpgradeActive(previous) || previous == lifecyclev1alpha1.NodePhaseFailed:
+		return lifecyclev1alpha1.NodePhaseFailed
+	default:
+		return lifecyclev1alpha1.NodePhasePending
+	}
+}
+
+func isNodeUpgradeActive(phase lifecyclev1alpha1.NodePhase) bool {
+	switch phase {
+	case lifecyclev1alpha1.NodePhaseCordoned, lifecyclev1alpha1.NodePhaseDraining,
+		lifecyclev1alpha1.NodePhaseUpgrading, lifecyclev1alpha1.NodePhaseRebooting:
+		return true
+	default:
+		return false
+	}
+}
+
+// isNodeUpgradeStateChanged reports whether any of the node attributes used for tracking the upgrade differ.
+func isNodeUpgradeStateChanged(oldNode, newNode *corev1.Node) bool {
+	return oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable ||
+		isNodeReady(oldNode) != isNodeReady(newNode) ||
+		oldNode.Status.NodeInfo.OSImage != newNode.Status.NodeInfo.OSImage ||
+		oldNode.Status.NodeInfo.KubeletVersion != newNode.Status.NodeInfo.KubeletVersion
+}
+
+func nodeRole(node *corev1.Node) lifecyclev1alpha1.NodeRole {
+	if node.Labels[upgrade.ControlPlaneLabel] == "true" {
+		return lifecyclev1alpha1.NodeRoleControlPlane
+	}
+
+	return lifecyclev1alpha1.NodeRoleWorker
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	return slices.ContainsFunc(node.Status.Conditions, func(condition corev1.NodeCondition) bool {
+		return condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue
+	})
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestNodePhase(t *testing.T) {
+	readyNode := func(unschedulable bool) *corev1.Node {
+		return &corev1.Node{
+			Spec: corev1.NodeSpec{Unschedulable: unschedulable},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		node          *corev1.Node
+		previous      lifecyclev1alpha1.NodePhase
+		applying      bool
+		upgraded      bool
+		drain         bool
+		expectedPhase lifecyclev1alpha1.NodePhase
+	}{
+		{
+			name:          "Not yet applying",
+			node:          readyNode(false),
+			previous:      lifecyclev1alpha1.NodePhasePending,
+			expectedPhase: lifecyclev1alpha1.NodePhasePending,
+		},
+		{
+			name:          "Applying without cordon",
+			node:          readyNode(false),
+			previous:      lifecyclev1alpha1.NodePhasePending,
+			applying:      true,
+			expectedPhase: lifecyclev1alpha1.NodePhaseUpgrading,
+		},
+		{
+			name:          "Cordoned",
+			node:          readyNode(true),
+			previous:      lifecyclev1alpha1.NodePhaseUpgrading,
+			applying:      true,
+			expectedPhase: lifecyclev1alpha1.NodePhaseCordoned,
+		},
+		{
+			name:          "Draining",
+			node:          readyNode(true),
+			previous:      lifecyclev1alpha1.NodePhaseUpgrading,
+			applying:      true,
+			drain:         true,
+			expectedPhase: lifecyclev1alpha1.NodePhaseDraining,
+		},
+		{
+			name:          "Rebooting",
+			node:          &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}},
+			previous:      lifecyclev1alpha1.NodePhaseDraining,
+			applying:      true,
+			drain:         true,
+			expectedPhase: lifecyclev1alpha1.NodePhaseRebooting,
+		},
+		{
+			name:          "Done",
+			node:          readyNode(false),
+			previous:      lifecyclev1alpha1.NodePhaseRebooting,
+			upgraded:      true,
+			expectedPhase: lifecyclev1alpha1.NodePhaseDone,
+		},
+		{
+			name:          "No longer applying without being upgraded",
+			node:          readyNode(true),
+			previous:      lifecyclev1alpha1.NodePhaseDraining,
+			expectedPhase: lifecyclev1alpha1.NodePhaseFailed,
+		},
+		{
+			name:          "Next stage",
+			node:          readyNode(false),
+			previous:      lifecyclev1alpha1.NodePhaseDone,
+			expectedPhase: lifecyclev1alpha1.NodePhasePending,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			phase := nodePhase(test.node, test.previous, test.applying, test.upgraded, test.drain)
+			assert.Equal(t, test.expectedPhase, phase)
+		})
+	}
+}
+
+func TestUpdateNodeStatuses(t *testing.T) {
+	ready := []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	nodeList := &corev1.NodeList{
+		Items: []corev1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+				Status: corev1.NodeStatus{
+					Conditions: ready,
+					NodeInfo:   corev1.NodeSystemInfo{OSImage: "SL Micro 6.0", KubeletVersion: "v1.30.3+k3s1"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "control-plane-1", Labels: map[string]string{upgrade.ControlPlaneLabel: "true"}},
+				Spec:       corev1.NodeSpec{Unschedulable: true},
+				Status: corev1.NodeStatus{
+					Conditions: ready,
+					NodeInfo:   corev1.NodeSystemInfo{OSImage: "SL Micro 6.0", KubeletVersion: "v1.30.3+k3s1"},
+				},
+			},
+		},
+	}
+
+	stage := &nodeUpgradeStage{
+		applying:          sets.New("control-plane-1"),
+		drainControlPlane: true,
+		isUpgraded: func(node corev1.Node) bool {
+			return node.Status.NodeInfo.OSImage == "SL Micro 6.1" && !node.Spec.Unschedulable
+		},
+	}
+
+	plan := &lifecyclev1alpha1.UpgradePlan{}
+	start := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+	updateNodeStatuses(plan, nodeList, stage, start)
+
+	require.Len(t, plan.Status.Nodes, 2)
+
+	controlPlane := plan.Status.Nodes[0]
+	assert.Equal(t, "control-plane-1", controlPlane.Name)
+	assert.Equal(t, lifecyclev1alpha1.NodeRoleControlPlane, controlPlane.Role)
+	assert.Equal(t, lifecyclev1alpha1.NodePhaseDraining, controlPlane.Phase)
+	assert.Equal(t, &start, controlPlane.StartTime)
+	assert.Nil(t, controlPlane.CompletionTime)
+
+	worker := plan.Status.Nodes[1]
+	assert.Equal(t, "worker-1", worker.Name)
+	assert.Equal(t, lifecyclev1alpha1.NodeRoleWorker, worker.Role)
+	assert.Equal(t, lifecyclev1alpha1.NodePhasePending, worker.Phase)
+	assert.Nil(t, worker.StartTime)
+
+	// Control plane node finishes the upgrade.
+	nodeList.Items[1].Spec.Unschedulable = false
+	nodeList.Items[1].Status.NodeInfo.OSImage = "SL Micro 6.1"
+	stage.applying = sets.New[string]()
+
+	end := metav1.NewTime(start.Add(30 * time.Minute))
+	updateNodeStatuses(plan, nodeList, stage, end)
+
+	controlPlane = plan.Status.Nodes[0]
+	assert.Equal(t, lifecyclev1alpha1.NodePhaseDone, controlPlane.Phase)
+	assert.Equal(t, "SL Micro 6.0", controlPlane.InitialOSImage)
+	assert.Equal(t, "SL Micro 6.1", controlPlane.OSImage)
+	assert.Equal(t, &start, controlPlane.StartTime)
+	assert.Equal(t, &end, controlPlane.CompletionTime)
+	assert.Equal(t, end, controlPlane.LastTransitionTime)
+
+	// Removed nodes are no longer tracked.
+	nodeList.Items = nodeList.Items[1:]
+	updateNodeStatuses(plan, nodeList, stage, end)
+
+	require.Len(t, plan.Status.Nodes, 1)
+	assert.Equal(t, "control-plane-1", plan.Status.Nodes[0].Name)
+}
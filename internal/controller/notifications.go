@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/notification"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// notificationTimeout bounds the delivery of the notifications of a single reconciliation including retries.
+const notificationTimeout = 5 * time.Minute
+
+// notify sends the condition transitions of the given plan to the matching notification targets.
+// Deliveries happen in the background in order to not block the reconciliation, through a bounded queue
+// per target which preserves the order of the transitions.
+func (r *UpgradePlanReconciler) notify(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, previousConditions []metav1.Condition) {
+	if r.Notifier == nil {
+		return
+	}
+
+	events := conditionTransitions(plan, previousConditions, time.Now())
+	if len(events) == 0 {
+		return
+	}
+
+	logger := log.FromContext(ctx)
+
+	targets := &lifecyclev1alpha1.NotificationTargetList{}
+	if err := r.List(ctx, targets, client.InNamespace(plan.Namespace)); err != nil {
+		logger.Error(err, "failed to list notification targets")
+		return
+	}
+
+	for _, target := range targets.Items {
+		matching, err := matchingEvents(&target, plan, events)
+		if err != nil {
+			logger.Error(err, "failed to evaluate notification target", "notificationTarget", target.Name)
+			continue
+		} else if len(matching) == 0 {
+			continue
+		}
+
+		signingKey, err := r.signingKey(ctx, &target)
+		if err != nil {
+			r.Recorder.Eventf(plan, corev1.EventTypeWarning, lifecyclev1alpha1.NotificationFailedReason,
+				"Notification target %s is misconfigured: %v", target.Name, err)
+			continue
+		}
+
+		// Detach the delivery from the reconciliation while retaining the logger.
+		deliveryCtx := log.IntoContext(context.Background(), logger)
+		planCopy := plan.DeepCopy()
+		targetName, url := target.Name, target.Spec.URL
+
+		if !r.notifications.Enqueue(target.Namespace+"/"+target.Name, func() {
+			r.deliver(deliveryCtx, planCopy, targetName, url, signingKey, matching)
+		}) {
+			r.Recorder.Eventf(plan, corev1.EventTypeWarning, lifecyclev1alpha1.NotificationFailedReason,
+				"Dropped %d notifications for %s as its delivery queue is full", len(matching), target.Name)
+		}
+	}
+}
+
+func (r *UpgradePlanReconciler) deliver(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, targetName, url string, signingKey []byte, events []notification.Event) {
+	ctx, cancel := context.WithTimeout(ctx, notificationTimeout)
+	defer cancel()
+
+	logger := log.FromContext(ctx)
+
+	for _, event := range events {
+		if err := r.Notifier.Send(ctx, url, signingKey, &event); err != nil {
+			logger.Error(err, "failed to deliver notification", "notificationTarget", targetName, "condition", event.Condition)
+			r.Recorder.Eventf(plan, corev1.EventTypeWarning, lifecyclev1alpha1.NotificationFailedReason,
+				"Failed to notify %s about %s transition to %s: %v", targetName, event.Condition, event.Reason, err)
+		}
+	}
+}
+
+func (r *UpgradePlanReconciler) signingKey(ctx context.Context, target *lifecyclev1alpha1.NotificationTarget) ([]byte, error) {
+	ref := target.Spec.SigningSecretRef
+	if ref == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: target.Namespace}, secret); err != nil {
+		return nil, fmt.Errorf("retrieving signing secret: %w", err)
+	}
+
+	key, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("signing secret %s does not contain key %s", ref.Name, ref.Key)
+	}
+
+	return key, nil
+}
+
+// conditionTransitions returns an event for each condition of the plan whose status or reason differs from its previous state.
+func conditionTransitions(plan *lifecyclev1alpha1.UpgradePlan, previousConditions []metav1.Condition, now time.Time) []notification.Event {
+	var events []notification.Event
+
+	for _, condition := range plan.Status.Conditions {
+		var previousReason string
+
+		previous := meta.FindStatusCondition(previousConditions, condition.Type)
+		if previous != nil {
+			if previous.Status == condition.Status && previous.Reason == condition.Reason {
+				continue
+			}
+			previousReason = previous.Reason
+		}
+
+		events = append(events, notification.Event{
+			UpgradePlan:    plan.Name,
+			Namespace:      plan.Namespace,
+			ReleaseVersion: plan.Spec.ReleaseVersion,
+			Condition:      condition.Type,
+			Status:         string(condition.Status),
+			Reason:         condition.Reason,
+			PreviousReason: previousReason,
+			Message:        condition.Message,
+			Timestamp:      now,
+		})
+	}
+
+	return events
+}
+
+// matchingEvents filters the given events according to the filters of the notification target.
+func matchingEvents(target *lifecyclev1alpha1.NotificationTarget, plan *lifecyclev1alpha1.UpgradePlan, events []notification.Event) ([]notification.Event, error) {
+	if target.Spec.UpgradePlanSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(target.Spec.UpgradePlanSelector)
+		if err != nil {
+			return nil, fmt.Errorf("parsing upgrade plan selector: %w", err)
+		}
+
+		if !selector.Matches(labels.Set(plan.Labels)) {
+			return nil, nil
+		}
+	}
+
+	var matching []notification.Event
+	for _, event := range events {
+		if len(target.Spec.Conditions) != 0 && !slices.Contains(target.Spec.Conditions, event.Condition) {
+			continue
+		}
+
+		if len(target.Spec.Reasons) != 0 && !slices.Contains(target.Spec.Reasons, event.Reason) {
+			continue
+		}
+
+		matching = append(matching, event)
+	}
+
+	return matching, nil
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/notification"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConditionTransitions(t *testing.T) {
+	now := time.Now()
+
+	plan := newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeInProgress,
+		lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradePending,
+	})
+	previous := newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeInProgress,
+	}).Status.Conditions
+
+	events := conditionTransitions(plan, previous, now)
+	require.Len(t, events, 1)
+	assert.Equal(t, lifecyclev1alpha1.KubernetesUpgradedCondition, events[0].Condition)
+	assert.Equal(t, lifecyclev1alpha1.UpgradePending, events[0].Reason)
+	assert.Empty(t, events[0].PreviousReason)
+
+	previous = plan.Status.Conditions
+	plan = newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeSucceeded,
+		lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradePending,
+	})
+
+	events = conditionTransitions(plan, previous, now)
+	require.Len(t, events, 1)
+	assert.Equal(t, notification.Event{
+		ReleaseVersion: "3.1.0",
+		Condition:      lifecyclev1alpha1.OperatingSystemUpgradedCondition,
+		Status:         string(metav1.ConditionTrue),
+		Reason:         lifecyclev1alpha1.UpgradeSucceeded,
+		PreviousReason: lifecyclev1alpha1.UpgradeInProgress,
+		Timestamp:      now,
+	}, events[0])
+}
+
+func TestMatchingEvents(t *testing.T) {
+	plan := &lifecyclev1alpha1.UpgradePlan{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"env": "prod"}}}
+	events := []notification.Event{
+		{Condition: lifecyclev1alpha1.OperatingSystemUpgradedCondition, Reason: lifecyclev1alpha1.UpgradeInProgress},
+		{Condition: lifecyclev1alpha1.OperatingSystemUpgradedCondition, Reason: lifecyclev1alpha1.UpgradeFailed},
+		{Condition: lifecyclev1alpha1.KubernetesUpgradedCondition, Reason: lifecyclev1alpha1.UpgradeFailed},
+	}
+
+	tests := []struct {
+		name     string
+		spec     lifecyclev1alpha1.NotificationTargetSpec
+		expected []notification.Event
+	}{
+		{
+			name:     "No filters",
+			expected: events,
+		},
+		{
+			name:     "Condition filter",
+			spec:     lifecyclev1alpha1.NotificationTargetSpec{Conditions: []string{lifecyclev1alpha1.KubernetesUpgradedCondition}},
+			expected: events[2:],
+		},
+		{
+			name:     "Reason filter",
+			spec:     lifecyclev1alpha1.NotificationTargetSpec{Reasons: []string{lifecyclev1alpha1.UpgradeFailed}},
+			expected: events[1:],
+		},
+		{
+			name: "Matching selector",
+			spec: lifecyclev1alpha1.NotificationTargetSpec{
+				UpgradePlanSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+				Reasons:             []string{lifecyclev1alpha1.UpgradeInProgress},
+			},
+			expected: events[:1],
+		},
+		{
+			name: "Non-matching selector",
+			spec: lifecyclev1alpha1.NotificationTargetSpec{
+				UpgradePlanSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matching, err := matchingEvents(&lifecyclev1alpha1.NotificationTarget{Spec: test.spec}, plan, events)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, matching)
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, lifecyclev1alpha1.AddToScheme(scheme))
+
+	received := make(chan *http.Request, 5)
+	payloads := make(chan notification.Event, 5)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event notification.Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- r
+		payloads <- event
+	}))
+	defer server.Close()
+
+	namespace := "upgrade-controller-system"
+	objects := []client.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: namespace},
+			Data:       map[string][]byte{"key": []byte("secret")},
+		},
+		&lifecyclev1alpha1.NotificationTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: "failures", Namespace: namespace},
+			Spec: lifecyclev1alpha1.NotificationTargetSpec{
+				URL:              server.URL,
+				SigningSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "signing-key"}, Key: "key"},
+				Reasons:          []string{lifecyclev1alpha1.UpgradeFailed},
+			},
+		},
+		&lifecyclev1alpha1.NotificationTarget{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-namespace", Namespace: "default"},
+			Spec:       lifecyclev1alpha1.NotificationTargetSpec{URL: server.URL},
+		},
+	}
+
+	r := &UpgradePlanReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Recorder: record.NewFakeRecorder(5),
+		Notifier: &notification.Sender{
+			Client:  server.Client(),
+			Backoff: wait.Backoff{Duration: time.Millisecond, Steps: 1},
+		},
+	}
+
+	previous := newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeInProgress,
+	}).Status.Conditions
+	plan := newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeFailed,
+		lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradePending,
+	})
+	plan.Name = "upgrade-plan"
+	plan.Namespace = namespace
+
+	r.notify(context.Background(), plan, previous)
+
+	select {
+	case req := <-received:
+		event := <-payloads
+		assert.Equal(t, lifecyclev1alpha1.OperatingSystemUpgradedCondition, req.Header.Get(notification.EventHeader))
+		assert.NotEmpty(t, req.Header.Get(notification.SignatureHeader))
+		assert.Equal(t, "upgrade-plan", event.UpgradePlan)
+		assert.Equal(t, lifecyclev1alpha1.UpgradeFailed, event.Reason)
+		assert.Equal(t, lifecyclev1alpha1.UpgradeInProgress, event.PreviousReason)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not delivered")
+	}
+
+	select {
+	case req := <-received:
+		t.Fatalf("unexpected notification: %s", req.Header.Get(notification.EventHeader))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,394 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// preflightRetryInterval is the delay before failed pre-flight checks are performed again.
+const preflightRetryInterval = time.Minute
+
+// PreflightCheck verifies a single precondition of an upgrade before its OS stage starts.
+type PreflightCheck interface {
+	// Name is the type of the condition reporting the result of the check.
+	Name() string
+	// Check returns a message describing the failure or an empty string if the check has passed.
+	Check(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, nodeList *corev1.NodeList) (string, error)
+}
+
+// DefaultPreflightChecks returns the built-in pre-flight checks reading the cluster state from the given reader.
+func DefaultPreflightChecks(reader client.Reader) []PreflightCheck {
+	return []PreflightCheck{
+		NodesReadyCheck{},
+		NodesSchedulableCheck{},
+		DeploymentAvailableCheck{
+			Reader:    reader,
+			CheckName: "SystemUpgradeControllerAvailable",
+			Key:       types.NamespacedName{Name: "system-upgrade-controller", Namespace: upgrade.SUCNamespace},
+		},
+		DeploymentAvailableCheck{
+			Reader:    reader,
+			CheckName: "HelmControllerAvailable",
+			Key:       types.NamespacedName{Name: "helm-controller", Namespace: upgrade.KubeSystemNamespace},
+			// Helm Controller is built into k3s and RKE2 unless deployed separately.
+			Optional: true,
+		},
+		HelmChartJobsCheck{Reader: reader},
+		DrainCapacityCheck{Reader: reader},
+	}
+}
+
+// reconcilePreflight performs the pre-flight checks and reports whether the OS upgrade may start.
+func (r *UpgradePlanReconciler) reconcilePreflight(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, nodeList *corev1.NodeList) (bool, error) {
+	var skip []string
+	var ignoreFailures bool
+	if plan.Spec.Preflight != nil {
+		skip = plan.Spec.Preflight.Skip
+		ignoreFailures = plan.Spec.Preflight.IgnoreFailures
+	}
+
+	var failed []string
+	var errs []error
+
+	for _, check := range r.PreflightChecks {
+		condition := metav1.Condition{Type: check.Name(), Status: metav1.ConditionTrue, Reason: lifecyclev1alpha1.PreflightCheckPassedReason}
+
+		if slices.Contains(skip, check.Name()) {
+			condition.Reason = lifecyclev1alpha1.PreflightCheckSkippedReason
+			condition.Message = "Check is skipped"
+			meta.SetStatusCondition(&plan.Status.PreflightChecks, condition)
+			continue
+		}
+
+		message, err := check.Check(ctx, plan, nodeList)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("performing pre-flight check %s: %w", check.Name(), err))
+			condition.Status = metav1.ConditionUnknown
+			condition.Reason = lifecyclev1alpha1.PreflightCheckErrorReason
+			condition.Message = err.Error()
+		case message != "":
+			failed = append(failed, check.Name())
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = lifecyclev1alpha1.PreflightCheckFailedReason
+			condition.Message = message
+		default:
+			condition.Message = "Check passed"
+		}
+
+		meta.SetStatusCondition(&plan.Status.PreflightChecks, condition)
+	}
+
+	if len(errs) != 0 {
+		return false, errors.Join(errs...)
+	}
+
+	previous := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.PreflightChecksCondition)
+
+	switch {
+	case len(failed) == 0:
+		setPreflightCondition(plan, metav1.ConditionTrue, lifecyclev1alpha1.PreflightChecksPassedReason, "All pre-flight checks passed")
+		return true, nil
+	case ignoreFailures:
+		setPreflightCondition(plan, metav1.ConditionTrue, lifecyclev1alpha1.PreflightChecksOverriddenReason,
+			fmt.Sprintf("Ignoring failed pre-flight checks: %s", strings.Join(failed, ", ")))
+		return true, nil
+	}
+
+	message := fmt.Sprintf("Failed pre-flight checks: %s", strings.Join(failed, ", "))
+	setPreflightCondition(plan, metav1.ConditionFalse, lifecyclev1alpha1.PreflightChecksFailedReason, message)
+
+	if previous == nil || previous.Reason != lifecyclev1alpha1.PreflightChecksFailedReason || previous.Message != message {
+		r.Recorder.Eventf(plan, corev1.EventTypeWarning, lifecyclev1alpha1.PreflightChecksFailedReason, "OS upgrade is blocked. %s", message)
+	}
+
+	return false, nil
+}
+
+// isPreflightPending reports whether the pre-flight checks of the current generation have yet to pass.
+// Checks are only performed before the OS upgrade starts.
+func (r *UpgradePlanReconciler) isPreflightPending(plan *lifecyclev1alpha1.UpgradePlan) bool {
+	if len(r.PreflightChecks) == 0 || meta.IsStatusConditionTrue(plan.Status.Conditions, lifecyclev1alpha1.PreflightChecksCondition) {
+		return false
+	}
+
+	condition := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.OperatingSystemUpgradedCondition)
+	return condition != nil && condition.Reason == lifecyclev1alpha1.UpgradePending
+}
+
+func setPreflightCondition(plan *lifecyclev1alpha1.UpgradePlan, status metav1.ConditionStatus, reason, message string) {
+	condition := metav1.Condition{Type: lifecyclev1alpha1.PreflightChecksCondition, Status: status, Reason: reason, Message: message}
+	meta.SetStatusCondition(&plan.Status.Conditions, condition)
+}
+
+// NodesReadyCheck verifies that all cluster nodes are Ready.
+type NodesReadyCheck struct{}
+
+func (NodesReadyCheck) Name() string {
+	return "NodesReady"
+}
+
+func (NodesReadyCheck) Check(_ context.Context, _ *lifecyclev1alpha1.UpgradePlan, nodeList *corev1.NodeList) (string, error) {
+	var notReady []string
+	for _, node := range nodeList.Items {
+		if !isNodeReady(&node) {
+			notReady = append(notReady, node.Name)
+		}
+	}
+
+	if len(notReady) != 0 {
+		return fmt.Sprintf("Nodes are not ready: %s", strings.Join(notReady, ", ")), nil
+	}
+
+	return "", nil
+}
+
+// NodesSchedulableCheck verifies that no cluster node is cordoned.
+type NodesSchedulableCheck struct{}
+
+func (NodesSchedulableCheck) Name() string {
+	return "NodesSchedulable"
+}
+
+func (NodesSchedulableCheck) Check(_ context.Context, _ *lifecyclev1alpha1.UpgradePlan, nodeList *corev1.NodeList) (string, error) {
+	var cordoned []string
+	for _, node := range nodeList.Items {
+		if node.Spec.Unschedulable {
+			cordoned = append(cordoned, node.Name)
+		}
+	}
+
+	if len(cordoned) != 0 {
+		return fmt.Sprintf("Nodes are cordoned: %s", strings.Join(cordoned, ", ")), nil
+	}
+
+	return "", nil
+}
+
+// DeploymentAvailableCheck verifies that a deployment is available.
+type DeploymentAvailableCheck struct {
+	Reader    client.Reader
+	CheckName string
+	Key       types.NamespacedName
+	// Optional deployments pass the check if they do not exist.
+	Optional bool
+}
+
+func (c DeploymentAvailableCheck) Name() string {
+	return c.CheckName
+}
+
+func (c DeploymentAvailableCheck) Check(ctx context.Context, _ *lifecyclev1alpha1.UpgradePlan, _ *corev1.NodeList) (string, error) {
+	deployment := &appsv1.Deployment{}
+	if err := c.Reader.Get(ctx, c.Key, deployment); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("getting deployment: %w", err)
+		}
+
+		if c.Optional {
+			return "", nil
+		}
+
+		return fmt.Sprintf("Deployment %s does not exist", c.Key), nil
+	}
+
+	available := slices.ContainsFunc(deployment.Status.Conditions, func(condition appsv1.DeploymentCondition) bool {
+		return condition.Type == appsv1.DeploymentAvailable && condition.Status == corev1.ConditionTrue
+	})
+	if !available {
+		return fmt.Sprintf("Deployment %s is not available", c.Key), nil
+	}
+
+	return "", nil
+}
+
+// HelmChartJobsCheck verifies that none of the jobs installing or upgrading HelmCharts has failed.
+type HelmChartJobsCheck struct {
+	Reader client.Reader
+}
+
+func (HelmChartJobsCheck) Name() string {
+	return "HelmChartJobsHealthy"
+}
+
+func (c HelmChartJobsCheck) Check(ctx context.Context, _ *lifecyclev1alpha1.UpgradePlan, _ *corev1.NodeList) (string, error) {
+	charts := &helmcattlev1.HelmChartList{}
+	if err := c.Reader.List(ctx, charts); err != nil {
+		return "", fmt.Errorf("listing helm charts: %w", err)
+	}
+
+	var failing []string
+	for _, chart := range charts.Items {
+		if chart.Status.JobName == "" {
+			continue
+		}
+
+		job := &batchv1.Job{}
+		if err := c.Reader.Get(ctx, types.NamespacedName{Name: chart.Status.JobName, Namespace: chart.Namespace}, job); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return "", fmt.Errorf("getting job of helm chart %s: %w", chart.Name, err)
+		}
+
+		if isJobFailed(job.Status.Conditions) {
+			failing = append(failing, fmt.Sprintf("%s/%s", chart.Namespace, chart.Name))
+		}
+	}
+
+	if len(failing) != 0 {
+		return fmt.Sprintf("Jobs of HelmCharts are failing: %s", strings.Join(failing, ", ")), nil
+	}
+
+	return "", nil
+}
+
+// DrainCapacityCheck verifies that the pods of any single drained node fit into the unrequested
+// allocatable CPU and memory of the remaining schedulable nodes. Nodes whose role is not drained are not considered.
+// Only the total capacity is taken into account, scheduling constraints of the pods are not.
+type DrainCapacityCheck struct {
+	Reader client.Reader
+}
+
+func (DrainCapacityCheck) Name() string {
+	return "DrainCapacitySufficient"
+}
+
+func (c DrainCapacityCheck) Check(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, nodeList *corev1.NodeList) (string, error) {
+	drainControlPlane, drainWorker := parseDrainOptions(nodeList, plan)
+	if !drainControlPlane && !drainWorker {
+		return "", nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := c.Reader.List(ctx, pods); err != nil {
+		return "", fmt.Errorf("listing pods: %w", err)
+	}
+
+	return drainCapacityShortage(nodeList, pods, drainControlPlane, drainWorker), nil
+}
+
+type nodeResources struct {
+	cpu    resource.Quantity
+	memory resource.Quantity
+}
+
+func (r *nodeResources) add(requests corev1.ResourceList) {
+	r.cpu.Add(requests[corev1.ResourceCPU])
+	r.memory.Add(requests[corev1.ResourceMemory])
+}
+
+func drainCapacityShortage(nodeList *corev1.NodeList, pods *corev1.PodList, drainControlPlane, drainWorker bool) string {
+	requested := map[string]*nodeResources{}
+	evicted := map[string]*nodeResources{}
+	for _, node := range nodeList.Items {
+		requested[node.Name] = &nodeResources{}
+		evicted[node.Name] = &nodeResources{}
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		if _, ok := requested[pod.Spec.NodeName]; !ok {
+			continue
+		}
+
+		requests := podRequests(&pod)
+		requested[pod.Spec.NodeName].add(requests)
+
+		if isPodEvicted(&pod) {
+			evicted[pod.Spec.NodeName].add(requests)
+		}
+	}
+
+	free := map[string]*nodeResources{}
+	for _, node := range nodeList.Items {
+		if node.Spec.Unschedulable || !isNodeReady(&node) {
+			continue
+		}
+
+		resources := &nodeResources{
+			cpu:    node.Status.Allocatable.Cpu().DeepCopy(),
+			memory: node.Status.Allocatable.Memory().DeepCopy(),
+		}
+		resources.cpu.Sub(requested[node.Name].cpu)
+		resources.memory.Sub(requested[node.Name].memory)
+		free[node.Name] = resources
+	}
+
+	var insufficient []string
+	for _, node := range nodeList.Items {
+		isControlPlane := node.Labels[upgrade.ControlPlaneLabel] == "true"
+		if (isControlPlane && !drainControlPlane) || (!isControlPlane && !drainWorker) {
+			continue
+		}
+
+		available := &nodeResources{}
+		for name, resources := range free {
+			if name != node.Name {
+				available.cpu.Add(resources.cpu)
+				available.memory.Add(resources.memory)
+			}
+		}
+
+		if available.cpu.Cmp(evicted[node.Name].cpu) < 0 || available.memory.Cmp(evicted[node.Name].memory) < 0 {
+			insufficient = append(insufficient, node.Name)
+		}
+	}
+
+	if len(insufficient) != 0 {
+		return fmt.Sprintf("Remaining nodes lack the CPU or memory to host the pods of drained nodes: %s", strings.Join(insufficient, ", "))
+	}
+
+	return ""
+}
+
+// podRequests returns the effective resource requests of the given pod.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	total := &nodeResources{}
+	for _, container := range pod.Spec.Containers {
+		total.add(container.Resources.Requests)
+	}
+
+	// Init containers run sequentially before the regular ones.
+	for _, container := range pod.Spec.InitContainers {
+		if cpu := container.Resources.Requests[corev1.ResourceCPU]; cpu.Cmp(total.cpu) > 0 {
+			total.cpu = cpu.DeepCopy()
+		}
+		if memory := container.Resources.Requests[corev1.ResourceMemory]; memory.Cmp(total.memory) > 0 {
+			total.memory = memory.DeepCopy()
+		}
+	}
+
+	return corev1.ResourceList{corev1.ResourceCPU: total.cpu, corev1.ResourceMemory: total.memory}
+}
+
+// isPodEvicted reports whether the given pod is evicted when draining its node.
+// DaemonSet and static pods remain on the node.
+func isPodEvicted(pod *corev1.Pod) bool {
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+
+	controller := metav1.GetControllerOf(pod)
+	return controller == nil || controller.Kind != "DaemonSet"
+}
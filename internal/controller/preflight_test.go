@@ -0,0 +1,301 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newPreflightNode(name string, controlPlane, ready, unschedulable bool, cpu, memory string) corev1.Node {
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+
+	if controlPlane {
+		node.Labels[upgrade.ControlPlaneLabel] = "true"
+	}
+
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}
+
+	return node
+}
+
+func newPreflightPod(name, nodeName, cpu, memory string, owner *metav1.OwnerReference) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+
+	return pod
+}
+
+func TestNodeChecks(t *testing.T) {
+	nodeList := &corev1.NodeList{Items: []corev1.Node{
+		newPreflightNode("node-1", true, true, false, "4", "8Gi"),
+		newPreflightNode("node-2", false, false, false, "4", "8Gi"),
+		newPreflightNode("node-3", false, true, true, "4", "8Gi"),
+	}}
+
+	message, err := NodesReadyCheck{}.Check(context.Background(), nil, nodeList)
+	require.NoError(t, err)
+	assert.Equal(t, "Nodes are not ready: node-2", message)
+
+	message, err = NodesSchedulableCheck{}.Check(context.Background(), nil, nodeList)
+	require.NoError(t, err)
+	assert.Equal(t, "Nodes are cordoned: node-3", message)
+
+	nodeList.Items = nodeList.Items[:1]
+
+	message, err = NodesReadyCheck{}.Check(context.Background(), nil, nodeList)
+	require.NoError(t, err)
+	assert.Empty(t, message)
+
+	message, err = NodesSchedulableCheck{}.Check(context.Background(), nil, nodeList)
+	require.NoError(t, err)
+	assert.Empty(t, message)
+}
+
+func TestDeploymentAvailableCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	available := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "system-upgrade-controller", Namespace: upgrade.SUCNamespace},
+		Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{
+			{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue},
+		}},
+	}
+	unavailable := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "unavailable", Namespace: upgrade.SUCNamespace},
+		Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{
+			{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionFalse},
+		}},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(available, unavailable).Build()
+
+	tests := []struct {
+		name            string
+		key             types.NamespacedName
+		optional        bool
+		expectedMessage string
+	}{
+		{
+			name: "Available deployment",
+			key:  client.ObjectKeyFromObject(available),
+		},
+		{
+			name:            "Unavailable deployment",
+			key:             client.ObjectKeyFromObject(unavailable),
+			expectedMessage: "Deployment cattle-system/unavailable is not available",
+		},
+		{
+			name:            "Missing deployment",
+			key:             types.NamespacedName{Name: "missing", Namespace: upgrade.SUCNamespace},
+			expectedMessage: "Deployment cattle-system/missing does not exist",
+		},
+		{
+			name:     "Missing optional deployment",
+			key:      types.NamespacedName{Name: "missing", Namespace: upgrade.SUCNamespace},
+			optional: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check := DeploymentAvailableCheck{Reader: c, CheckName: "Deployment", Key: test.key, Optional: test.optional}
+
+			message, err := check.Check(context.Background(), nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedMessage, message)
+		})
+	}
+}
+
+func TestHelmChartJobsCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, helmcattlev1.AddToScheme(scheme))
+
+	newChart := func(name, jobName string) *helmcattlev1.HelmChart {
+		chart := &helmcattlev1.HelmChart{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: upgrade.KubeSystemNamespace}}
+		chart.Status.JobName = jobName
+		return chart
+	}
+
+	failedJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "helm-install-rancher", Namespace: upgrade.KubeSystemNamespace},
+		Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+		}},
+	}
+	completedJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "helm-install-neuvector", Namespace: upgrade.KubeSystemNamespace},
+		Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+		}},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newChart("rancher", failedJob.Name),
+		newChart("neuvector", completedJob.Name),
+		newChart("metallb", "helm-install-metallb"),
+		failedJob,
+		completedJob,
+	).Build()
+
+	message, err := HelmChartJobsCheck{Reader: c}.Check(context.Background(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Jobs of HelmCharts are failing: kube-system/rancher", message)
+}
+
+func TestDrainCapacityShortage(t *testing.T) {
+	daemonSet := &metav1.OwnerReference{Kind: "DaemonSet", Name: "agent", Controller: ptr.To(true)}
+
+	nodeList := &corev1.NodeList{Items: []corev1.Node{
+		newPreflightNode("control-plane", true, true, false, "4", "8Gi"),
+		newPreflightNode("worker-1", false, true, false, "4", "8Gi"),
+		newPreflightNode("worker-2", false, true, false, "4", "8Gi"),
+	}}
+
+	pods := &corev1.PodList{Items: []corev1.Pod{
+		newPreflightPod("control-plane-app", "control-plane", "3", "2Gi", nil),
+		newPreflightPod("app-1", "worker-1", "1", "2Gi", nil),
+		newPreflightPod("agent-1", "worker-1", "2", "2Gi", daemonSet),
+		newPreflightPod("app-2", "worker-2", "1", "2Gi", nil),
+	}}
+
+	// Free capacity: control-plane 1 CPU, worker-1 1 CPU, worker-2 3 CPU.
+	assert.Empty(t, drainCapacityShortage(nodeList, pods, false, true))
+
+	// Draining the control plane requires 3 CPU while the workers only have 4 CPU left in total.
+	assert.Empty(t, drainCapacityShortage(nodeList, pods, true, true))
+
+	// Cordoned nodes do not provide capacity.
+	nodeList.Items[2].Spec.Unschedulable = true
+	assert.Equal(t, "Remaining nodes lack the CPU or memory to host the pods of drained nodes: control-plane",
+		drainCapacityShortage(nodeList, pods, true, false))
+
+	nodeList.Items[2].Spec.Unschedulable = false
+	pods.Items = append(pods.Items, newPreflightPod("app-3", "worker-2", "2", "1Gi", nil))
+	assert.Equal(t, "Remaining nodes lack the CPU or memory to host the pods of drained nodes: control-plane, worker-2",
+		drainCapacityShortage(nodeList, pods, true, true))
+}
+
+type fakePreflightCheck struct {
+	name    string
+	message string
+}
+
+func (c fakePreflightCheck) Name() string {
+	return c.name
+}
+
+func (c fakePreflightCheck) Check(context.Context, *lifecyclev1alpha1.UpgradePlan, *corev1.NodeList) (string, error) {
+	return c.message, nil
+}
+
+func TestReconcilePreflight(t *testing.T) {
+	recorder := record.NewFakeRecorder(5)
+	r := &UpgradePlanReconciler{
+		Recorder: recorder,
+		PreflightChecks: []PreflightCheck{
+			fakePreflightCheck{name: "NodesReady"},
+			fakePreflightCheck{name: "DrainCapacitySufficient", message: "Not enough capacity"},
+		},
+	}
+
+	plan := newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradePending,
+	})
+	assert.True(t, r.isPreflightPending(plan))
+
+	passed, err := r.reconcilePreflight(context.Background(), plan, &corev1.NodeList{})
+	require.NoError(t, err)
+	assert.False(t, passed)
+	assert.True(t, r.isPreflightPending(plan))
+
+	condition := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.PreflightChecksCondition)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, lifecyclev1alpha1.PreflightChecksFailedReason, condition.Reason)
+	assert.Equal(t, "Failed pre-flight checks: DrainCapacitySufficient", condition.Message)
+
+	require.Len(t, plan.Status.PreflightChecks, 2)
+	assert.Equal(t, lifecyclev1alpha1.PreflightCheckPassedReason, plan.Status.PreflightChecks[0].Reason)
+	assert.Equal(t, lifecyclev1alpha1.PreflightCheckFailedReason, plan.Status.PreflightChecks[1].Reason)
+	assert.Equal(t, "Not enough capacity", plan.Status.PreflightChecks[1].Message)
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Warning PreflightChecksFailed OS upgrade is blocked")
+
+	// Unchanged failures are not reported again.
+	_, err = r.reconcilePreflight(context.Background(), plan, &corev1.NodeList{})
+	require.NoError(t, err)
+	assert.Empty(t, recorder.Events)
+
+	plan.Spec.Preflight = &lifecyclev1alpha1.PreflightChecks{IgnoreFailures: true}
+	passed, err = r.reconcilePreflight(context.Background(), plan, &corev1.NodeList{})
+	require.NoError(t, err)
+	assert.True(t, passed)
+	assert.True(t, meta.IsStatusConditionTrue(plan.Status.Conditions, lifecyclev1alpha1.PreflightChecksCondition))
+	assert.Equal(t, lifecyclev1alpha1.PreflightChecksOverriddenReason,
+		meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.PreflightChecksCondition).Reason)
+	assert.False(t, r.isPreflightPending(plan))
+
+	plan.Spec.Preflight = &lifecyclev1alpha1.PreflightChecks{Skip: []string{"DrainCapacitySufficient"}}
+	passed, err = r.reconcilePreflight(context.Background(), plan, &corev1.NodeList{})
+	require.NoError(t, err)
+	assert.True(t, passed)
+	assert.Equal(t, lifecyclev1alpha1.PreflightChecksPassedReason,
+		meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.PreflightChecksCondition).Reason)
+	assert.Equal(t, lifecyclev1alpha1.PreflightCheckSkippedReason, plan.Status.PreflightChecks[1].Reason)
+
+	// Checks are not performed once the OS upgrade has started.
+	meta.RemoveStatusCondition(&plan.Status.Conditions, lifecyclev1alpha1.PreflightChecksCondition)
+	setInProgressCondition(plan, lifecyclev1alpha1.OperatingSystemUpgradedCondition, "Control plane nodes are being upgraded")
+	assert.False(t, r.isPreflightPending(plan))
+}
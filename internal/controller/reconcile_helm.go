@@ -2,35 +2,59 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
 	"github.com/suse-edge/upgrade-controller/internal/upgrade"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
-func (r *UpgradePlanReconciler) reconcileHelmChart(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan, chart *lifecyclev1alpha1.HelmChart) (ctrl.Result, error) {
+func (r *UpgradePlanReconciler) reconcileHelmChart(
+	ctx context.Context,
+	upgradePlan *lifecyclev1alpha1.UpgradePlan,
+	chart *lifecyclev1alpha1.HelmChart,
+	kubernetesVersion string,
+) (result ctrl.Result, err error) {
+	ctx, span := r.startSpan(ctx, "reconcileHelmChart", chartAttribute.String(chart.ReleaseName))
+	defer func() { endSpan(span, err) }()
+
 	conditionType := lifecyclev1alpha1.GetChartConditionType(chart.PrettyName)
 
+	var previousReason string
+	if condition := meta.FindStatusCondition(upgradePlan.Status.Conditions, conditionType); condition != nil {
+		previousReason = condition.Reason
+	}
+	defer recordHelmChartOutcome(upgradePlan, conditionType, chart.ReleaseName, previousReason)
+
+	var validationErr *chartValidationError
+
 	if len(chart.DependencyCharts) != 0 {
 		for _, depChart := range chart.DependencyCharts {
-			depState, err := r.upgradeHelmChart(ctx, upgradePlan, &depChart)
-			if err != nil {
+			depState, err := r.upgradeHelmChart(ctx, upgradePlan, &depChart, kubernetesVersion)
+			if errors.As(err, &validationErr) {
+				setFailedCondition(upgradePlan, conditionType, validationErr.Error())
+				return ctrl.Result{Requeue: true}, nil
+			} else if err != nil {
 				return ctrl.Result{}, err
 			}
 
 			if depState != upgrade.ChartStateSucceeded && depState != upgrade.ChartStateVersionAlreadyInstalled {
 				setCondition, requeue := evaluateHelmChartState(depState)
-				setCondition(upgradePlan, conditionType, depState.FormattedMessage(depChart.ReleaseName))
+				setCondition(upgradePlan, conditionType, valuesDiffMessage(upgradePlan, depChart.ReleaseName, depState.FormattedMessage(depChart.ReleaseName)))
 
 				return ctrl.Result{Requeue: requeue}, nil
 			}
 		}
 	}
 
-	coreState, err := r.upgradeHelmChart(ctx, upgradePlan, chart)
-	if err != nil {
+	coreState, err := r.upgradeHelmChart(ctx, upgradePlan, chart, kubernetesVersion)
+	if errors.As(err, &validationErr) {
+		setFailedCondition(upgradePlan, conditionType, validationErr.Error())
+		return ctrl.Result{Requeue: true}, nil
+	} else if err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -41,15 +65,19 @@ func (r *UpgradePlanReconciler) reconcileHelmChart(ctx context.Context, upgradeP
 
 	if coreState != upgrade.ChartStateSucceeded && coreState != upgrade.ChartStateVersionAlreadyInstalled {
 		setCondition, requeue := evaluateHelmChartState(coreState)
-		setCondition(upgradePlan, conditionType, coreState.FormattedMessage(chart.ReleaseName))
+		setCondition(upgradePlan, conditionType, valuesDiffMessage(upgradePlan, chart.ReleaseName, coreState.FormattedMessage(chart.ReleaseName)))
 
 		return ctrl.Result{Requeue: requeue}, nil
 	}
 
 	if len(chart.AddonCharts) != 0 {
 		for _, addonChart := range chart.AddonCharts {
-			addonState, err := r.upgradeHelmChart(ctx, upgradePlan, &addonChart)
-			if err != nil {
+			addonState, err := r.upgradeHelmChart(ctx, upgradePlan, &addonChart, kubernetesVersion)
+			if errors.As(err, &validationErr) {
+				r.Recorder.Eventf(upgradePlan, corev1.EventTypeWarning, conditionType,
+					"'%s' upgraded successfully, but add-on component '%s' failed validation: %s", chart.ReleaseName, addonChart.ReleaseName, validationErr.Error())
+				continue
+			} else if err != nil {
 				return ctrl.Result{}, err
 			}
 
@@ -65,7 +93,7 @@ func (r *UpgradePlanReconciler) reconcileHelmChart(ctx context.Context, upgradeP
 					"'%s' add-on component successfully upgraded", addonChart.ReleaseName)
 			case upgrade.ChartStateInProgress:
 				// mark that current add-on chart upgrade is in progress
-				setInProgressCondition(upgradePlan, conditionType, addonState.FormattedMessage(addonChart.ReleaseName))
+				setInProgressCondition(upgradePlan, conditionType, valuesDiffMessage(upgradePlan, addonChart.ReleaseName, addonState.FormattedMessage(addonChart.ReleaseName)))
 				return ctrl.Result{Requeue: true}, nil
 			case upgrade.ChartStateUnknown:
 				return ctrl.Result{}, nil
@@ -75,6 +103,6 @@ func (r *UpgradePlanReconciler) reconcileHelmChart(ctx context.Context, upgradeP
 
 	// to avoid confusion, when upgrade has been done, use core component message in the component condition
 	setCondition, requeue := evaluateHelmChartState(coreState)
-	setCondition(upgradePlan, conditionType, coreState.FormattedMessage(chart.ReleaseName))
+	setCondition(upgradePlan, conditionType, valuesDiffMessage(upgradePlan, chart.ReleaseName, coreState.FormattedMessage(chart.ReleaseName)))
 	return ctrl.Result{Requeue: requeue}, nil
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"slices"
-	"strings"
 	"time"
 
 	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
@@ -14,9 +13,11 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -25,8 +26,12 @@ func (r *UpgradePlanReconciler) reconcileKubernetes(
 	ctx context.Context,
 	upgradePlan *lifecyclev1alpha1.UpgradePlan,
 	kubernetes *lifecyclev1alpha1.Kubernetes,
+	charts []lifecyclev1alpha1.HelmChart,
 	nodeList *corev1.NodeList,
-) (ctrl.Result, error) {
+) (result ctrl.Result, err error) {
+	ctx, span := r.startSpan(ctx, "reconcileKubernetes")
+	defer func() { endSpan(span, err) }()
+
 	nameSuffix := upgradePlan.Status.SUCNameSuffix
 
 	k8sDistro, err := targetKubernetesDistribution(nodeList, kubernetes)
@@ -34,28 +39,78 @@ func (r *UpgradePlanReconciler) reconcileKubernetes(
 		return ctrl.Result{}, fmt.Errorf("identifying target kubernetes distribution: %w", err)
 	}
 
+	if upgradePlan.Annotations[lifecyclev1alpha1.SkipVersionSkewCheckAnnotation] != "true" {
+		if skewErr := lifecyclev1alpha1.ValidateKubernetesVersionSkew(nodeList.Items, k8sDistro.Version); skewErr != nil {
+			setValidationFailedCondition(upgradePlan, lifecyclev1alpha1.KubernetesVersionSkewReason, skewErr.Error())
+			return ctrl.Result{}, nil
+		}
+	}
+
 	conditionType := lifecyclev1alpha1.KubernetesUpgradedCondition
 
 	identifierLabels := upgrade.PlanIdentifierLabels(upgradePlan.Name, upgradePlan.Namespace)
 	drainControlPlane, drainWorker := parseDrainOptions(nodeList, upgradePlan)
+
+	inspectNodes := func(nodes []corev1.Node) bool {
+		return isKubernetesUpgraded(nodes, k8sDistro.Version)
+	}
+	isUpgraded := func(node corev1.Node) bool {
+		return inspectNodes([]corev1.Node{node})
+	}
+	recordNodeMetrics(upgradePlan, "kubernetes", nodeList, isUpgraded)
+
+	stage := &nodeUpgradeStage{
+		applying:          sets.New[string](),
+		drainControlPlane: drainControlPlane,
+		drainWorker:       drainWorker,
+		isUpgraded:        isUpgraded,
+	}
+	var previousReason string
+	if condition := meta.FindStatusCondition(upgradePlan.Status.Conditions, conditionType); condition != nil {
+		previousReason = condition.Reason
+	}
+
+	defer func() {
+		updateNodeStatuses(upgradePlan, nodeList, stage, metav1.Now())
+		r.evaluateStall(upgradePlan, conditionType, lifecyclev1alpha1.UpgradePlanPhaseKubernetes, previousReason, time.Now())
+	}()
+
 	controlPlanePlan := upgrade.KubernetesControlPlanePlan(nameSuffix, k8sDistro.Version, drainControlPlane, identifierLabels)
 	if err = r.Get(ctx, client.ObjectKeyFromObject(controlPlanePlan), controlPlanePlan); err != nil {
 		if !errors.IsNotFound(err) {
 			return ctrl.Result{}, err
 		}
 
+		if proceed, err := r.scanRemovedAPIs(ctx, upgradePlan, charts, nodeList, k8sDistro.Version); err != nil {
+			return ctrl.Result{}, err
+		} else if !proceed {
+			return ctrl.Result{RequeueAfter: removedAPIsRetryInterval}, nil
+		}
+
+		if proceed, err := r.analyzeDrain(ctx, upgradePlan, controlPlanePlan, nodeList); err != nil {
+			return ctrl.Result{}, err
+		} else if !proceed {
+			return ctrl.Result{RequeueAfter: drainRefusedRetryInterval}, nil
+		}
+
 		setInProgressCondition(upgradePlan, conditionType, "Control plane nodes are being upgraded")
 		return ctrl.Result{}, r.createObject(ctx, upgradePlan, controlPlanePlan)
 	}
 
+	// Drain may have been disabled due to drain blockers.
+	stage.drainControlPlane = controlPlanePlan.Spec.Drain != nil
+	stage.applying.Insert(controlPlanePlan.Status.Applying...)
+	r.collectSUCFailureLogs(ctx, upgradePlan, "Kubernetes", controlPlanePlan.Name)
+
 	nodes, err := findMatchingNodes(nodeList, controlPlanePlan.Spec.NodeSelector)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if !isKubernetesUpgraded(nodes, k8sDistro.Version) {
+	applied, eventDriven := isSUCPlanApplied(controlPlanePlan, nodes, inspectNodes)
+	if !applied {
 		setInProgressCondition(upgradePlan, conditionType, "Control plane nodes are being upgraded")
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		return waitForNodes(upgradePlan, lifecyclev1alpha1.UpgradePlanPhaseKubernetes, eventDriven, time.Now()), nil
 	} else if controlPlaneOnlyCluster(nodeList) {
 		allUpgraded, waitingFor, err := r.getK8sCoreComponentsUpgradeStatus(ctx, k8sDistro.CoreComponents)
 		if err != nil {
@@ -78,18 +133,34 @@ func (r *UpgradePlanReconciler) reconcileKubernetes(
 			return ctrl.Result{}, err
 		}
 
+		if isUpgradeHalted(upgradePlan, conditionType, lifecyclev1alpha1.UpgradePlanPhaseKubernetes, time.Now()) {
+			// The stage remains stalled until its timeouts are extended or the stalled nodes complete their upgrade.
+			return ctrl.Result{RequeueAfter: haltedRetryInterval}, nil
+		}
+
+		if proceed, err := r.analyzeDrain(ctx, upgradePlan, workerPlan, nodeList); err != nil {
+			return ctrl.Result{}, err
+		} else if !proceed {
+			return ctrl.Result{RequeueAfter: drainRefusedRetryInterval}, nil
+		}
+
 		setInProgressCondition(upgradePlan, conditionType, "Worker nodes are being upgraded")
 		return ctrl.Result{}, r.createObject(ctx, upgradePlan, workerPlan)
 	}
 
+	stage.drainWorker = workerPlan.Spec.Drain != nil
+	stage.applying.Insert(workerPlan.Status.Applying...)
+	r.collectSUCFailureLogs(ctx, upgradePlan, "Kubernetes", workerPlan.Name)
+
 	nodes, err = findMatchingNodes(nodeList, workerPlan.Spec.NodeSelector)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if !isKubernetesUpgraded(nodes, k8sDistro.Version) {
+	applied, eventDriven = isSUCPlanApplied(workerPlan, nodes, inspectNodes)
+	if !applied {
 		setInProgressCondition(upgradePlan, conditionType, "Worker nodes are being upgraded")
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		return waitForNodes(upgradePlan, lifecyclev1alpha1.UpgradePlanPhaseKubernetes, eventDriven, time.Now()), nil
 	}
 
 	allUpgraded, waitingFor, err := r.getK8sCoreComponentsUpgradeStatus(ctx, k8sDistro.CoreComponents)
@@ -183,14 +254,12 @@ func targetKubernetesDistribution(nodeList *corev1.NodeList, kubernetes *lifecyc
 
 	kubeletVersion := nodeList.Items[0].Status.NodeInfo.KubeletVersion
 
-	switch {
-	case strings.Contains(kubeletVersion, "k3s"):
-		return &kubernetes.K3S, nil
-	case strings.Contains(kubeletVersion, "rke2"):
-		return &kubernetes.RKE2, nil
-	default:
+	distribution := kubernetes.Distribution(kubeletVersion)
+	if distribution == nil {
 		return nil, fmt.Errorf("unsupported kubernetes distribution detected in version %s", kubeletVersion)
 	}
+
+	return distribution, nil
 }
 
 func findMatchingNodes(nodeList *corev1.NodeList, nodeSelector *metav1.LabelSelector) ([]corev1.Node, error) {
@@ -215,6 +284,8 @@ func findMatchingNodes(nodeList *corev1.NodeList, nodeSelector *metav1.LabelSele
 	return targetNodes, nil
 }
 
+// isKubernetesUpgraded inspects the given nodes directly in order to determine whether they are upgraded.
+// Used as a fallback for SUC versions which do not report the completion of their plans.
 func isKubernetesUpgraded(nodes []corev1.Node, kubernetesVersion string) bool {
 	for _, node := range nodes {
 		var nodeReadyStatus corev1.ConditionStatus
@@ -228,8 +299,6 @@ func isKubernetesUpgraded(nodes []corev1.Node, kubernetesVersion string) bool {
 
 		if nodeReadyStatus != corev1.ConditionTrue || node.Spec.Unschedulable || node.Status.NodeInfo.KubeletVersion != kubernetesVersion {
 			// Upgrade is still in progress.
-			// TODO: Adjust to looking at the `Complete` condition of the
-			//  `plans.upgrade.cattle.io` resources once system-upgrade-controller v0.13.4 is released.
 			return false
 		}
 	}
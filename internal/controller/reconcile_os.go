@@ -9,6 +9,9 @@ import (
 	"github.com/suse-edge/upgrade-controller/internal/upgrade"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -19,7 +22,10 @@ func (r *UpgradePlanReconciler) reconcileOS(
 	releaseVersion string,
 	releaseOS *lifecyclev1alpha1.OperatingSystem,
 	nodeList *corev1.NodeList,
-) (ctrl.Result, error) {
+) (result ctrl.Result, err error) {
+	ctx, span := r.startSpan(ctx, "reconcileOS", releaseVersionAttribute.String(releaseVersion))
+	defer func() { endSpan(span, err) }()
+
 	identifierLabels := upgrade.PlanIdentifierLabels(upgradePlan.Name, upgradePlan.Namespace)
 	nameSuffix := upgradePlan.Status.SUCNameSuffix
 
@@ -39,24 +45,61 @@ func (r *UpgradePlanReconciler) reconcileOS(
 	conditionType := lifecyclev1alpha1.OperatingSystemUpgradedCondition
 
 	drainControlPlane, drainWorker := parseDrainOptions(nodeList, upgradePlan)
+
+	inspectNodes := func(nodes []corev1.Node) bool {
+		return isOSUpgraded(nodes, releaseOS.PrettyName)
+	}
+	isUpgraded := func(node corev1.Node) bool {
+		return inspectNodes([]corev1.Node{node})
+	}
+	recordNodeMetrics(upgradePlan, "os", nodeList, isUpgraded)
+
+	stage := &nodeUpgradeStage{
+		applying:          sets.New[string](),
+		drainControlPlane: drainControlPlane,
+		drainWorker:       drainWorker,
+		isUpgraded:        isUpgraded,
+	}
+	var previousReason string
+	if condition := meta.FindStatusCondition(upgradePlan.Status.Conditions, conditionType); condition != nil {
+		previousReason = condition.Reason
+	}
+
+	defer func() {
+		updateNodeStatuses(upgradePlan, nodeList, stage, metav1.Now())
+		r.evaluateStall(upgradePlan, conditionType, lifecyclev1alpha1.UpgradePlanPhaseOS, previousReason, time.Now())
+	}()
+
 	controlPlanePlan := upgrade.OSControlPlanePlan(nameSuffix, releaseVersion, secret.Name, releaseOS, drainControlPlane, identifierLabels)
 	if err = r.Get(ctx, client.ObjectKeyFromObject(controlPlanePlan), controlPlanePlan); err != nil {
 		if !errors.IsNotFound(err) {
 			return ctrl.Result{}, err
 		}
 
+		if proceed, err := r.analyzeDrain(ctx, upgradePlan, controlPlanePlan, nodeList); err != nil {
+			return ctrl.Result{}, err
+		} else if !proceed {
+			return ctrl.Result{RequeueAfter: drainRefusedRetryInterval}, nil
+		}
+
 		setInProgressCondition(upgradePlan, conditionType, "Control plane nodes are being upgraded")
 		return ctrl.Result{}, r.createObject(ctx, upgradePlan, controlPlanePlan)
 	}
 
+	// Drain may have been disabled due to drain blockers.
+	stage.drainControlPlane = controlPlanePlan.Spec.Drain != nil
+	stage.applying.Insert(controlPlanePlan.Status.Applying...)
+	r.collectSUCFailureLogs(ctx, upgradePlan, "OS", controlPlanePlan.Name)
+
 	nodes, err := findMatchingNodes(nodeList, controlPlanePlan.Spec.NodeSelector)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if !isOSUpgraded(nodes, releaseOS.PrettyName) {
+	applied, eventDriven := isSUCPlanApplied(controlPlanePlan, nodes, inspectNodes)
+	if !applied {
 		setInProgressCondition(upgradePlan, conditionType, "Control plane nodes are being upgraded")
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		return waitForNodes(upgradePlan, lifecyclev1alpha1.UpgradePlanPhaseOS, eventDriven, time.Now()), nil
 	} else if controlPlaneOnlyCluster(nodeList) {
 		setSuccessfulCondition(upgradePlan, conditionType, "All cluster nodes are upgraded")
 		return ctrl.Result{Requeue: true}, nil
@@ -68,24 +111,42 @@ func (r *UpgradePlanReconciler) reconcileOS(
 			return ctrl.Result{}, err
 		}
 
+		if isUpgradeHalted(upgradePlan, conditionType, lifecyclev1alpha1.UpgradePlanPhaseOS, time.Now()) {
+			// The stage remains stalled until its timeouts are extended or the stalled nodes complete their upgrade.
+			return ctrl.Result{RequeueAfter: haltedRetryInterval}, nil
+		}
+
+		if proceed, err := r.analyzeDrain(ctx, upgradePlan, workerPlan, nodeList); err != nil {
+			return ctrl.Result{}, err
+		} else if !proceed {
+			return ctrl.Result{RequeueAfter: drainRefusedRetryInterval}, nil
+		}
+
 		setInProgressCondition(upgradePlan, conditionType, "Worker nodes are being upgraded")
 		return ctrl.Result{}, r.createObject(ctx, upgradePlan, workerPlan)
 	}
 
+	stage.drainWorker = workerPlan.Spec.Drain != nil
+	stage.applying.Insert(workerPlan.Status.Applying...)
+	r.collectSUCFailureLogs(ctx, upgradePlan, "OS", workerPlan.Name)
+
 	nodes, err = findMatchingNodes(nodeList, workerPlan.Spec.NodeSelector)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if !isOSUpgraded(nodes, releaseOS.PrettyName) {
+	applied, eventDriven = isSUCPlanApplied(workerPlan, nodes, inspectNodes)
+	if !applied {
 		setInProgressCondition(upgradePlan, conditionType, "Worker nodes are being upgraded")
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		return waitForNodes(upgradePlan, lifecyclev1alpha1.UpgradePlanPhaseOS, eventDriven, time.Now()), nil
 	}
 
 	setSuccessfulCondition(upgradePlan, conditionType, "All cluster nodes are upgraded")
 	return ctrl.Result{Requeue: true}, nil
 }
 
+// isOSUpgraded inspects the given nodes directly in order to determine whether they are upgraded.
+// Used as a fallback for SUC versions which do not report the completion of their plans.
 func isOSUpgraded(nodes []corev1.Node, osPrettyName string) bool {
 	for _, node := range nodes {
 		var nodeReadyStatus corev1.ConditionStatus
@@ -99,8 +160,6 @@ func isOSUpgraded(nodes []corev1.Node, osPrettyName string) bool {
 
 		if nodeReadyStatus != corev1.ConditionTrue || node.Spec.Unschedulable || node.Status.NodeInfo.OSImage != osPrettyName {
 			// Upgrade is still in progress.
-			// TODO: Adjust to looking at the `Complete` condition of the
-			//  `plans.upgrade.cattle.io` resources once system-upgrade-controller v0.13.4 is released.
 			return false
 		}
 	}
@@ -2,49 +2,174 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
 	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// releaseVersionIndexKey is the field index used for looking up release manifests by version.
+const releaseVersionIndexKey = "spec.releaseVersion"
+
 var errReleaseManifestNotFound = fmt.Errorf("release manifest not found")
 
-func (r *UpgradePlanReconciler) retrieveReleaseManifest(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan) (*lifecyclev1alpha1.ReleaseManifest, error) {
+// releaseManifestValidationError indicates that the release manifest of an upgrade plan
+// cannot be determined without user intervention.
+type releaseManifestValidationError struct {
+	reason  string
+	message string
+}
+
+func (e *releaseManifestValidationError) Error() string {
+	return e.message
+}
+
+func (r *UpgradePlanReconciler) retrieveReleaseManifest(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan) (manifest *lifecyclev1alpha1.ReleaseManifest, err error) {
+	ctx, span := r.startSpan(ctx, "retrieveReleaseManifest", releaseVersionAttribute.String(currentReleaseVersion(upgradePlan)))
+	defer func() { endSpan(span, err) }()
+
+	// The referenced release manifest is only used for the target release.
+	if ref := upgradePlan.Spec.ReleaseManifestRef; ref != nil && !isIntermediateRelease(upgradePlan) {
+		return r.retrieveReferencedReleaseManifest(ctx, upgradePlan, ref)
+	}
+
+	namespaces := []string{upgradePlan.Namespace}
+	if r.CatalogNamespace != "" && r.CatalogNamespace != upgradePlan.Namespace {
+		namespaces = append(namespaces, r.CatalogNamespace)
+	}
+
+	for _, namespace := range namespaces {
+		manifest, err := findReleaseManifest(ctx, r.Client, namespace, currentReleaseVersion(upgradePlan))
+		if err != nil {
+			if errors.Is(err, errReleaseManifestNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		return manifest, nil
+	}
+
+	return nil, errReleaseManifestNotFound
+}
+
+// findReleaseManifest looks up the release manifest for the given release version in the given namespace.
+func findReleaseManifest(ctx context.Context, c client.Reader, namespace, releaseVersion string) (*lifecyclev1alpha1.ReleaseManifest, error) {
 	manifests := &lifecyclev1alpha1.ReleaseManifestList{}
-	listOpts := &client.ListOptions{
-		Namespace: upgradePlan.Namespace,
+	listOpts := []client.ListOption{
+		client.InNamespace(namespace),
+		client.MatchingFields{releaseVersionIndexKey: releaseVersion},
 	}
-	if err := r.List(ctx, manifests, listOpts); err != nil {
+	if err := c.List(ctx, manifests, listOpts...); err != nil {
 		return nil, fmt.Errorf("listing release manifests in cluster: %w", err)
 	}
 
-	for _, manifest := range manifests.Items {
-		if manifest.Spec.ReleaseVersion == upgradePlan.Spec.ReleaseVersion {
-			return &manifest, nil
+	switch len(manifests.Items) {
+	case 0:
+		return nil, errReleaseManifestNotFound
+	case 1:
+		return &manifests.Items[0], nil
+	default:
+		var names []string
+		for _, manifest := range manifests.Items {
+			names = append(names, fmt.Sprintf("%s/%s", manifest.Namespace, manifest.Name))
+		}
+		slices.Sort(names)
+
+		return nil, &releaseManifestValidationError{
+			reason: lifecyclev1alpha1.DuplicateReleaseManifestsReason,
+			message: fmt.Sprintf("Multiple release manifests found for release version %s: %s. Specify one via releaseManifestRef",
+				releaseVersion, strings.Join(names, ", ")),
 		}
 	}
+}
 
-	return nil, errReleaseManifestNotFound
+func (r *UpgradePlanReconciler) retrieveReferencedReleaseManifest(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan, ref *lifecyclev1alpha1.ReleaseManifestReference) (*lifecyclev1alpha1.ReleaseManifest, error) {
+	key := releaseManifestRefKey(upgradePlan, ref)
+
+	manifest := &lifecyclev1alpha1.ReleaseManifest{}
+	if err := r.Get(ctx, key, manifest); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("retrieving release manifest %s: %w", key, err)
+		}
+
+		return nil, &releaseManifestValidationError{
+			reason:  lifecyclev1alpha1.ReleaseManifestNotFoundReason,
+			message: fmt.Sprintf("Referenced release manifest %s does not exist", key),
+		}
+	}
+
+	if manifest.Spec.ReleaseVersion != upgradePlan.Spec.ReleaseVersion {
+		return nil, &releaseManifestValidationError{
+			reason: lifecyclev1alpha1.ReleaseManifestMismatchReason,
+			message: fmt.Sprintf("Referenced release manifest %s is for release version %s, expected %s",
+				key, manifest.Spec.ReleaseVersion, upgradePlan.Spec.ReleaseVersion),
+		}
+	}
+
+	return manifest, nil
+}
+
+func releaseManifestRefKey(upgradePlan *lifecyclev1alpha1.UpgradePlan, ref *lifecyclev1alpha1.ReleaseManifestReference) types.NamespacedName {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = upgradePlan.Namespace
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: ref.Name}
+}
+
+func indexReleaseVersion(object client.Object) []string {
+	manifest, ok := object.(*lifecyclev1alpha1.ReleaseManifest)
+	if !ok {
+		return nil
+	}
+
+	return []string{manifest.Spec.ReleaseVersion}
 }
 
 func (r *UpgradePlanReconciler) createReleaseManifest(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan) error {
 	labels := upgrade.PlanIdentifierLabels(upgradePlan.Name, upgradePlan.Namespace)
 	releaseManifest := upgrade.ContainerImage{
 		Name:    r.ReleaseManifestImage,
-		Version: strings.TrimPrefix(upgradePlan.Spec.ReleaseVersion, "v"),
+		Version: strings.TrimPrefix(currentReleaseVersion(upgradePlan), "v"),
 	}
 	job, err := upgrade.ReleaseManifestInstallJob(releaseManifest, r.Kubectl, r.ServiceAccount, upgradePlan.Namespace, labels)
 	if err != nil {
 		return err
 	}
 
-	// Retry the creation since a previously failed job could be in the process of deletion due to TTL
+	existing := &batchv1.Job{}
+	if err = r.Get(ctx, client.ObjectKeyFromObject(job), existing); err == nil {
+		if !isJobFinished(existing.Status.Conditions) {
+			// The plan will be reconciled again once the job finishes.
+			return nil
+		}
+
+		if isJobFailed(existing.Status.Conditions) {
+			r.collectFailureLogs(ctx, upgradePlan, releaseManifestComponent, existing)
+		}
+
+		if err = r.Delete(ctx, existing, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("deleting finished release manifest job: %w", err)
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("retrieving release manifest job: %w", err)
+	}
+
+	// Retry the creation since a previously finished job could be in the process of deletion
 	return retry.OnError(wait.Backoff{Steps: 5, Duration: 500 * time.Millisecond},
 		func(err error) bool { return true },
 		func() error { return r.createObject(ctx, upgradePlan, job) })
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRetrieveReleaseManifest(t *testing.T) {
+	newManifest := func(name, namespace, releaseVersion string) client.Object {
+		return &lifecyclev1alpha1.ReleaseManifest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       lifecyclev1alpha1.ReleaseManifestSpec{ReleaseVersion: releaseVersion},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		manifests        []client.Object
+		ref              *lifecyclev1alpha1.ReleaseManifestReference
+		expectedManifest string
+		expectedReason   string
+		expectedErr      error
+	}{
+		{
+			name: "Manifest found by release version",
+			manifests: []client.Object{
+				newManifest("release-3-0-2", "default", "3.0.2"),
+				newManifest("release-3-1-0", "default", "3.1.0"),
+				newManifest("release-3-1-0", "other", "3.1.0"),
+			},
+			expectedManifest: "default/release-3-1-0",
+		},
+		{
+			name: "Manifest not found",
+			manifests: []client.Object{
+				newManifest("release-3-1-0", "other", "3.1.0"),
+			},
+			expectedErr: errReleaseManifestNotFound,
+		},
+		{
+			name: "Manifest found in catalog namespace",
+			manifests: []client.Object{
+				newManifest("release-3-0-2", "default", "3.0.2"),
+				newManifest("release-3-1-0", "catalog", "3.1.0"),
+			},
+			expectedManifest: "catalog/release-3-1-0",
+		},
+		{
+			name: "Manifest in plan namespace takes precedence over catalog namespace",
+			manifests: []client.Object{
+				newManifest("release-3-1-0", "default", "3.1.0"),
+				newManifest("release-3-1-0", "catalog", "3.1.0"),
+			},
+			expectedManifest: "default/release-3-1-0",
+		},
+		{
+			name: "Multiple manifests for the same release version",
+			manifests: []client.Object{
+				newManifest("release-3-1-0-b", "default", "3.1.0"),
+				newManifest("release-3-1-0-a", "default", "3.1.0"),
+			},
+			expectedReason: lifecyclev1alpha1.DuplicateReleaseManifestsReason,
+		},
+		{
+			name: "Referenced manifest takes precedence over duplicates",
+			manifests: []client.Object{
+				newManifest("release-3-1-0-b", "default", "3.1.0"),
+				newManifest("release-3-1-0-a", "default", "3.1.0"),
+			},
+			ref:              &lifecyclev1alpha1.ReleaseManifestReference{Name: "release-3-1-0-b"},
+			expectedManifest: "default/release-3-1-0-b",
+		},
+		{
+			name: "Referenced manifest does not exist",
+			manifests: []client.Object{
+				newManifest("release-3-1-0", "default", "3.1.0"),
+			},
+			ref:            &lifecyclev1alpha1.ReleaseManifestReference{Name: "release-3-1-0", Namespace: "other"},
+			expectedReason: lifecyclev1alpha1.ReleaseManifestNotFoundReason,
+		},
+		{
+			name: "Referenced manifest has a different release version",
+			manifests: []client.Object{
+				newManifest("release-3-0-2", "default", "3.0.2"),
+			},
+			ref:            &lifecyclev1alpha1.ReleaseManifestReference{Name: "release-3-0-2"},
+			expectedReason: lifecyclev1alpha1.ReleaseManifestMismatchReason,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, lifecyclev1alpha1.AddToScheme(scheme))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &UpgradePlanReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(test.manifests...).
+					WithIndex(&lifecyclev1alpha1.ReleaseManifest{}, releaseVersionIndexKey, indexReleaseVersion).
+					Build(),
+				CatalogNamespace: "catalog",
+			}
+
+			plan := &lifecyclev1alpha1.UpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{Name: "plan", Namespace: "default"},
+				Spec: lifecyclev1alpha1.UpgradePlanSpec{
+					ReleaseVersion:     "3.1.0",
+					ReleaseManifestRef: test.ref,
+				},
+			}
+
+			manifest, err := r.retrieveReleaseManifest(context.Background(), plan)
+
+			switch {
+			case test.expectedErr != nil:
+				assert.ErrorIs(t, err, test.expectedErr)
+			case test.expectedReason != "":
+				var validationErr *releaseManifestValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, test.expectedReason, validationErr.reason)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, test.expectedManifest, client.ObjectKeyFromObject(manifest).String())
+			}
+		})
+	}
+}
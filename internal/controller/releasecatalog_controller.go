@@ -0,0 +1,308 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/catalog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const defaultCatalogSyncInterval = 6 * time.Hour
+
+// ReleaseCatalogReconciler reconciles a ReleaseCatalog object
+type ReleaseCatalogReconciler struct {
+	client.Client
+	Scheme               *runtime.Scheme
+	Recorder             record.EventRecorder
+	HTTPClient           *http.Client
+	ReleaseManifestImage string
+	CatalogNamespace     string
+}
+
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=releasecatalogs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=releasecatalogs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=upgradeplans,verbs=get;list;watch
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=upgradeplans/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile periodically synchronizes ReleaseCatalog objects with their source
+// and reports the availability of newer releases to the respective UpgradePlans.
+func (r *ReleaseCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	releaseCatalog := &lifecyclev1alpha1.ReleaseCatalog{}
+
+	if err := r.Get(ctx, req.NamespacedName, releaseCatalog); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	logger := log.FromContext(ctx)
+	logger.Info("Reconciling ReleaseCatalog")
+
+	interval := catalogSyncInterval(releaseCatalog)
+
+	var syncErr error
+	if isCatalogSyncDue(releaseCatalog, interval, time.Now()) {
+		syncErr = r.sync(ctx, releaseCatalog)
+	}
+
+	err := errors.Join(syncErr, r.updateUpgradePlans(ctx, releaseCatalog), r.Status().Update(ctx, releaseCatalog))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: time.Until(releaseCatalog.Status.LastSyncTime.Add(interval))}, nil
+}
+
+func (r *ReleaseCatalogReconciler) sync(ctx context.Context, releaseCatalog *lifecyclev1alpha1.ReleaseCatalog) error {
+	releaseCatalog.Status.ObservedGeneration = releaseCatalog.Generation
+
+	versions, err := r.listVersions(ctx, releaseCatalog)
+	if err != nil {
+		condition := metav1.Condition{Type: lifecyclev1alpha1.SyncedCondition, Status: metav1.ConditionFalse, Reason: lifecyclev1alpha1.SyncFailedReason, Message: err.Error()}
+		meta.SetStatusCondition(&releaseCatalog.Status.Conditions, condition)
+
+		r.Recorder.Eventf(releaseCatalog, corev1.EventTypeWarning, lifecyclev1alpha1.SyncFailedReason, "Synchronizing release catalog failed: %v", err)
+		return fmt.Errorf("synchronizing release catalog: %w", err)
+	}
+
+	releaseCatalog.Status.AvailableVersions = versions
+	releaseCatalog.Status.LatestVersion = ""
+	if len(versions) != 0 {
+		releaseCatalog.Status.LatestVersion = versions[len(versions)-1]
+	}
+
+	message := fmt.Sprintf("Found %d release versions", len(releaseCatalog.Status.AvailableVersions))
+	condition := metav1.Condition{Type: lifecyclev1alpha1.SyncedCondition, Status: metav1.ConditionTrue, Reason: lifecyclev1alpha1.SyncSucceededReason, Message: message}
+	meta.SetStatusCondition(&releaseCatalog.Status.Conditions, condition)
+
+	now := metav1.Now()
+	releaseCatalog.Status.LastSyncTime = &now
+
+	return nil
+}
+
+func (r *ReleaseCatalogReconciler) listVersions(ctx context.Context, releaseCatalog *lifecyclev1alpha1.ReleaseCatalog) ([]string, error) {
+	source, err := catalog.NewSource(r.HTTPClient, &releaseCatalog.Spec, r.ReleaseManifestImage)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := source.ListVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.SortVersions(versions), nil
+}
+
+// updateUpgradePlans reports the availability of newer releases to the plans the given release catalog applies to.
+// As plans may be covered by several catalogs in their own namespace and the catalog namespace,
+// each plan is compared against the catalog with the highest latest version among them.
+func (r *ReleaseCatalogReconciler) updateUpgradePlans(ctx context.Context, releaseCatalog *lifecyclev1alpha1.ReleaseCatalog) error {
+	if releaseCatalog.Status.LatestVersion == "" {
+		return nil
+	}
+
+	listOpts := &client.ListOptions{}
+	if releaseCatalog.Namespace != r.CatalogNamespace {
+		listOpts.Namespace = releaseCatalog.Namespace
+	}
+
+	plans := &lifecyclev1alpha1.UpgradePlanList{}
+	if err := r.List(ctx, plans, listOpts); err != nil {
+		return fmt.Errorf("listing upgrade plans: %w", err)
+	}
+
+	catalogs := map[string][]lifecyclev1alpha1.ReleaseCatalog{}
+
+	var errs []error
+
+	for _, plan := range plans.Items {
+		var candidates []lifecyclev1alpha1.ReleaseCatalog
+
+		for _, namespace := range r.catalogNamespaces(&plan) {
+			if _, ok := catalogs[namespace]; !ok {
+				list := &lifecyclev1alpha1.ReleaseCatalogList{}
+				if err := r.List(ctx, list, client.InNamespace(namespace)); err != nil {
+					return errors.Join(append(errs, fmt.Errorf("listing release catalogs in namespace %s: %w", namespace, err))...)
+				}
+				catalogs[namespace] = list.Items
+			}
+
+			candidates = append(candidates, catalogs[namespace]...)
+		}
+
+		latest := selectLatestReleaseCatalog(candidates, releaseCatalog)
+		if latest != releaseCatalog {
+			// The plan is updated by the reconciliation of the catalog with the highest version.
+			continue
+		}
+
+		condition, err := upgradeAvailableCondition(&plan, latest)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		} else if condition == nil {
+			continue
+		}
+
+		patch := client.MergeFromWithOptions(plan.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		if !meta.SetStatusCondition(&plan.Status.Conditions, *condition) {
+			continue
+		}
+
+		if err = r.Status().Patch(ctx, &plan, patch); err != nil {
+			errs = append(errs, fmt.Errorf("updating upgrade plan %s/%s: %w", plan.Namespace, plan.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// catalogNamespaces returns the namespaces holding the release catalogs which apply to the given plan.
+func (r *ReleaseCatalogReconciler) catalogNamespaces(plan client.Object) []string {
+	namespaces := []string{plan.GetNamespace()}
+	if r.CatalogNamespace != "" && r.CatalogNamespace != plan.GetNamespace() {
+		namespaces = append(namespaces, r.CatalogNamespace)
+	}
+
+	return namespaces
+}
+
+// selectLatestReleaseCatalog returns the release catalog with the highest latest version among the given ones,
+// breaking ties by namespace and name so that the same catalog is selected regardless of which one is reconciled.
+// The reconciled catalog replaces its listed copy, whose status may be outdated. Catalogs without a valid latest version are ignored.
+func selectLatestReleaseCatalog(catalogs []lifecyclev1alpha1.ReleaseCatalog, reconciled *lifecyclev1alpha1.ReleaseCatalog) *lifecyclev1alpha1.ReleaseCatalog {
+	candidates := []*lifecyclev1alpha1.ReleaseCatalog{reconciled}
+	for i := range catalogs {
+		if catalogs[i].Namespace != reconciled.Namespace || catalogs[i].Name != reconciled.Name {
+			candidates = append(candidates, &catalogs[i])
+		}
+	}
+
+	var latest *lifecyclev1alpha1.ReleaseCatalog
+	var latestVersion *version.Version
+
+	for _, c := range candidates {
+		v, err := lifecyclev1alpha1.ValidateReleaseVersion(c.Status.LatestVersion)
+		if err != nil {
+			continue
+		}
+
+		if latestVersion == nil || v.GreaterThan(latestVersion) ||
+			(v.EqualTo(latestVersion) && objectName(c.Namespace, c.Name) < objectName(latest.Namespace, latest.Name)) {
+			latest, latestVersion = c, v
+		}
+	}
+
+	return latest
+}
+
+// upgradeAvailableCondition compares the last successfully applied release version of the given upgrade plan
+// against the latest version in the given release catalog. Returns nil if the plan has not completed an upgrade yet.
+func upgradeAvailableCondition(plan *lifecyclev1alpha1.UpgradePlan, releaseCatalog *lifecyclev1alpha1.ReleaseCatalog) (*metav1.Condition, error) {
+	current := plan.Status.LastSuccessfulReleaseVersion
+	if current == "" {
+		return nil, nil
+	}
+
+	latest, err := lifecyclev1alpha1.ValidateReleaseVersion(releaseCatalog.Status.LatestVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	indicator, err := latest.Compare(current)
+	if err != nil {
+		return nil, fmt.Errorf("comparing versions: %w", err)
+	}
+
+	if indicator > 0 {
+		return &metav1.Condition{
+			Type:    lifecyclev1alpha1.UpgradeAvailableCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  lifecyclev1alpha1.NewerReleaseAvailableReason,
+			Message: fmt.Sprintf("Release %s is available in catalog %s/%s", releaseCatalog.Status.LatestVersion, releaseCatalog.Namespace, releaseCatalog.Name),
+		}, nil
+	}
+
+	return &metav1.Condition{
+		Type:    lifecyclev1alpha1.UpgradeAvailableCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  lifecyclev1alpha1.UpToDateReason,
+		Message: fmt.Sprintf("Release %s is the latest one in catalog %s/%s", current, releaseCatalog.Namespace, releaseCatalog.Name),
+	}, nil
+}
+
+func catalogSyncInterval(releaseCatalog *lifecyclev1alpha1.ReleaseCatalog) time.Duration {
+	if releaseCatalog.Spec.Interval == nil || releaseCatalog.Spec.Interval.Duration <= 0 {
+		return defaultCatalogSyncInterval
+	}
+
+	return releaseCatalog.Spec.Interval.Duration
+}
+
+func isCatalogSyncDue(releaseCatalog *lifecyclev1alpha1.ReleaseCatalog, interval time.Duration, now time.Time) bool {
+	status := releaseCatalog.Status
+
+	return status.ObservedGeneration != releaseCatalog.Generation ||
+		status.LastSyncTime == nil ||
+		!meta.IsStatusConditionTrue(status.Conditions, lifecyclev1alpha1.SyncedCondition) ||
+		!now.Before(status.LastSyncTime.Add(interval))
+}
+
+func (r *ReleaseCatalogReconciler) findReleaseCatalogsFromPlan(ctx context.Context, plan client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+
+	for _, namespace := range r.catalogNamespaces(plan) {
+		catalogs := &lifecyclev1alpha1.ReleaseCatalogList{}
+		if err := r.List(ctx, catalogs, &client.ListOptions{Namespace: namespace}); err != nil {
+			logger := log.FromContext(ctx)
+			logger.Error(err, "failed to list release catalogs")
+
+			return []reconcile.Request{}
+		}
+
+		for _, c := range catalogs.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: c.Namespace, Name: c.Name},
+			})
+		}
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReleaseCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&lifecyclev1alpha1.ReleaseCatalog{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&lifecyclev1alpha1.UpgradePlan{}, handler.EnqueueRequestsFromMapFunc(r.findReleaseCatalogsFromPlan), builder.WithPredicates(predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				// Availability of newer releases only needs to be reevaluated once an upgrade is complete.
+				return e.ObjectNew.(*lifecyclev1alpha1.UpgradePlan).Status.LastSuccessfulReleaseVersion !=
+					e.ObjectOld.(*lifecyclev1alpha1.UpgradePlan).Status.LastSuccessfulReleaseVersion
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+		})).
+		Complete(r)
+}
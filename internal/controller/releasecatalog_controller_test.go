@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpgradeAvailableCondition(t *testing.T) {
+	releaseCatalog := &lifecyclev1alpha1.ReleaseCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "suse-edge", Namespace: "default"},
+		Status:     lifecyclev1alpha1.ReleaseCatalogStatus{LatestVersion: "3.1.1"},
+	}
+
+	tests := []struct {
+		name            string
+		currentVersion  string
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:           "No upgrade completed yet",
+			currentVersion: "",
+		},
+		{
+			name:            "Newer release available",
+			currentVersion:  "3.1.0",
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  lifecyclev1alpha1.NewerReleaseAvailableReason,
+			expectedMessage: "Release 3.1.1 is available in catalog default/suse-edge",
+		},
+		{
+			name:            "Up to date",
+			currentVersion:  "3.1.1",
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  lifecyclev1alpha1.UpToDateReason,
+			expectedMessage: "Release 3.1.1 is the latest one in catalog default/suse-edge",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := &lifecyclev1alpha1.UpgradePlan{
+				Status: lifecyclev1alpha1.UpgradePlanStatus{LastSuccessfulReleaseVersion: test.currentVersion},
+			}
+
+			condition, err := upgradeAvailableCondition(plan, releaseCatalog)
+			require.NoError(t, err)
+
+			if test.expectedReason == "" {
+				assert.Nil(t, condition)
+				return
+			}
+
+			require.NotNil(t, condition)
+			assert.Equal(t, lifecyclev1alpha1.UpgradeAvailableCondition, condition.Type)
+			assert.Equal(t, test.expectedStatus, condition.Status)
+			assert.Equal(t, test.expectedReason, condition.Reason)
+			assert.Equal(t, test.expectedMessage, condition.Message)
+		})
+	}
+}
+
+func TestSelectLatestReleaseCatalog(t *testing.T) {
+	newCatalog := func(namespace, name, latestVersion string) lifecyclev1alpha1.ReleaseCatalog {
+		return lifecyclev1alpha1.ReleaseCatalog{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Status:     lifecyclev1alpha1.ReleaseCatalogStatus{LatestVersion: latestVersion},
+		}
+	}
+
+	catalogs := []lifecyclev1alpha1.ReleaseCatalog{
+		newCatalog("default", "tenant", "3.1.0"),
+		newCatalog("release-catalog", "suse-edge", "3.2.0"),
+		newCatalog("release-catalog", "mirror", "3.2.0"),
+		newCatalog("release-catalog", "broken", ""),
+	}
+
+	// The listed status of the reconciled catalog is outdated.
+	reconciled := newCatalog("default", "tenant", "3.3.0")
+	assert.Same(t, &reconciled, selectLatestReleaseCatalog(catalogs, &reconciled))
+
+	// Catalogs with the same version are selected by name regardless of the reconciled one.
+	for _, name := range []string{"tenant", "suse-edge", "mirror", "broken"} {
+		reconciled = newCatalog("default", "tenant", "3.1.0")
+		if name != "tenant" {
+			reconciled = newCatalog("release-catalog", name, catalogs[slices.IndexFunc(catalogs, func(c lifecyclev1alpha1.ReleaseCatalog) bool {
+				return c.Name == name
+			})].Status.LatestVersion)
+		}
+
+		latest := selectLatestReleaseCatalog(catalogs, &reconciled)
+		require.NotNil(t, latest)
+		assert.Equal(t, "mirror", latest.Name, name)
+	}
+
+	// The reconciled catalog is considered even if it is not listed yet.
+	reconciled = newCatalog("default", "new", "3.4.0")
+	assert.Same(t, &reconciled, selectLatestReleaseCatalog(catalogs, &reconciled))
+
+	assert.Nil(t, selectLatestReleaseCatalog(nil, &lifecyclev1alpha1.ReleaseCatalog{}))
+}
+
+func TestIsCatalogSyncDue(t *testing.T) {
+	now := time.Now()
+	lastSync := metav1.NewTime(now.Add(-time.Hour))
+
+	releaseCatalog := &lifecyclev1alpha1.ReleaseCatalog{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1},
+	}
+	assert.True(t, isCatalogSyncDue(releaseCatalog, 2*time.Hour, now))
+
+	releaseCatalog.Status = lifecyclev1alpha1.ReleaseCatalogStatus{
+		ObservedGeneration: 1,
+		LastSyncTime:       &lastSync,
+		Conditions: []metav1.Condition{
+			{Type: lifecyclev1alpha1.SyncedCondition, Status: metav1.ConditionTrue, Reason: lifecyclev1alpha1.SyncSucceededReason},
+		},
+	}
+	assert.False(t, isCatalogSyncDue(releaseCatalog, 2*time.Hour, now))
+	assert.True(t, isCatalogSyncDue(releaseCatalog, time.Hour, now))
+
+	releaseCatalog.Generation = 2
+	assert.True(t, isCatalogSyncDue(releaseCatalog, 2*time.Hour, now))
+
+	releaseCatalog.Generation = 1
+	releaseCatalog.Status.Conditions[0].Status = metav1.ConditionFalse
+	assert.True(t, isCatalogSyncDue(releaseCatalog, 2*time.Hour, now))
+}
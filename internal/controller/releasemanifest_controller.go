@@ -0,0 +1,340 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ReleaseManifestReconciler reconciles a ReleaseManifest object
+type ReleaseManifestReconciler struct {
+	client.Client
+	Scheme           *runtime.Scheme
+	Recorder         record.EventRecorder
+	CatalogNamespace string
+}
+
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=releasemanifests,verbs=get;list;watch
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=releasemanifests/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=upgradeplans,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=watch;list
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile validates ReleaseManifest objects and keeps track of the UpgradePlans referencing them.
+func (r *ReleaseManifestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	manifest := &lifecyclev1alpha1.ReleaseManifest{}
+
+	if err := r.Get(ctx, req.NamespacedName, manifest); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	logger := log.FromContext(ctx)
+	logger.Info("Reconciling ReleaseManifest")
+
+	err := r.reconcileNormal(ctx, manifest)
+
+	// Attempt to update the manifest status before returning.
+	return ctrl.Result{}, errors.Join(err, r.Status().Update(ctx, manifest))
+}
+
+func (r *ReleaseManifestReconciler) reconcileNormal(ctx context.Context, manifest *lifecyclev1alpha1.ReleaseManifest) error {
+	if err := validateReleaseManifest(manifest); err != nil {
+		setManifestCondition(manifest, lifecyclev1alpha1.ValidCondition, metav1.ConditionFalse, lifecyclev1alpha1.InvalidReleaseManifestReason, err.Error())
+	} else {
+		setManifestCondition(manifest, lifecyclev1alpha1.ValidCondition, metav1.ConditionTrue, lifecyclev1alpha1.ValidationSucceededReason, "Release manifest is valid")
+	}
+
+	manifest.Status.ObservedGeneration = manifest.Generation
+
+	nodeList := &corev1.NodeList{}
+	if err := r.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	}
+
+	verifyReleaseManifest(manifest, nodeList)
+
+	plans := &lifecyclev1alpha1.UpgradePlanList{}
+	if err := r.List(ctx, plans); err != nil {
+		return fmt.Errorf("listing upgrade plans: %w", err)
+	}
+
+	var referencingPlans []string
+	var inUse bool
+
+	for _, plan := range plans.Items {
+		referenced, err := r.isReferencedByPlan(ctx, &plan, manifest)
+		if err != nil {
+			return fmt.Errorf("checking upgrade plan %s/%s: %w", plan.Namespace, plan.Name, err)
+		} else if !referenced {
+			continue
+		}
+
+		referencingPlans = append(referencingPlans, fmt.Sprintf("%s/%s", plan.Namespace, plan.Name))
+
+		if isUpgradeStarted(&plan) {
+			inUse = true
+		}
+	}
+
+	manifest.Status.UpgradePlans = referencingPlans
+
+	if inUse && !manifest.Status.Immutable {
+		manifest.Status.Immutable = true
+		r.Recorder.Eventf(manifest, corev1.EventTypeNormal, lifecyclev1alpha1.ReleaseManifestImmutableReason,
+			"Release manifest is in use by upgrade plans %s and can no longer be edited", strings.Join(referencingPlans, ", "))
+	}
+
+	return nil
+}
+
+func (r *ReleaseManifestReconciler) isReferencedByPlan(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, manifest *lifecyclev1alpha1.ReleaseManifest) (bool, error) {
+	if !referencesReleaseManifest(plan, manifest, r.CatalogNamespace) {
+		return false, nil
+	}
+
+	if (plan.Spec.ReleaseManifestRef != nil && !isIntermediateRelease(plan)) || plan.Namespace == manifest.Namespace {
+		return true, nil
+	}
+
+	// Catalog release manifests are only used if the plan namespace does not provide its own.
+	_, err := findReleaseManifest(ctx, r.Client, plan.Namespace, currentReleaseVersion(plan))
+	if errors.Is(err, errReleaseManifestNotFound) {
+		return true, nil
+	}
+
+	var validationErr *releaseManifestValidationError
+	if err != nil && !errors.As(err, &validationErr) {
+		return false, err
+	}
+
+	// The plan namespace provides its own release manifest for this version.
+	return false, nil
+}
+
+// verifyReleaseManifest checks whether the release manifest is applicable to the nodes in the cluster.
+func verifyReleaseManifest(manifest *lifecyclev1alpha1.ReleaseManifest, nodeList *corev1.NodeList) {
+	supportedArchitectures := lifecyclev1alpha1.SupportedArchitectures(manifest.Spec.Components.OperatingSystem.SupportedArchs)
+	if unsupportedNodes := findUnsupportedNodes(nodeList, supportedArchitectures); len(unsupportedNodes) > 0 {
+		msg := fmt.Sprintf("One or more cluster nodes are running on unsupported architecture: %s", unsupportedNodes)
+		setManifestCondition(manifest, lifecyclev1alpha1.VerifiedCondition, metav1.ConditionFalse, lifecyclev1alpha1.UnsupportedArchitectureReason, msg)
+		return
+	}
+
+	if _, err := targetKubernetesDistribution(nodeList, &manifest.Spec.Components.Kubernetes); err != nil {
+		setManifestCondition(manifest, lifecyclev1alpha1.VerifiedCondition, metav1.ConditionFalse, lifecyclev1alpha1.UnsupportedKubernetesReason, err.Error())
+		return
+	}
+
+	setManifestCondition(manifest, lifecyclev1alpha1.VerifiedCondition, metav1.ConditionTrue, lifecyclev1alpha1.VerificationSucceededReason, "Release manifest is applicable to the cluster")
+}
+
+func validateReleaseManifest(manifest *lifecyclev1alpha1.ReleaseManifest) error {
+	var errs []error
+
+	if _, err := version.ParseSemantic(manifest.Spec.ReleaseVersion); err != nil {
+		errs = append(errs, fmt.Errorf("release version '%s' is not a semantic version", manifest.Spec.ReleaseVersion))
+	}
+
+	if v := manifest.Spec.MinimumUpgradeableFrom; v != "" {
+		if _, err := version.ParseSemantic(v); err != nil {
+			errs = append(errs, fmt.Errorf("minimum upgradeable from version '%s' is not a semantic version", v))
+		}
+	}
+
+	if c := manifest.Spec.UpgradeableFrom; c != "" {
+		if _, err := semver.NewConstraint(c); err != nil {
+			errs = append(errs, fmt.Errorf("upgradeable from constraint '%s' is not valid", c))
+		}
+	}
+
+	components := manifest.Spec.Components
+
+	errs = append(errs, validateKubernetesDistribution("k3s", &components.Kubernetes.K3S)...)
+	errs = append(errs, validateKubernetesDistribution("rke2", &components.Kubernetes.RKE2)...)
+
+	for _, arch := range components.OperatingSystem.SupportedArchs {
+		if arch != lifecyclev1alpha1.ArchTypeX86 && arch != lifecyclev1alpha1.ArchTypeARM {
+			errs = append(errs, fmt.Errorf("unsupported architecture '%s'", arch))
+		}
+	}
+
+	releaseNames := map[string]struct{}{}
+	prettyNames := map[string]struct{}{}
+
+	for _, chart := range components.Workloads.Helm {
+		if _, ok := prettyNames[chart.PrettyName]; ok {
+			errs = append(errs, fmt.Errorf("duplicate helm chart pretty name '%s'", chart.PrettyName))
+		}
+		prettyNames[chart.PrettyName] = struct{}{}
+
+		errs = append(errs, validateHelmChart(&chart, releaseNames)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateKubernetesDistribution(name string, distribution *lifecyclev1alpha1.KubernetesDistribution) []error {
+	var errs []error
+
+	if _, err := version.ParseSemantic(distribution.Version); err != nil {
+		errs = append(errs, fmt.Errorf("%s version '%s' is not a semantic version", name, distribution.Version))
+	}
+
+	componentNames := map[string]struct{}{}
+
+	for _, component := range distribution.CoreComponents {
+		if _, ok := componentNames[component.Name]; ok {
+			errs = append(errs, fmt.Errorf("duplicate %s core component '%s'", name, component.Name))
+		}
+		componentNames[component.Name] = struct{}{}
+
+		switch component.Type {
+		case lifecyclev1alpha1.HelmChartType:
+			if component.Version == "" {
+				errs = append(errs, fmt.Errorf("%s core component '%s' does not specify a version", name, component.Name))
+			}
+		case lifecyclev1alpha1.DeploymentType:
+			if len(component.Containers) == 0 {
+				errs = append(errs, fmt.Errorf("%s core component '%s' does not specify any containers", name, component.Name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s core component '%s' has unsupported type '%s'", name, component.Name, component.Type))
+		}
+	}
+
+	return errs
+}
+
+func validateHelmChart(chart *lifecyclev1alpha1.HelmChart, releaseNames map[string]struct{}) []error {
+	var errs []error
+
+	if chart.Name == "" {
+		errs = append(errs, fmt.Errorf("helm chart '%s' does not specify a chart name", chart.ReleaseName))
+	}
+
+	if _, err := version.ParseSemantic(strings.TrimPrefix(chart.Version, "v")); err != nil {
+		errs = append(errs, fmt.Errorf("helm chart '%s' version '%s' is not a semantic version", chart.Name, chart.Version))
+	}
+
+	if chart.ReleaseName == "" {
+		errs = append(errs, fmt.Errorf("helm chart '%s' does not specify a release name", chart.Name))
+	} else if _, ok := releaseNames[chart.ReleaseName]; ok {
+		errs = append(errs, fmt.Errorf("duplicate helm release name '%s'", chart.ReleaseName))
+	}
+	releaseNames[chart.ReleaseName] = struct{}{}
+
+	if err := lifecyclev1alpha1.ValidateValuesMerge(chart.Values, chart.MergeStrategy, chart.MergeKey); err != nil {
+		errs = append(errs, fmt.Errorf("helm chart '%s' values are invalid: %w", chart.Name, err))
+	}
+
+	for _, c := range slices.Concat(chart.DependencyCharts, chart.AddonCharts) {
+		errs = append(errs, validateHelmChart(&c, releaseNames)...)
+	}
+
+	return errs
+}
+
+// referencesReleaseManifest reports whether the given upgrade plan may target the given release manifest.
+// Release manifests in the catalog namespace match plans from all namespaces, even though
+// a release manifest with the same version in the plan namespace would take precedence.
+func referencesReleaseManifest(plan *lifecyclev1alpha1.UpgradePlan, manifest *lifecyclev1alpha1.ReleaseManifest, catalogNamespace string) bool {
+	if ref := plan.Spec.ReleaseManifestRef; ref != nil && !isIntermediateRelease(plan) {
+		return releaseManifestRefKey(plan, ref) == client.ObjectKeyFromObject(manifest)
+	}
+
+	if plan.Namespace != manifest.Namespace && (catalogNamespace == "" || manifest.Namespace != catalogNamespace) {
+		return false
+	}
+
+	return currentReleaseVersion(plan) == manifest.Spec.ReleaseVersion
+}
+
+// isUpgradeStarted reports whether an upgrade has been initiated for the current generation of the given upgrade plan.
+func isUpgradeStarted(plan *lifecyclev1alpha1.UpgradePlan) bool {
+	return plan.Status.SUCNameSuffix != "" && plan.Status.ObservedGeneration == plan.Generation
+}
+
+func setManifestCondition(manifest *lifecyclev1alpha1.ReleaseManifest, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	condition := metav1.Condition{Type: conditionType, Status: status, Reason: reason, Message: message, ObservedGeneration: manifest.Generation}
+	meta.SetStatusCondition(&manifest.Status.Conditions, condition)
+}
+
+func (r *ReleaseManifestReconciler) findReleaseManifestsInNamespace(ctx context.Context, namespace string) []reconcile.Request {
+	manifests := &lifecyclev1alpha1.ReleaseManifestList{}
+	listOpts := &client.ListOptions{}
+	if namespace != "" {
+		listOpts.Namespace = namespace
+	}
+
+	if err := r.List(ctx, manifests, listOpts); err != nil {
+		logger := log.FromContext(ctx)
+		logger.Error(err, "failed to list release manifests")
+
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, 0, len(manifests.Items))
+	for _, manifest := range manifests.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: manifest.Namespace, Name: manifest.Name},
+		})
+	}
+
+	return requests
+}
+
+func (r *ReleaseManifestReconciler) findReleaseManifestsFromPlan(ctx context.Context, object client.Object) []reconcile.Request {
+	requests := r.findReleaseManifestsInNamespace(ctx, object.GetNamespace())
+	if r.CatalogNamespace != "" && r.CatalogNamespace != object.GetNamespace() {
+		requests = append(requests, r.findReleaseManifestsInNamespace(ctx, r.CatalogNamespace)...)
+	}
+
+	plan, ok := object.(*lifecyclev1alpha1.UpgradePlan)
+	if ok && plan.Spec.ReleaseManifestRef != nil {
+		key := releaseManifestRefKey(plan, plan.Spec.ReleaseManifestRef)
+		if key.Namespace != plan.Namespace && key.Namespace != r.CatalogNamespace {
+			requests = append(requests, reconcile.Request{NamespacedName: key})
+		}
+	}
+
+	return requests
+}
+
+func (r *ReleaseManifestReconciler) findReleaseManifestsFromNode(ctx context.Context, _ client.Object) []reconcile.Request {
+	return r.findReleaseManifestsInNamespace(ctx, "")
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReleaseManifestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&lifecyclev1alpha1.ReleaseManifest{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&lifecyclev1alpha1.UpgradePlan{}, handler.EnqueueRequestsFromMapFunc(r.findReleaseManifestsFromPlan)).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.findReleaseManifestsFromNode), builder.WithPredicates(predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				// Node statuses are being constantly updated.
+				// Manifests only need to be verified again when the cluster topology changes.
+				return false
+			},
+		})).
+		Complete(r)
+}
@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func validReleaseManifest() *lifecyclev1alpha1.ReleaseManifest {
+	return &lifecyclev1alpha1.ReleaseManifest{
+		Spec: lifecyclev1alpha1.ReleaseManifestSpec{
+			ReleaseVersion: "3.1.0",
+			Components: lifecyclev1alpha1.Components{
+				Kubernetes: lifecyclev1alpha1.Kubernetes{
+					K3S: lifecyclev1alpha1.KubernetesDistribution{
+						Version: "v1.30.3+k3s1",
+						CoreComponents: []lifecyclev1alpha1.CoreComponent{
+							{Name: "traefik", Version: "27.0.201+up27.0.2", Type: lifecyclev1alpha1.HelmChartType},
+						},
+					},
+					RKE2: lifecyclev1alpha1.KubernetesDistribution{
+						Version: "v1.30.3+rke2r1",
+						CoreComponents: []lifecyclev1alpha1.CoreComponent{
+							{Name: "rke2-canal", Version: "v3.28.1-build20240806", Type: lifecyclev1alpha1.HelmChartType},
+							{
+								Name:       "rke2-cilium",
+								Type:       lifecyclev1alpha1.DeploymentType,
+								Containers: []lifecyclev1alpha1.CoreComponentContainer{{Name: "cilium-operator", Image: "rancher/mirrored-cilium-operator:v1.15.7"}},
+							},
+						},
+					},
+				},
+				OperatingSystem: lifecyclev1alpha1.OperatingSystem{
+					SupportedArchs: []lifecyclev1alpha1.Arch{lifecyclev1alpha1.ArchTypeX86},
+				},
+				Workloads: lifecyclev1alpha1.Workloads{
+					Helm: []lifecyclev1alpha1.HelmChart{
+						{
+							ReleaseName: "metal3",
+							Name:        "metal3",
+							Version:     "0.8.1",
+							PrettyName:  "Metal3",
+						},
+						{
+							ReleaseName: "rancher",
+							Name:        "rancher",
+							Version:     "v2.9.1",
+							PrettyName:  "Rancher",
+							DependencyCharts: []lifecyclev1alpha1.HelmChart{
+								{ReleaseName: "cert-manager", Name: "cert-manager", Version: "1.15.3"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateReleaseManifest(t *testing.T) {
+	tests := []struct {
+		name        string
+		mutate      func(manifest *lifecyclev1alpha1.ReleaseManifest)
+		expectedErr []string
+	}{
+		{
+			name:   "Valid release manifest",
+			mutate: func(*lifecyclev1alpha1.ReleaseManifest) {},
+		},
+		{
+			name: "Invalid versions",
+			mutate: func(manifest *lifecyclev1alpha1.ReleaseManifest) {
+				manifest.Spec.ReleaseVersion = "3.1"
+				manifest.Spec.Components.Kubernetes.K3S.Version = "latest"
+				manifest.Spec.Components.Workloads.Helm[0].Version = "x"
+			},
+			expectedErr: []string{
+				"release version '3.1' is not a semantic version",
+				"k3s version 'latest' is not a semantic version",
+				"helm chart 'metal3' version 'x' is not a semantic version",
+			},
+		},
+		{
+			name: "Invalid upgradeable from versions",
+			mutate: func(manifest *lifecyclev1alpha1.ReleaseManifest) {
+				manifest.Spec.MinimumUpgradeableFrom = "3"
+				manifest.Spec.UpgradeableFrom = "3.x || latest"
+			},
+			expectedErr: []string{
+				"minimum upgradeable from version '3' is not a semantic version",
+				"upgradeable from constraint '3.x || latest' is not valid",
+			},
+		},
+		{
+			name: "Duplicate release and pretty names",
+			mutate: func(manifest *lifecyclev1alpha1.ReleaseManifest) {
+				manifest.Spec.Components.Workloads.Helm[1].PrettyName = "Metal3"
+				manifest.Spec.Components.Workloads.Helm[1].DependencyCharts[0].ReleaseName = "metal3"
+			},
+			expectedErr: []string{
+				"duplicate helm chart pretty name 'Metal3'",
+				"duplicate helm release name 'metal3'",
+			},
+		},
+		{
+			name: "Invalid values merge",
+			mutate: func(manifest *lifecyclev1alpha1.ReleaseManifest) {
+				manifest.Spec.Components.Workloads.Helm[0].MergeStrategy = lifecyclev1alpha1.ValuesMergeStrategyJSONPatch
+				manifest.Spec.Components.Workloads.Helm[0].Values = &apiextensionsv1.JSON{Raw: []byte(`{"replicas": 2}`)}
+			},
+			expectedErr: []string{"helm chart 'metal3' values are invalid: values are not a valid JSON patch"},
+		},
+		{
+			name: "Unsupported architecture",
+			mutate: func(manifest *lifecyclev1alpha1.ReleaseManifest) {
+				manifest.Spec.Components.OperatingSystem.SupportedArchs = append(manifest.Spec.Components.OperatingSystem.SupportedArchs, "s390x")
+			},
+			expectedErr: []string{"unsupported architecture 's390x'"},
+		},
+		{
+			name: "Invalid core components",
+			mutate: func(manifest *lifecyclev1alpha1.ReleaseManifest) {
+				components := manifest.Spec.Components.Kubernetes.RKE2.CoreComponents
+				components[0].Version = ""
+				components[1].Containers = nil
+				manifest.Spec.Components.Kubernetes.RKE2.CoreComponents = append(components, lifecyclev1alpha1.CoreComponent{
+					Name: "rke2-canal", Version: "v3.28.1", Type: lifecyclev1alpha1.HelmChartType,
+				})
+			},
+			expectedErr: []string{
+				"rke2 core component 'rke2-canal' does not specify a version",
+				"rke2 core component 'rke2-cilium' does not specify any containers",
+				"duplicate rke2 core component 'rke2-canal'",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest := validReleaseManifest()
+			test.mutate(manifest)
+
+			err := validateReleaseManifest(manifest)
+			if len(test.expectedErr) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			for _, expected := range test.expectedErr {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestVerifyReleaseManifest(t *testing.T) {
+	tests := []struct {
+		name           string
+		nodes          []corev1.Node
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name: "Supported cluster",
+			nodes: []corev1.Node{
+				{Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{Architecture: "amd64", KubeletVersion: "v1.30.2+k3s1"}}},
+			},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: lifecyclev1alpha1.VerificationSucceededReason,
+		},
+		{
+			name: "Unsupported architecture",
+			nodes: []corev1.Node{
+				{Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{Architecture: "arm64", KubeletVersion: "v1.30.2+k3s1"}}},
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: lifecyclev1alpha1.UnsupportedArchitectureReason,
+		},
+		{
+			name: "Unsupported kubernetes distribution",
+			nodes: []corev1.Node{
+				{Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{Architecture: "x86_64", KubeletVersion: "v1.30.2"}}},
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: lifecyclev1alpha1.UnsupportedKubernetesReason,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest := validReleaseManifest()
+
+			verifyReleaseManifest(manifest, &corev1.NodeList{Items: test.nodes})
+
+			condition := meta.FindStatusCondition(manifest.Status.Conditions, lifecyclev1alpha1.VerifiedCondition)
+			require.NotNil(t, condition)
+			assert.Equal(t, test.expectedStatus, condition.Status)
+			assert.Equal(t, test.expectedReason, condition.Reason)
+		})
+	}
+}
+
+func TestIsUpgradeStarted(t *testing.T) {
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+	}
+	assert.False(t, isUpgradeStarted(plan))
+
+	plan.Status.SUCNameSuffix = "abcdef"
+	plan.Status.ObservedGeneration = 1
+	assert.False(t, isUpgradeStarted(plan))
+
+	plan.Status.ObservedGeneration = 2
+	assert.True(t, isUpgradeStarted(plan))
+}
@@ -0,0 +1,400 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"gopkg.in/yaml.v3"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	helmutil "helm.sh/helm/v3/pkg/releaseutil"
+	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// removedAPIsRetryInterval is the delay before a blocked Kubernetes upgrade is scanned again.
+	removedAPIsRetryInterval = 5 * time.Minute
+
+	// maxRemovedAPIs limits the number of removed API usages listed in the status of a plan.
+	maxRemovedAPIs = 50
+)
+
+// removedAPI is an API version of a kind which is no longer served as of a Kubernetes minor version.
+type removedAPI struct {
+	apiVersion string
+	kind       string
+	// replacement is the API version to migrate to. Live objects are listed via this version.
+	replacement string
+}
+
+// removedAPIs lists the API versions removed by each Kubernetes 1.x minor version.
+// Refer to https://kubernetes.io/docs/reference/using-api/deprecation-guide/.
+var removedAPIs = map[uint][]removedAPI{
+	16: {
+		{apiVersion: "extensions/v1beta1", kind: "DaemonSet", replacement: "apps/v1"},
+		{apiVersion: "extensions/v1beta1", kind: "Deployment", replacement: "apps/v1"},
+		{apiVersion: "extensions/v1beta1", kind: "ReplicaSet", replacement: "apps/v1"},
+		{apiVersion: "extensions/v1beta1", kind: "NetworkPolicy", replacement: "networking.k8s.io/v1"},
+		{apiVersion: "apps/v1beta1", kind: "Deployment", replacement: "apps/v1"},
+		{apiVersion: "apps/v1beta1", kind: "StatefulSet", replacement: "apps/v1"},
+		{apiVersion: "apps/v1beta2", kind: "DaemonSet", replacement: "apps/v1"},
+		{apiVersion: "apps/v1beta2", kind: "Deployment", replacement: "apps/v1"},
+		{apiVersion: "apps/v1beta2", kind: "ReplicaSet", replacement: "apps/v1"},
+		{apiVersion: "apps/v1beta2", kind: "StatefulSet", replacement: "apps/v1"},
+	},
+	22: {
+		{apiVersion: "admissionregistration.k8s.io/v1beta1", kind: "MutatingWebhookConfiguration", replacement: "admissionregistration.k8s.io/v1"},
+		{apiVersion: "admissionregistration.k8s.io/v1beta1", kind: "ValidatingWebhookConfiguration", replacement: "admissionregistration.k8s.io/v1"},
+		{apiVersion: "apiextensions.k8s.io/v1beta1", kind: "CustomResourceDefinition", replacement: "apiextensions.k8s.io/v1"},
+		{apiVersion: "apiregistration.k8s.io/v1beta1", kind: "APIService", replacement: "apiregistration.k8s.io/v1"},
+		{apiVersion: "certificates.k8s.io/v1beta1", kind: "CertificateSigningRequest", replacement: "certificates.k8s.io/v1"},
+		{apiVersion: "coordination.k8s.io/v1beta1", kind: "Lease", replacement: "coordination.k8s.io/v1"},
+		{apiVersion: "extensions/v1beta1", kind: "Ingress", replacement: "networking.k8s.io/v1"},
+		{apiVersion: "networking.k8s.io/v1beta1", kind: "Ingress", replacement: "networking.k8s.io/v1"},
+		{apiVersion: "networking.k8s.io/v1beta1", kind: "IngressClass", replacement: "networking.k8s.io/v1"},
+		{apiVersion: "rbac.authorization.k8s.io/v1beta1", kind: "ClusterRole", replacement: "rbac.authorization.k8s.io/v1"},
+		{apiVersion: "rbac.authorization.k8s.io/v1beta1", kind: "ClusterRoleBinding", replacement: "rbac.authorization.k8s.io/v1"},
+		{apiVersion: "rbac.authorization.k8s.io/v1beta1", kind: "Role", replacement: "rbac.authorization.k8s.io/v1"},
+		{apiVersion: "rbac.authorization.k8s.io/v1beta1", kind: "RoleBinding", replacement: "rbac.authorization.k8s.io/v1"},
+		{apiVersion: "scheduling.k8s.io/v1beta1", kind: "PriorityClass", replacement: "scheduling.k8s.io/v1"},
+		{apiVersion: "storage.k8s.io/v1beta1", kind: "CSIDriver", replacement: "storage.k8s.io/v1"},
+		{apiVersion: "storage.k8s.io/v1beta1", kind: "CSINode", replacement: "storage.k8s.io/v1"},
+		{apiVersion: "storage.k8s.io/v1beta1", kind: "StorageClass", replacement: "storage.k8s.io/v1"},
+		{apiVersion: "storage.k8s.io/v1beta1", kind: "VolumeAttachment", replacement: "storage.k8s.io/v1"},
+	},
+	25: {
+		{apiVersion: "batch/v1beta1", kind: "CronJob", replacement: "batch/v1"},
+		{apiVersion: "discovery.k8s.io/v1beta1", kind: "EndpointSlice", replacement: "discovery.k8s.io/v1"},
+		{apiVersion: "autoscaling/v2beta1", kind: "HorizontalPodAutoscaler", replacement: "autoscaling/v2"},
+		{apiVersion: "policy/v1beta1", kind: "PodDisruptionBudget", replacement: "policy/v1"},
+		{apiVersion: "policy/v1beta1", kind: "PodSecurityPolicy"},
+		{apiVersion: "node.k8s.io/v1beta1", kind: "RuntimeClass", replacement: "node.k8s.io/v1"},
+	},
+	26: {
+		{apiVersion: "autoscaling/v2beta2", kind: "HorizontalPodAutoscaler", replacement: "autoscaling/v2"},
+		{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta1", kind: "FlowSchema", replacement: "flowcontrol.apiserver.k8s.io/v1beta2"},
+		{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta1", kind: "PriorityLevelConfiguration", replacement: "flowcontrol.apiserver.k8s.io/v1beta2"},
+	},
+	27: {
+		{apiVersion: "storage.k8s.io/v1beta1", kind: "CSIStorageCapacity", replacement: "storage.k8s.io/v1"},
+	},
+	29: {
+		{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta2", kind: "FlowSchema", replacement: "flowcontrol.apiserver.k8s.io/v1beta3"},
+		{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta2", kind: "PriorityLevelConfiguration", replacement: "flowcontrol.apiserver.k8s.io/v1beta3"},
+	},
+	32: {
+		{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta3", kind: "FlowSchema", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+		{apiVersion: "flowcontrol.apiserver.k8s.io/v1beta3", kind: "PriorityLevelConfiguration", replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	},
+}
+
+// removedAPIsBetween returns the API versions removed after the current and up to the target Kubernetes version,
+// keyed on the minor version removing them.
+func removedAPIsBetween(current, target *version.Version) map[uint][]removedAPI {
+	removed := map[uint][]removedAPI{}
+	if current.Major() != 1 || target.Major() != 1 {
+		return removed
+	}
+
+	for minor := current.Minor() + 1; minor <= target.Minor(); minor++ {
+		if apis, ok := removedAPIs[minor]; ok {
+			removed[minor] = apis
+		}
+	}
+
+	return removed
+}
+
+// RemovedAPIScanner looks up objects using API versions which are removed by a Kubernetes minor upgrade.
+type RemovedAPIScanner struct {
+	Reader client.Reader
+	// HelmRelease retrieves the latest revision of the given Helm release. Defaults to retrieveHelmRelease.
+	HelmRelease func(name string) (*helmrelease.Release, error)
+}
+
+// Scan inspects the stored manifests of the given Helm releases and the last applied configuration of live objects
+// for API versions removed after the current and up to the target Kubernetes version.
+func (s *RemovedAPIScanner) Scan(ctx context.Context, helmReleases []string, currentVersion, targetVersion string) ([]lifecyclev1alpha1.RemovedAPIUsage, error) {
+	current, err := version.ParseSemantic(currentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("parsing current kubernetes version '%s': %w", currentVersion, err)
+	}
+
+	target, err := version.ParseSemantic(targetVersion)
+	if err != nil {
+		return nil, fmt.Errorf("parsing target kubernetes version '%s': %w", targetVersion, err)
+	}
+
+	removed := removedAPIsBetween(current, target)
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	var usages []lifecyclev1alpha1.RemovedAPIUsage
+
+	for _, name := range helmReleases {
+		found, err := s.scanHelmRelease(name, removed)
+		if err != nil {
+			return nil, err
+		}
+
+		usages = append(usages, found...)
+	}
+
+	found, err := s.scanLiveObjects(ctx, removed)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(usages, found...), nil
+}
+
+func (s *RemovedAPIScanner) scanHelmRelease(name string, removed map[uint][]removedAPI) ([]lifecyclev1alpha1.RemovedAPIUsage, error) {
+	retrieve := s.HelmRelease
+	if retrieve == nil {
+		retrieve = retrieveHelmRelease
+	}
+
+	release, err := retrieve(name)
+	if err != nil {
+		if errors.Is(err, helmdriver.ErrReleaseNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("retrieving helm release %s: %w", name, err)
+	}
+
+	manifests := helmutil.SplitManifests(release.Manifest)
+
+	var usages []lifecyclev1alpha1.RemovedAPIUsage
+
+	for _, key := range slices.Sorted(maps.Keys(manifests)) {
+		var object struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Metadata   struct {
+				Name      string `yaml:"name"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"metadata"`
+		}
+		if err = yaml.Unmarshal([]byte(manifests[key]), &object); err != nil {
+			return nil, fmt.Errorf("parsing manifest of helm release %s: %w", name, err)
+		}
+
+		if usage, ok := findRemovedAPI(removed, object.APIVersion, object.Kind); ok {
+			usage.Object = objectName(object.Metadata.Namespace, object.Metadata.Name)
+			usage.HelmRelease = release.Name
+			usages = append(usages, usage)
+		}
+	}
+
+	return usages, nil
+}
+
+// scanLiveObjects lists the objects of the affected kinds via their replacement API versions
+// and inspects the API versions of their last applied configuration and managed fields.
+func (s *RemovedAPIScanner) scanLiveObjects(ctx context.Context, removed map[uint][]removedAPI) ([]lifecyclev1alpha1.RemovedAPIUsage, error) {
+	var kinds []schema.GroupVersionKind
+	for _, apis := range removed {
+		for _, api := range apis {
+			if api.replacement == "" {
+				continue
+			}
+
+			gvk := schema.FromAPIVersionAndKind(api.replacement, api.kind+"List")
+			if !slices.Contains(kinds, gvk) {
+				kinds = append(kinds, gvk)
+			}
+		}
+	}
+
+	slices.SortFunc(kinds, func(a, b schema.GroupVersionKind) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	var usages []lifecyclev1alpha1.RemovedAPIUsage
+
+	for _, gvk := range kinds {
+		objects := &metav1.PartialObjectMetadataList{}
+		objects.SetGroupVersionKind(gvk)
+
+		if err := s.Reader.List(ctx, objects); err != nil {
+			if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+				// The replacement is not served by the current version.
+				continue
+			}
+
+			return nil, fmt.Errorf("listing %s: %w", gvk.Kind, err)
+		}
+
+		kind := strings.TrimSuffix(gvk.Kind, "List")
+
+		for _, object := range objects.Items {
+			for _, apiVersion := range objectAPIVersions(&object) {
+				if usage, ok := findRemovedAPI(removed, apiVersion, kind); ok {
+					usage.Object = objectName(object.Namespace, object.Name)
+					usages = append(usages, usage)
+				}
+			}
+		}
+	}
+
+	return usages, nil
+}
+
+// objectAPIVersions returns the distinct API versions the object was last written with,
+// taken from its last applied configuration and its managed fields entries.
+func objectAPIVersions(object *metav1.PartialObjectMetadata) []string {
+	var apiVersions []string
+
+	if lastApplied, ok := object.Annotations[corev1.LastAppliedConfigAnnotation]; ok {
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal([]byte(lastApplied), &typeMeta); err == nil && typeMeta.APIVersion != "" {
+			apiVersions = append(apiVersions, typeMeta.APIVersion)
+		}
+	}
+
+	for _, entry := range object.ManagedFields {
+		if entry.APIVersion != "" && !slices.Contains(apiVersions, entry.APIVersion) {
+			apiVersions = append(apiVersions, entry.APIVersion)
+		}
+	}
+
+	return apiVersions
+}
+
+func findRemovedAPI(removed map[uint][]removedAPI, apiVersion, kind string) (lifecyclev1alpha1.RemovedAPIUsage, bool) {
+	for minor, apis := range removed {
+		for _, api := range apis {
+			if api.apiVersion == apiVersion && api.kind == kind {
+				return lifecyclev1alpha1.RemovedAPIUsage{
+					APIVersion:  apiVersion,
+					Kind:        kind,
+					RemovedIn:   fmt.Sprintf("v1.%d", minor),
+					Replacement: api.replacement,
+				}, true
+			}
+		}
+	}
+
+	return lifecyclev1alpha1.RemovedAPIUsage{}, false
+}
+
+func objectName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + "/" + name
+}
+
+// scanRemovedAPIs looks up usages of API versions removed by the target Kubernetes version before the Kubernetes upgrade
+// starts and reports whether the upgrade may proceed. The stored manifests of the Helm releases of the given charts
+// and the live objects of the cluster are inspected.
+func (r *UpgradePlanReconciler) scanRemovedAPIs(
+	ctx context.Context,
+	plan *lifecyclev1alpha1.UpgradePlan,
+	charts []lifecyclev1alpha1.HelmChart,
+	nodeList *corev1.NodeList,
+	targetVersion string,
+) (bool, error) {
+	if r.RemovedAPIScanner == nil {
+		return true, nil
+	}
+
+	currentVersion := lowestKubeletVersion(nodeList)
+	if currentVersion == "" {
+		return true, nil
+	}
+
+	usages, err := r.RemovedAPIScanner.Scan(ctx, helmReleaseNames(charts), currentVersion, targetVersion)
+	if err != nil {
+		return false, fmt.Errorf("scanning removed APIs: %w", err)
+	}
+
+	plan.Status.RemovedAPIs = usages
+	if len(usages) > maxRemovedAPIs {
+		plan.Status.RemovedAPIs = usages[:maxRemovedAPIs]
+	}
+
+	if len(usages) == 0 {
+		setRemovedAPIsCondition(plan, metav1.ConditionFalse, lifecyclev1alpha1.RemovedAPIsNotFoundReason,
+			fmt.Sprintf("No objects use API versions removed by Kubernetes %s", targetVersion))
+		return true, nil
+	}
+
+	summary := fmt.Sprintf("Found %d objects using API versions removed by Kubernetes %s", len(usages), targetVersion)
+
+	proceed := true
+	var reason, message string
+
+	switch plan.Spec.RemovedAPIPolicy {
+	case lifecyclev1alpha1.RemovedAPIPolicyBlock:
+		proceed = false
+		reason = lifecyclev1alpha1.RemovedAPIsBlockedReason
+		message = fmt.Sprintf("%s. The Kubernetes upgrade is blocked until they are migrated", summary)
+	default:
+		reason = lifecyclev1alpha1.RemovedAPIsReportedReason
+		message = fmt.Sprintf("%s. The Kubernetes upgrade proceeds regardless", summary)
+	}
+
+	previous := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.RemovedAPIsCondition)
+	setRemovedAPIsCondition(plan, metav1.ConditionTrue, reason, message)
+
+	if previous == nil || previous.Reason != reason || previous.Message != message {
+		r.Recorder.Eventf(plan, corev1.EventTypeWarning, reason, "%s", message)
+	}
+
+	return proceed, nil
+}
+
+// helmReleaseNames returns the release names of the given charts including their dependencies and add-ons.
+func helmReleaseNames(charts []lifecyclev1alpha1.HelmChart) []string {
+	var names []string
+
+	for _, chart := range charts {
+		names = append(names, helmReleaseNames(chart.DependencyCharts)...)
+		names = append(names, chart.ReleaseName)
+		names = append(names, helmReleaseNames(chart.AddonCharts)...)
+	}
+
+	return names
+}
+
+// lowestKubeletVersion returns the lowest kubelet version of the given nodes or an empty string if none can be parsed.
+func lowestKubeletVersion(nodeList *corev1.NodeList) string {
+	var lowest *version.Version
+	var lowestVersion string
+
+	for _, node := range nodeList.Items {
+		v, err := version.ParseSemantic(node.Status.NodeInfo.KubeletVersion)
+		if err != nil {
+			continue
+		}
+
+		if lowest == nil || v.LessThan(lowest) {
+			lowest = v
+			lowestVersion = node.Status.NodeInfo.KubeletVersion
+		}
+	}
+
+	return lowestVersion
+}
+
+func setRemovedAPIsCondition(plan *lifecyclev1alpha1.UpgradePlan, status metav1.ConditionStatus, reason, message string) {
+	condition := metav1.Condition{Type: lifecyclev1alpha1.RemovedAPIsCondition, Status: status, Reason: reason, Message: message}
+	meta.SetStatusCondition(&plan.Status.Conditions, condition)
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const legacyChartManifest = `---
+# Source: legacy/templates/cronjob.yaml
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: cleanup
+  namespace: legacy
+---
+# Source: legacy/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: legacy
+  namespace: legacy
+`
+
+func newRemovedAPIScanner(t *testing.T) *RemovedAPIScanner {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	newBudget := func(name, lastAppliedVersion string) *policyv1.PodDisruptionBudget {
+		budget := &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		if lastAppliedVersion != "" {
+			budget.Annotations = map[string]string{
+				corev1.LastAppliedConfigAnnotation: `{"apiVersion":"` + lastAppliedVersion + `","kind":"PodDisruptionBudget"}`,
+			}
+		}
+		return budget
+	}
+
+	managedFields := func(manager, apiVersion, subresource string) metav1.ManagedFieldsEntry {
+		return metav1.ManagedFieldsEntry{
+			Manager:     manager,
+			Operation:   metav1.ManagedFieldsOperationUpdate,
+			APIVersion:  apiVersion,
+			FieldsType:  "FieldsV1",
+			FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{}`)},
+			Subresource: subresource,
+		}
+	}
+
+	return &RemovedAPIScanner{
+		Reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			newBudget("legacy", "policy/v1beta1"),
+			newBudget("current", "policy/v1"),
+			newBudget("unmanaged", ""),
+			&policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{
+				Name:      "managed",
+				Namespace: "default",
+				ManagedFields: []metav1.ManagedFieldsEntry{
+					managedFields("legacy-operator", "policy/v1beta1", ""),
+					managedFields("kube-controller-manager", "policy/v1", "status"),
+				},
+			}},
+		).WithReturnManagedFields().Build(),
+		HelmRelease: func(name string) (*helmrelease.Release, error) {
+			if name != "legacy" {
+				return nil, helmdriver.ErrReleaseNotFound
+			}
+
+			return &helmrelease.Release{Name: name, Manifest: legacyChartManifest}, nil
+		},
+	}
+}
+
+func TestRemovedAPIScanner(t *testing.T) {
+	scanner := newRemovedAPIScanner(t)
+
+	usages, err := scanner.Scan(context.Background(), []string{"legacy", "missing"}, "v1.24.17+k3s1", "v1.25.16+k3s1")
+	require.NoError(t, err)
+
+	assert.Equal(t, []lifecyclev1alpha1.RemovedAPIUsage{
+		{
+			APIVersion:  "batch/v1beta1",
+			Kind:        "CronJob",
+			Object:      "legacy/cleanup",
+			HelmRelease: "legacy",
+			RemovedIn:   "v1.25",
+			Replacement: "batch/v1",
+		},
+		{
+			APIVersion:  "policy/v1beta1",
+			Kind:        "PodDisruptionBudget",
+			Object:      "default/legacy",
+			RemovedIn:   "v1.25",
+			Replacement: "policy/v1",
+		},
+		{
+			APIVersion:  "policy/v1beta1",
+			Kind:        "PodDisruptionBudget",
+			Object:      "default/managed",
+			RemovedIn:   "v1.25",
+			Replacement: "policy/v1",
+		},
+	}, usages)
+
+	// API versions removed by earlier or later minor versions are not reported.
+	usages, err = scanner.Scan(context.Background(), []string{"legacy"}, "v1.25.16+k3s1", "v1.26.15+k3s1")
+	require.NoError(t, err)
+	assert.Empty(t, usages)
+
+	_, err = scanner.Scan(context.Background(), nil, "unknown", "v1.25.16+k3s1")
+	assert.ErrorContains(t, err, "parsing current kubernetes version 'unknown'")
+}
+
+func TestScanRemovedAPIs(t *testing.T) {
+	charts := []lifecyclev1alpha1.HelmChart{{ReleaseName: "legacy"}}
+
+	tests := []struct {
+		name            string
+		policy          lifecyclev1alpha1.RemovedAPIPolicy
+		targetVersion   string
+		expectedProceed bool
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  string
+		expectedEvent   bool
+	}{
+		{
+			name:            "Report",
+			targetVersion:   "v1.25.16+k3s1",
+			expectedProceed: true,
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  lifecyclev1alpha1.RemovedAPIsReportedReason,
+			expectedEvent:   true,
+		},
+		{
+			name:            "Block",
+			policy:          lifecyclev1alpha1.RemovedAPIPolicyBlock,
+			targetVersion:   "v1.25.16+k3s1",
+			expectedProceed: false,
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  lifecyclev1alpha1.RemovedAPIsBlockedReason,
+			expectedEvent:   true,
+		},
+		{
+			name:            "No removed APIs",
+			policy:          lifecyclev1alpha1.RemovedAPIPolicyBlock,
+			targetVersion:   "v1.24.17+k3s2",
+			expectedProceed: true,
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  lifecyclev1alpha1.RemovedAPIsNotFoundReason,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(5)
+			r := &UpgradePlanReconciler{Recorder: recorder, RemovedAPIScanner: newRemovedAPIScanner(t)}
+
+			plan := newPlanWithConditions(nil)
+			plan.Spec.RemovedAPIPolicy = test.policy
+
+			nodeList := &corev1.NodeList{Items: []corev1.Node{
+				newPathNode("node-1", "v1.24.17+k3s1"),
+				newPathNode("node-2", "v1.25.16+k3s1"),
+			}}
+
+			proceed, err := r.scanRemovedAPIs(context.Background(), plan, charts, nodeList, test.targetVersion)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedProceed, proceed)
+
+			condition := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.RemovedAPIsCondition)
+			require.NotNil(t, condition)
+			assert.Equal(t, test.expectedStatus, condition.Status)
+			assert.Equal(t, test.expectedReason, condition.Reason)
+			assert.Equal(t, test.expectedEvent, len(recorder.Events) == 1)
+
+			if test.expectedStatus == metav1.ConditionTrue {
+				assert.Len(t, plan.Status.RemovedAPIs, 3)
+			} else {
+				assert.Empty(t, plan.Status.RemovedAPIs)
+			}
+
+			// Events are only emitted when the outcome changes.
+			_, err = r.scanRemovedAPIs(context.Background(), plan, charts, nodeList, test.targetVersion)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedEvent, len(recorder.Events) == 1)
+		})
+	}
+}
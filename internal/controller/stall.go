@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// haltedRetryInterval is the delay before a halted stage is evaluated again.
+const haltedRetryInterval = time.Minute
+
+// evaluateStall marks an ongoing upgrade stage as stalled once the deadline of the stage
+// or the deadline of any node being upgraded within it has passed.
+func (r *UpgradePlanReconciler) evaluateStall(plan *lifecyclev1alpha1.UpgradePlan, conditionType string, stage lifecyclev1alpha1.UpgradePlanPhase, previousReason string, now time.Time) {
+	condition := meta.FindStatusCondition(plan.Status.Conditions, conditionType)
+	if condition == nil || (condition.Reason != lifecyclev1alpha1.UpgradeInProgress && condition.Reason != lifecyclev1alpha1.UpgradeStalled) {
+		return
+	}
+
+	message := stallMessage(plan, stage, now)
+	if message == "" {
+		// The deadlines have been extended or the nodes are progressing again.
+		if condition.Reason == lifecyclev1alpha1.UpgradeStalled {
+			setInProgressCondition(plan, conditionType, fmt.Sprintf("%s upgrade resumed", stage))
+		}
+		return
+	}
+
+	setStalledCondition(plan, conditionType, message)
+
+	if previousReason != lifecyclev1alpha1.UpgradeStalled {
+		r.Recorder.Eventf(plan, corev1.EventTypeWarning, lifecyclev1alpha1.UpgradeStalled, "%s upgrade stalled: %s", stage, message)
+	}
+}
+
+// stallMessage describes the exceeded deadlines of the given stage. Returns an empty string if none is exceeded.
+func stallMessage(plan *lifecyclev1alpha1.UpgradePlan, stage lifecyclev1alpha1.UpgradePlanPhase, now time.Time) string {
+	timeouts := plan.Spec.Timeouts
+	if timeouts == nil {
+		return ""
+	}
+
+	if timeouts.Stage != nil {
+		for _, s := range plan.Status.Stages {
+			if s.Name != stage || s.StartTime == nil {
+				continue
+			}
+
+			// Stages whose nodes keep completing past the deadline are not considered stalled.
+			deadline := s.StartTime.Add(timeouts.Stage.Duration)
+			if now.Before(deadline) || isNodeCompletedSince(plan, deadline) {
+				continue
+			}
+
+			var pending []string
+			for _, node := range plan.Status.Nodes {
+				if node.Phase != lifecyclev1alpha1.NodePhaseDone {
+					pending = append(pending, node.Name)
+				}
+			}
+
+			return fmt.Sprintf("Stage exceeded its deadline of %s, nodes not yet upgraded: %s",
+				timeouts.Stage.Duration, strings.Join(pending, ", "))
+		}
+	}
+
+	if timeouts.Node != nil {
+		var stalled []string
+		for _, node := range plan.Status.Nodes {
+			if isNodeUpgradeActive(node.Phase) && node.StartTime != nil && !now.Before(node.StartTime.Add(timeouts.Node.Duration)) {
+				stalled = append(stalled, node.Name)
+			}
+		}
+
+		if len(stalled) != 0 {
+			return fmt.Sprintf("Nodes exceeded their deadline of %s: %s", timeouts.Node.Duration, strings.Join(stalled, ", "))
+		}
+	}
+
+	return ""
+}
+
+// isNodeCompletedSince reports whether any node completed its upgrade within the current stage at or after the given time.
+func isNodeCompletedSince(plan *lifecyclev1alpha1.UpgradePlan, t time.Time) bool {
+	return slices.ContainsFunc(plan.Status.Nodes, func(node lifecyclev1alpha1.NodeUpgradeStatus) bool {
+		return node.CompletionTime != nil && !node.CompletionTime.Time.Before(t)
+	})
+}
+
+// isUpgradeHalted reports whether the creation of further SUC Plans is prevented due to the given stage being stalled.
+// The upgrade continues once the deadlines are no longer exceeded, e.g. after the timeouts of the plan have been edited
+// or the stalled nodes have completed their upgrade.
+func isUpgradeHalted(plan *lifecyclev1alpha1.UpgradePlan, conditionType string, stage lifecyclev1alpha1.UpgradePlanPhase, now time.Time) bool {
+	if plan.Spec.Timeouts == nil || !plan.Spec.Timeouts.HaltOnStall {
+		return false
+	}
+
+	condition := meta.FindStatusCondition(plan.Status.Conditions, conditionType)
+	return condition != nil && condition.Reason == lifecyclev1alpha1.UpgradeStalled && stallMessage(plan, stage, now) != ""
+}
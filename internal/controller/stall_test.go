@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestStallMessage(t *testing.T) {
+	now := time.Now()
+	started := metav1.NewTime(now.Add(-time.Hour))
+	recent := metav1.NewTime(now.Add(-5 * time.Minute))
+
+	nodes := []lifecyclev1alpha1.NodeUpgradeStatus{
+		{Name: "node-1", Phase: lifecyclev1alpha1.NodePhaseDone, StartTime: &started},
+		{Name: "node-2", Phase: lifecyclev1alpha1.NodePhaseRebooting, StartTime: &started},
+		{Name: "node-3", Phase: lifecyclev1alpha1.NodePhaseDraining, StartTime: &recent},
+		{Name: "node-4", Phase: lifecyclev1alpha1.NodePhasePending},
+	}
+	stages := []lifecyclev1alpha1.UpgradeStageStatus{{Name: lifecyclev1alpha1.UpgradePlanPhaseOS, StartTime: &started}}
+
+	tests := []struct {
+		name            string
+		timeouts        *lifecyclev1alpha1.UpgradeTimeouts
+		stage           lifecyclev1alpha1.UpgradePlanPhase
+		completed       *metav1.Time
+		expectedMessage string
+	}{
+		{
+			name:  "No timeouts",
+			stage: lifecyclev1alpha1.UpgradePlanPhaseOS,
+		},
+		{
+			name:            "Stage deadline exceeded",
+			timeouts:        &lifecyclev1alpha1.UpgradeTimeouts{Stage: &metav1.Duration{Duration: 30 * time.Minute}},
+			stage:           lifecyclev1alpha1.UpgradePlanPhaseOS,
+			expectedMessage: "Stage exceeded its deadline of 30m0s, nodes not yet upgraded: node-2, node-3, node-4",
+		},
+		{
+			name:     "Stage deadline not exceeded",
+			timeouts: &lifecyclev1alpha1.UpgradeTimeouts{Stage: &metav1.Duration{Duration: 2 * time.Hour}},
+			stage:    lifecyclev1alpha1.UpgradePlanPhaseOS,
+		},
+		{
+			name:     "Stage deadline of a different stage",
+			timeouts: &lifecyclev1alpha1.UpgradeTimeouts{Stage: &metav1.Duration{Duration: 30 * time.Minute}},
+			stage:    lifecyclev1alpha1.UpgradePlanPhaseKubernetes,
+		},
+		{
+			name:      "Stage deadline exceeded while nodes keep completing",
+			timeouts:  &lifecyclev1alpha1.UpgradeTimeouts{Stage: &metav1.Duration{Duration: 30 * time.Minute}},
+			stage:     lifecyclev1alpha1.UpgradePlanPhaseOS,
+			completed: &recent,
+		},
+		{
+			name:            "Node deadline exceeded",
+			timeouts:        &lifecyclev1alpha1.UpgradeTimeouts{Node: &metav1.Duration{Duration: 30 * time.Minute}},
+			stage:           lifecyclev1alpha1.UpgradePlanPhaseOS,
+			expectedMessage: "Nodes exceeded their deadline of 30m0s: node-2",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := &lifecyclev1alpha1.UpgradePlan{
+				Spec:   lifecyclev1alpha1.UpgradePlanSpec{Timeouts: test.timeouts},
+				Status: lifecyclev1alpha1.UpgradePlanStatus{Nodes: slices.Clone(nodes), Stages: stages},
+			}
+			plan.Status.Nodes[0].CompletionTime = test.completed
+
+			assert.Equal(t, test.expectedMessage, stallMessage(plan, test.stage, now))
+		})
+	}
+}
+
+func TestEvaluateStall(t *testing.T) {
+	started := metav1.NewTime(time.Now().Add(-time.Hour))
+	conditionType := lifecyclev1alpha1.OperatingSystemUpgradedCondition
+
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		Spec: lifecyclev1alpha1.UpgradePlanSpec{
+			Timeouts: &lifecyclev1alpha1.UpgradeTimeouts{Node: &metav1.Duration{Duration: 30 * time.Minute}, HaltOnStall: true},
+		},
+		Status: lifecyclev1alpha1.UpgradePlanStatus{
+			Nodes: []lifecyclev1alpha1.NodeUpgradeStatus{
+				{Name: "node-1", Phase: lifecyclev1alpha1.NodePhaseRebooting, StartTime: &started},
+			},
+		},
+	}
+	setInProgressCondition(plan, conditionType, "Control plane nodes are being upgraded")
+	assert.False(t, isUpgradeHalted(plan, conditionType, lifecyclev1alpha1.UpgradePlanPhaseOS, time.Now()))
+
+	recorder := record.NewFakeRecorder(5)
+	r := &UpgradePlanReconciler{Recorder: recorder}
+
+	r.evaluateStall(plan, conditionType, lifecyclev1alpha1.UpgradePlanPhaseOS, lifecyclev1alpha1.UpgradeInProgress, time.Now())
+
+	condition := meta.FindStatusCondition(plan.Status.Conditions, conditionType)
+	require.NotNil(t, condition)
+	assert.Equal(t, lifecyclev1alpha1.UpgradeStalled, condition.Reason)
+	assert.Contains(t, condition.Message, "node-1")
+	assert.True(t, isUpgradeHalted(plan, conditionType, lifecyclev1alpha1.UpgradePlanPhaseOS, time.Now()))
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Warning Stalled OS upgrade stalled")
+
+	// Subsequent evaluations do not emit further events.
+	setInProgressCondition(plan, conditionType, "Control plane nodes are being upgraded")
+	r.evaluateStall(plan, conditionType, lifecyclev1alpha1.UpgradePlanPhaseOS, lifecyclev1alpha1.UpgradeStalled, time.Now())
+	assert.Empty(t, recorder.Events)
+}
+
+func TestStalledUpgradeResumes(t *testing.T) {
+	started := metav1.NewTime(time.Now().Add(-time.Hour))
+	conditionType := lifecyclev1alpha1.OperatingSystemUpgradedCondition
+	stage := lifecyclev1alpha1.UpgradePlanPhaseOS
+
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1},
+		Spec: lifecyclev1alpha1.UpgradePlanSpec{
+			ReleaseVersion: "3.1.0",
+			Timeouts:       &lifecyclev1alpha1.UpgradeTimeouts{Node: &metav1.Duration{Duration: 30 * time.Minute}, HaltOnStall: true},
+		},
+		Status: lifecyclev1alpha1.UpgradePlanStatus{
+			ObservedGeneration: 1,
+			Nodes: []lifecyclev1alpha1.NodeUpgradeStatus{
+				{Name: "node-1", Phase: lifecyclev1alpha1.NodePhaseRebooting, StartTime: &started},
+			},
+			History: []lifecyclev1alpha1.UpgradeRun{{Generation: 1, StartTime: started}},
+		},
+	}
+
+	hash, err := plan.Spec.RunHash()
+	require.NoError(t, err)
+	plan.Status.RunSpecHash = hash
+
+	r := &UpgradePlanReconciler{Recorder: record.NewFakeRecorder(5)}
+
+	setInProgressCondition(plan, conditionType, "Control plane nodes are being upgraded")
+	r.evaluateStall(plan, conditionType, stage, lifecyclev1alpha1.UpgradeInProgress, time.Now())
+	require.True(t, isUpgradeHalted(plan, conditionType, stage, time.Now()))
+
+	// Extending the timeouts continues the ongoing run.
+	plan.Generation = 2
+	plan.Spec.Timeouts = &lifecyclev1alpha1.UpgradeTimeouts{Node: &metav1.Duration{Duration: 2 * time.Hour}, HaltOnStall: true}
+
+	continued, err := continueUpgradeRun(plan)
+	require.NoError(t, err)
+	require.True(t, continued)
+	assert.False(t, isUpgradeHalted(plan, conditionType, stage, time.Now()))
+
+	r.evaluateStall(plan, conditionType, stage, lifecyclev1alpha1.UpgradeStalled, time.Now())
+
+	condition := meta.FindStatusCondition(plan.Status.Conditions, conditionType)
+	require.NotNil(t, condition)
+	assert.Equal(t, lifecyclev1alpha1.UpgradeInProgress, condition.Reason)
+	assert.Equal(t, "OS upgrade resumed", condition.Message)
+}
+
+func TestIsNodeUpgradeInProgress(t *testing.T) {
+	plan := &lifecyclev1alpha1.UpgradePlan{}
+	assert.False(t, isNodeUpgradeInProgress(plan))
+
+	setPendingCondition(plan, lifecyclev1alpha1.KubernetesUpgradedCondition, "Kubernetes upgrade is not yet started")
+	assert.False(t, isNodeUpgradeInProgress(plan))
+
+	setStalledCondition(plan, lifecyclev1alpha1.OperatingSystemUpgradedCondition, "Nodes exceeded their deadline of 30m0s: node-1")
+	assert.True(t, isNodeUpgradeInProgress(plan))
+
+	setInProgressCondition(plan, lifecyclev1alpha1.OperatingSystemUpgradedCondition, "OS upgrade resumed")
+	assert.True(t, isNodeUpgradeInProgress(plan))
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"slices"
+	"time"
+
+	upgradecattlev1 "github.com/rancher/system-upgrade-controller/pkg/apis/upgrade.cattle.io/v1"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// sucPlanCompleteCondition is reported by SUC v0.13.4 and later
+// once a plan has been applied to all of its selected nodes.
+const sucPlanCompleteCondition = "Complete"
+
+// fallbackRequeueInterval is used for polling the nodes on SUC versions
+// which do not report the completion of their plans.
+const fallbackRequeueInterval = 1 * time.Minute
+
+// isSUCPlanApplied reports whether the given SUC plan has been applied to all of the given nodes.
+// Completion is primarily determined via the plan status, with the availability of the nodes as a secondary signal.
+// SUC versions which do not report the Complete condition fall back to inspecting the nodes via the given function,
+// in which case the second return value is false as progress is not tracked through events.
+func isSUCPlanApplied(plan *upgradecattlev1.Plan, nodes []corev1.Node, inspectNodes func(nodes []corev1.Node) bool) (applied, eventDriven bool) {
+	idx := slices.IndexFunc(plan.Status.Conditions, func(condition upgradecattlev1.GenericCondition) bool {
+		return condition.Type == sucPlanCompleteCondition
+	})
+	if idx == -1 {
+		return inspectNodes(nodes), false
+	}
+
+	complete := plan.Status.Conditions[idx].Status == corev1.ConditionTrue &&
+		plan.Status.LatestHash != "" &&
+		len(plan.Status.Applying) == 0
+
+	return complete && areNodesAvailable(nodes), true
+}
+
+// areNodesAvailable reports whether all given nodes are ready and schedulable.
+func areNodesAvailable(nodes []corev1.Node) bool {
+	return !slices.ContainsFunc(nodes, func(node corev1.Node) bool {
+		return !isNodeReady(&node) || node.Spec.Unschedulable
+	})
+}
+
+// isSUCPlanProgressChanged reports whether any of the SUC plan status fields used for tracking the upgrade differ.
+func isSUCPlanProgressChanged(oldPlan, newPlan *upgradecattlev1.Plan) bool {
+	completeStatus := func(plan *upgradecattlev1.Plan) corev1.ConditionStatus {
+		for _, condition := range plan.Status.Conditions {
+			if condition.Type == sucPlanCompleteCondition {
+				return condition.Status
+			}
+		}
+		return ""
+	}
+
+	return !slices.Equal(oldPlan.Status.Applying, newPlan.Status.Applying) ||
+		oldPlan.Status.LatestHash != newPlan.Status.LatestHash ||
+		completeStatus(oldPlan) != completeStatus(newPlan)
+}
+
+// waitForNodes returns the result for waiting on the nodes of an ongoing stage.
+// Event driven progress only requires a requeue once the next deadline of the stage passes.
+func waitForNodes(plan *lifecyclev1alpha1.UpgradePlan, stage lifecyclev1alpha1.UpgradePlanPhase, eventDriven bool, now time.Time) ctrl.Result {
+	if !eventDriven {
+		return ctrl.Result{RequeueAfter: fallbackRequeueInterval}
+	}
+
+	return ctrl.Result{RequeueAfter: untilNextDeadline(plan, stage, now)}
+}
+
+// untilNextDeadline returns the duration until the earliest upcoming deadline of the given stage or its nodes.
+// Returns zero if there is none.
+func untilNextDeadline(plan *lifecyclev1alpha1.UpgradePlan, stage lifecyclev1alpha1.UpgradePlanPhase, now time.Time) time.Duration {
+	timeouts := plan.Spec.Timeouts
+	if timeouts == nil {
+		return 0
+	}
+
+	var deadlines []time.Time
+
+	if timeouts.Stage != nil {
+		for _, s := range plan.Status.Stages {
+			if s.Name == stage && s.StartTime != nil {
+				deadlines = append(deadlines, s.StartTime.Add(timeouts.Stage.Duration))
+			}
+		}
+	}
+
+	if timeouts.Node != nil {
+		for _, node := range plan.Status.Nodes {
+			if isNodeUpgradeActive(node.Phase) && node.StartTime != nil {
+				deadlines = append(deadlines, node.StartTime.Add(timeouts.Node.Duration))
+			}
+		}
+	}
+
+	var next time.Duration
+	for _, deadline := range deadlines {
+		if until := deadline.Sub(now); until > 0 && (next == 0 || until < next) {
+			next = until
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	upgradecattlev1 "github.com/rancher/system-upgrade-controller/pkg/apis/upgrade.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsSUCPlanApplied(t *testing.T) {
+	availableNodes := []corev1.Node{
+		{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}}},
+	}
+	cordonedNodes := []corev1.Node{
+		{
+			Spec:   corev1.NodeSpec{Unschedulable: true},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+		},
+	}
+	complete := []upgradecattlev1.GenericCondition{{Type: sucPlanCompleteCondition, Status: corev1.ConditionTrue}}
+	incomplete := []upgradecattlev1.GenericCondition{{Type: sucPlanCompleteCondition, Status: corev1.ConditionFalse}}
+
+	tests := []struct {
+		name                string
+		status              upgradecattlev1.PlanStatus
+		nodes               []corev1.Node
+		inspected           bool
+		expectedApplied     bool
+		expectedEventDriven bool
+	}{
+		{
+			name:                "Complete plan",
+			status:              upgradecattlev1.PlanStatus{Conditions: complete, LatestHash: "abc"},
+			nodes:               availableNodes,
+			expectedApplied:     true,
+			expectedEventDriven: true,
+		},
+		{
+			name:                "Incomplete plan",
+			status:              upgradecattlev1.PlanStatus{Conditions: incomplete, LatestHash: "abc", Applying: []string{"node-1"}},
+			nodes:               availableNodes,
+			expectedEventDriven: true,
+		},
+		{
+			name:                "Complete plan with unavailable nodes",
+			status:              upgradecattlev1.PlanStatus{Conditions: complete, LatestHash: "abc"},
+			nodes:               cordonedNodes,
+			expectedEventDriven: true,
+		},
+		{
+			name:                "Unresolved plan",
+			status:              upgradecattlev1.PlanStatus{Conditions: complete},
+			nodes:               availableNodes,
+			expectedEventDriven: true,
+		},
+		{
+			name:            "Older SUC version falls back to node inspection",
+			nodes:           cordonedNodes,
+			inspected:       true,
+			expectedApplied: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := &upgradecattlev1.Plan{Status: test.status}
+
+			applied, eventDriven := isSUCPlanApplied(plan, test.nodes, func([]corev1.Node) bool {
+				return test.inspected
+			})
+
+			assert.Equal(t, test.expectedApplied, applied)
+			assert.Equal(t, test.expectedEventDriven, eventDriven)
+		})
+	}
+}
+
+func TestIsSUCPlanProgressChanged(t *testing.T) {
+	newPlan := func(completeStatus corev1.ConditionStatus, applying ...string) *upgradecattlev1.Plan {
+		return &upgradecattlev1.Plan{
+			Status: upgradecattlev1.PlanStatus{
+				Conditions: []upgradecattlev1.GenericCondition{
+					{Type: "LatestResolved", Status: corev1.ConditionTrue},
+					{Type: sucPlanCompleteCondition, Status: completeStatus},
+				},
+				LatestHash: "abc",
+				Applying:   applying,
+			},
+		}
+	}
+
+	plan := newPlan(corev1.ConditionFalse, "node-1")
+
+	assert.False(t, isSUCPlanProgressChanged(plan, newPlan(corev1.ConditionFalse, "node-1")))
+	assert.True(t, isSUCPlanProgressChanged(plan, newPlan(corev1.ConditionFalse, "node-2")))
+	assert.True(t, isSUCPlanProgressChanged(plan, newPlan(corev1.ConditionTrue)))
+
+	updated := newPlan(corev1.ConditionFalse, "node-1")
+	updated.Status.LatestHash = "def"
+	assert.True(t, isSUCPlanProgressChanged(plan, updated))
+}
+
+func TestWaitForNodes(t *testing.T) {
+	now := time.Now()
+	started := metav1.NewTime(now.Add(-20 * time.Minute))
+
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		Status: lifecyclev1alpha1.UpgradePlanStatus{
+			Stages: []lifecyclev1alpha1.UpgradeStageStatus{{Name: lifecyclev1alpha1.UpgradePlanPhaseOS, StartTime: &started}},
+			Nodes: []lifecyclev1alpha1.NodeUpgradeStatus{
+				{Name: "node-1", Phase: lifecyclev1alpha1.NodePhaseDraining, StartTime: &started},
+			},
+		},
+	}
+
+	assert.Equal(t, fallbackRequeueInterval, waitForNodes(plan, lifecyclev1alpha1.UpgradePlanPhaseOS, false, now).RequeueAfter)
+	assert.Zero(t, waitForNodes(plan, lifecyclev1alpha1.UpgradePlanPhaseOS, true, now).RequeueAfter)
+
+	plan.Spec.Timeouts = &lifecyclev1alpha1.UpgradeTimeouts{
+		Stage: &metav1.Duration{Duration: time.Hour},
+		Node:  &metav1.Duration{Duration: 30 * time.Minute},
+	}
+	assert.Equal(t, 10*time.Minute, waitForNodes(plan, lifecyclev1alpha1.UpgradePlanPhaseOS, true, now).RequeueAfter)
+	assert.Equal(t, 10*time.Minute, waitForNodes(plan, lifecyclev1alpha1.UpgradePlanPhaseKubernetes, true, now).RequeueAfter)
+
+	plan.Spec.Timeouts.Node = nil
+	assert.Equal(t, 40*time.Minute, waitForNodes(plan, lifecyclev1alpha1.UpgradePlanPhaseOS, true, now).RequeueAfter)
+}
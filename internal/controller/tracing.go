@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	upgradePlanAttribute    = attribute.Key("upgradeplan.name")
+	namespaceAttribute      = attribute.Key("upgradeplan.namespace")
+	generationAttribute     = attribute.Key("upgradeplan.generation")
+	releaseVersionAttribute = attribute.Key("upgradeplan.release_version")
+	chartAttribute          = attribute.Key("helm.release_name")
+	resultAttribute         = attribute.Key("upgrade.result")
+)
+
+func (r *UpgradePlanReconciler) tracer() trace.Tracer {
+	if r.Tracer == nil {
+		return noop.NewTracerProvider().Tracer(tracing.TracerName)
+	}
+
+	return r.Tracer
+}
+
+func (r *UpgradePlanReconciler) startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return r.tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// generationContext attaches the root span of the generation currently being upgraded to the context.
+// Reconciliations of plans whose generation is yet to be observed are traced on their own.
+func generationContext(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan) context.Context {
+	if plan.Status.SUCNameSuffix == "" || plan.Status.ObservedGeneration != plan.Generation {
+		return ctx
+	}
+
+	return tracing.ContextWithGeneration(ctx, string(plan.UID), plan.Status.SUCNameSuffix)
+}
+
+// traceFinishedRuns records the root spans of the upgrade runs which have finished during the reconciliation.
+// The root span of a run covers its whole duration and is the parent of the spans of all its reconciliations.
+func (r *UpgradePlanReconciler) traceFinishedRuns(
+	ctx context.Context,
+	plan *lifecyclev1alpha1.UpgradePlan,
+	previousHistory []lifecyclev1alpha1.UpgradeRun,
+	previousSuffix string,
+) {
+	if r.Tracer == nil {
+		return
+	}
+
+	for _, run := range plan.Status.History {
+		if run.CompletionTime == nil || !isRunOngoing(previousHistory, run.Generation) {
+			continue
+		}
+
+		// Superseded runs belong to the generation observed before the reconciliation.
+		suffix := previousSuffix
+		if run.Generation == plan.Status.ObservedGeneration {
+			suffix = plan.Status.SUCNameSuffix
+		}
+
+		_, span := tracing.StartGenerationSpan(ctx, r.Tracer, string(plan.UID), suffix, "UpgradePlan",
+			trace.WithTimestamp(run.StartTime.Time),
+			trace.WithAttributes(
+				upgradePlanAttribute.String(plan.Name),
+				namespaceAttribute.String(plan.Namespace),
+				generationAttribute.Int64(run.Generation),
+				releaseVersionAttribute.String(run.ToReleaseVersion),
+				resultAttribute.String(string(run.Result)),
+			))
+
+		if run.Result == lifecyclev1alpha1.UpgradeRunFailed {
+			span.SetStatus(codes.Error, "upgrade failed")
+		}
+
+		span.End(trace.WithTimestamp(run.CompletionTime.Time))
+	}
+}
+
+func isRunOngoing(history []lifecyclev1alpha1.UpgradeRun, generation int64) bool {
+	for _, run := range history {
+		if run.Generation == generation {
+			return run.CompletionTime == nil
+		}
+	}
+
+	return false
+}
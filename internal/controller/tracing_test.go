@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestTracer() (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter), sdktrace.WithIDGenerator(tracing.IDGenerator{}))
+
+	return exporter, provider
+}
+
+func TestRetrieveReleaseManifestSpan(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, lifecyclev1alpha1.AddToScheme(scheme))
+
+	exporter, provider := newTestTracer()
+
+	r := &UpgradePlanReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithIndex(&lifecyclev1alpha1.ReleaseManifest{}, releaseVersionIndexKey, indexReleaseVersion).
+			Build(),
+		Tracer: provider.Tracer(tracing.TracerName),
+	}
+
+	plan := newPlanWithConditions(nil)
+	plan.UID = "plan-uid"
+	plan.Status.SUCNameSuffix = "abcdef"
+
+	_, err := r.retrieveReleaseManifest(generationContext(context.Background(), plan), plan)
+	require.ErrorIs(t, err, errReleaseManifestNotFound)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	traceID, spanID := tracing.GenerationIDs("plan-uid", "abcdef")
+	assert.Equal(t, "retrieveReleaseManifest", spans[0].Name)
+	assert.Equal(t, traceID, spans[0].SpanContext.TraceID())
+	assert.Equal(t, spanID, spans[0].Parent.SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Contains(t, spans[0].Attributes, releaseVersionAttribute.String("3.1.0"))
+}
+
+func TestGenerationContext(t *testing.T) {
+	plan := newPlanWithConditions(nil)
+	plan.UID = "plan-uid"
+
+	// Plans without SUC resources are not attributed to any generation.
+	ctx := generationContext(context.Background(), plan)
+	assert.Equal(t, context.Background(), ctx)
+
+	plan.Status.SUCNameSuffix = "abcdef"
+	plan.Generation = 2
+	ctx = generationContext(context.Background(), plan)
+	assert.Equal(t, context.Background(), ctx)
+
+	plan.Status.ObservedGeneration = 2
+	ctx = generationContext(context.Background(), plan)
+	assert.NotEqual(t, context.Background(), ctx)
+}
+
+func TestTraceFinishedRuns(t *testing.T) {
+	started := metav1.NewTime(time.Now().Add(-time.Hour))
+	now := metav1.Now()
+
+	exporter, provider := newTestTracer()
+	r := &UpgradePlanReconciler{Tracer: provider.Tracer(tracing.TracerName)}
+
+	plan := newPlanWithConditions(nil)
+	plan.UID = "plan-uid"
+	plan.Generation = 2
+	plan.Status.ObservedGeneration = 2
+	plan.Status.SUCNameSuffix = "ghijkl"
+	plan.Status.History = []lifecyclev1alpha1.UpgradeRun{
+		{Generation: 2, ToReleaseVersion: "3.1.0", StartTime: now},
+		{Generation: 1, ToReleaseVersion: "3.0.2", StartTime: started, CompletionTime: &now, Result: lifecyclev1alpha1.UpgradeRunSuperseded},
+	}
+
+	previousHistory := []lifecyclev1alpha1.UpgradeRun{
+		{Generation: 1, ToReleaseVersion: "3.0.2", StartTime: started},
+	}
+
+	r.traceFinishedRuns(context.Background(), plan, previousHistory, "abcdef")
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+
+	traceID, spanID := tracing.GenerationIDs("plan-uid", "abcdef")
+	assert.Equal(t, "UpgradePlan", spans[0].Name)
+	assert.Equal(t, traceID, spans[0].SpanContext.TraceID())
+	assert.Equal(t, spanID, spans[0].SpanContext.SpanID())
+	assert.Equal(t, started.Time, spans[0].StartTime)
+	assert.Equal(t, now.Time, spans[0].EndTime)
+	assert.Contains(t, spans[0].Attributes, resultAttribute.String(string(lifecyclev1alpha1.UpgradeRunSuperseded)))
+
+	// Runs already finished before the reconciliation are not traced again.
+	exporter.Reset()
+	r.traceFinishedRuns(context.Background(), plan, plan.Status.History, "ghijkl")
+	assert.Empty(t, exporter.GetSpans())
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// maxUpgradeHistory is the number of upgrade runs kept in the status of an upgrade plan.
+const maxUpgradeHistory = 10
+
+// startUpgradeRun records the start of the upgrade for the current generation of the given plan.
+// Unfinished runs of previous generations are marked as superseded.
+func startUpgradeRun(plan *lifecyclev1alpha1.UpgradePlan, now metav1.Time) {
+	for i := range plan.Status.History {
+		if run := &plan.Status.History[i]; run.CompletionTime == nil {
+			run.CompletionTime = &now
+			run.Result = lifecyclev1alpha1.UpgradeRunSuperseded
+		}
+	}
+
+	run := lifecyclev1alpha1.UpgradeRun{
+		Generation:         plan.Generation,
+		FromReleaseVersion: plan.Status.LastSuccessfulReleaseVersion,
+		ToReleaseVersion:   plan.Spec.ReleaseVersion,
+		TriggeredBy:        triggeredBy(plan),
+		StartTime:          now,
+	}
+
+	history := append([]lifecyclev1alpha1.UpgradeRun{run}, plan.Status.History...)
+	if len(history) > maxUpgradeHistory {
+		history = history[:maxUpgradeHistory]
+	}
+
+	plan.Status.History = history
+}
+
+// continueUpgradeRun carries the ongoing run over to the current generation of the given plan if the generation
+// only changed the settings overriding a blocked upgrade. Returns false if a new run has to be started instead.
+func continueUpgradeRun(plan *lifecyclev1alpha1.UpgradePlan) (bool, error) {
+	if plan.Status.RunSpecHash == "" || len(plan.Status.History) == 0 {
+		return false, nil
+	}
+
+	run := &plan.Status.History[0]
+	if run.Generation != plan.Status.ObservedGeneration || run.CompletionTime != nil {
+		return false, nil
+	}
+
+	hash, err := plan.Spec.RunHash()
+	if err != nil {
+		return false, fmt.Errorf("hashing plan spec: %w", err)
+	}
+
+	if hash != plan.Status.RunSpecHash {
+		return false, nil
+	}
+
+	run.Generation = plan.Generation
+	plan.Status.ObservedGeneration = plan.Generation
+
+	return true, nil
+}
+
+// finishUpgradeRun completes the run of the current generation once the upgrade has finished.
+// Returns the completed run or nil if the run is ongoing or has already been completed.
+func finishUpgradeRun(plan *lifecyclev1alpha1.UpgradePlan, now metav1.Time) *lifecyclev1alpha1.UpgradeRun {
+	if len(plan.Status.History) == 0 {
+		return nil
+	}
+
+	run := &plan.Status.History[0]
+	if run.Generation != plan.Status.ObservedGeneration || run.CompletionTime != nil {
+		return nil
+	}
+
+	switch plan.Status.Phase {
+	case lifecyclev1alpha1.UpgradePlanPhaseCompleted:
+		run.Result = lifecyclev1alpha1.UpgradeRunSucceeded
+	case lifecyclev1alpha1.UpgradePlanPhaseFailed:
+		run.Result = lifecyclev1alpha1.UpgradeRunFailed
+	default:
+		return nil
+	}
+
+	run.CompletionTime = &now
+	run.Components = nil
+
+	for _, condition := range plan.Status.Conditions {
+		if isComponentCondition(&condition) {
+			run.Components = append(run.Components, lifecyclev1alpha1.ComponentResult{
+				Name:    condition.Type,
+				Result:  condition.Reason,
+				Message: condition.Message,
+			})
+		}
+	}
+
+	return run
+}
+
+// triggeredBy returns the user which last updated the spec of the given plan as recorded by the webhook.
+// Falls back to the field manager of the last spec update for plans without the recorded user.
+func triggeredBy(plan *lifecyclev1alpha1.UpgradePlan) string {
+	if user := plan.Annotations[lifecyclev1alpha1.SpecUpdatedByAnnotation]; user != "" {
+		return user
+	}
+
+	var latest *metav1.ManagedFieldsEntry
+
+	for i, entry := range plan.ManagedFields {
+		if entry.Subresource != "" || entry.FieldsV1 == nil || !bytes.Contains(entry.FieldsV1.Raw, []byte(`"f:spec"`)) {
+			continue
+		}
+
+		if latest == nil || latest.Time == nil || (entry.Time != nil && !entry.Time.Before(latest.Time)) {
+			latest = &plan.ManagedFields[i]
+		}
+	}
+
+	if latest == nil {
+		return ""
+	}
+
+	return latest.Manager
+}
+
+// createUpgradeRecord exports the given finished run to an UpgradeRecord which outlives the plan.
+// The record is named after the run so that exporting it again, e.g. after the plan status failed to update, is a no-op.
+func (r *UpgradePlanReconciler) createUpgradeRecord(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, run *lifecyclev1alpha1.UpgradeRun) error {
+	record := &lifecyclev1alpha1.UpgradeRecord{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: lifecyclev1alpha1.GroupVersion.String(),
+			Kind:       "UpgradeRecord",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      upgradeRecordName(plan.Name, run),
+			Namespace: plan.Namespace,
+			Labels:    upgrade.PlanIdentifierLabels(plan.Name, plan.Namespace),
+		},
+		Spec: lifecyclev1alpha1.UpgradeRecordSpec{
+			UpgradePlan:    plan.Name,
+			UpgradePlanUID: string(plan.UID),
+			UpgradeRun:     *run,
+		},
+	}
+
+	if err := r.createObject(ctx, plan, record); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("creating upgrade record: %w", err)
+	}
+
+	return nil
+}
+
+// upgradeRecordName returns the name of the UpgradeRecord of the given run, e.g. "upgrade-plan-3-1729339200".
+func upgradeRecordName(planName string, run *lifecyclev1alpha1.UpgradeRun) string {
+	suffix := fmt.Sprintf("-%d-%d", run.Generation, run.StartTime.Unix())
+
+	if maxLength := validation.DNS1123SubdomainMaxLength - len(suffix); len(planName) > maxLength {
+		planName = strings.TrimRight(planName[:maxLength], "-.")
+	}
+
+	return planName + suffix
+}
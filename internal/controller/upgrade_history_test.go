@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStartUpgradeRun(t *testing.T) {
+	now := metav1.Now()
+
+	plan := newPlanWithConditions(nil)
+	plan.Status.LastSuccessfulReleaseVersion = "3.0.2"
+	plan.Status.History = []lifecyclev1alpha1.UpgradeRun{
+		{Generation: 1, ToReleaseVersion: "3.0.2", StartTime: now, CompletionTime: &now, Result: lifecyclev1alpha1.UpgradeRunSucceeded},
+	}
+	plan.Generation = 2
+	plan.Status.ObservedGeneration = 2
+
+	startUpgradeRun(plan, now)
+	require.Len(t, plan.Status.History, 2)
+	assert.Equal(t, int64(2), plan.Status.History[0].Generation)
+	assert.Equal(t, "3.0.2", plan.Status.History[0].FromReleaseVersion)
+	assert.Equal(t, "3.1.0", plan.Status.History[0].ToReleaseVersion)
+	assert.Nil(t, plan.Status.History[0].CompletionTime)
+	assert.Equal(t, lifecyclev1alpha1.UpgradeRunSucceeded, plan.Status.History[1].Result)
+
+	// Editing the plan before the run finishes supersedes it.
+	plan.Generation = 3
+	startUpgradeRun(plan, now)
+	require.Len(t, plan.Status.History, 3)
+	assert.Equal(t, lifecyclev1alpha1.UpgradeRunSuperseded, plan.Status.History[1].Result)
+	assert.NotNil(t, plan.Status.History[1].CompletionTime)
+
+	for i := 0; i < maxUpgradeHistory; i++ {
+		plan.Generation++
+		startUpgradeRun(plan, now)
+	}
+	require.Len(t, plan.Status.History, maxUpgradeHistory)
+	assert.Equal(t, plan.Generation, plan.Status.History[0].Generation)
+}
+
+func TestFinishUpgradeRun(t *testing.T) {
+	now := metav1.Now()
+
+	plan := newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeSucceeded,
+		lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradeInProgress,
+	})
+	startUpgradeRun(plan, now)
+	updateUpgradeSummary(plan, now)
+
+	assert.Nil(t, finishUpgradeRun(plan, now))
+
+	setSuccessfulCondition(plan, lifecyclev1alpha1.KubernetesUpgradedCondition, "All cluster nodes are upgraded")
+	setFailedCondition(plan, "RancherUpgraded", "Job failed")
+	plan.Status.LastSuccessfulReleaseVersion = "3.1.0"
+	updateUpgradeSummary(plan, now)
+
+	run := finishUpgradeRun(plan, now)
+	require.NotNil(t, run)
+	assert.Equal(t, lifecyclev1alpha1.UpgradeRunFailed, run.Result)
+	assert.Equal(t, &now, run.CompletionTime)
+	require.Len(t, run.Components, 3)
+	assert.Equal(t, lifecyclev1alpha1.ComponentResult{Name: "RancherUpgraded", Result: lifecyclev1alpha1.UpgradeFailed, Message: "Job failed"}, run.Components[2])
+
+	// Finished runs are only reported once.
+	assert.Nil(t, finishUpgradeRun(plan, now))
+}
+
+func TestContinueUpgradeRun(t *testing.T) {
+	now := metav1.Now()
+
+	newPlan := func() *lifecyclev1alpha1.UpgradePlan {
+		plan := newPlanWithConditions(nil)
+		plan.Generation = 2
+		plan.Status.ObservedGeneration = 2
+		startUpgradeRun(plan, now)
+
+		hash, err := plan.Spec.RunHash()
+		require.NoError(t, err)
+		plan.Status.RunSpecHash = hash
+
+		plan.Generation = 3
+		return plan
+	}
+
+	// Overrides of a blocked run continue it within the new generation.
+	plan := newPlan()
+	plan.Spec.Preflight = &lifecyclev1alpha1.PreflightChecks{IgnoreFailures: true}
+	plan.Spec.Timeouts = &lifecyclev1alpha1.UpgradeTimeouts{Stage: &metav1.Duration{Duration: time.Hour}}
+	plan.Spec.DrainBlockerPolicy = lifecyclev1alpha1.DrainBlockerPolicyReport
+	plan.Spec.RemovedAPIPolicy = lifecyclev1alpha1.RemovedAPIPolicyReport
+
+	continued, err := continueUpgradeRun(plan)
+	require.NoError(t, err)
+	assert.True(t, continued)
+	assert.Equal(t, int64(3), plan.Status.ObservedGeneration)
+	require.Len(t, plan.Status.History, 1)
+	assert.Equal(t, int64(3), plan.Status.History[0].Generation)
+
+	// Other changes start a new run.
+	plan = newPlan()
+	plan.Spec.ReleaseVersion = "3.2.0"
+
+	continued, err = continueUpgradeRun(plan)
+	require.NoError(t, err)
+	assert.False(t, continued)
+	assert.Equal(t, int64(2), plan.Status.ObservedGeneration)
+
+	// Finished runs are not continued.
+	plan = newPlan()
+	plan.Status.History[0].CompletionTime = &now
+
+	continued, err = continueUpgradeRun(plan)
+	require.NoError(t, err)
+	assert.False(t, continued)
+
+	// Runs started before the spec hash was recorded are not continued.
+	plan = newPlan()
+	plan.Status.RunSpecHash = ""
+
+	continued, err = continueUpgradeRun(plan)
+	require.NoError(t, err)
+	assert.False(t, continued)
+}
+
+func TestTriggeredBy(t *testing.T) {
+	older := metav1.NewTime(time.Now().Add(-time.Hour))
+	newer := metav1.Now()
+
+	plan := &lifecyclev1alpha1.UpgradePlan{}
+	assert.Empty(t, triggeredBy(plan))
+
+	plan.ManagedFields = []metav1.ManagedFieldsEntry{
+		{Manager: "kubectl-client-side-apply", Operation: metav1.ManagedFieldsOperationUpdate, Time: &older,
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:releaseVersion":{}}}`)}},
+		{Manager: "manager", Operation: metav1.ManagedFieldsOperationUpdate, Time: &newer, Subresource: "status",
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:status":{}}`)}},
+		{Manager: "manager", Operation: metav1.ManagedFieldsOperationUpdate, Time: &newer,
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:finalizers":{}}}`)}},
+	}
+	assert.Equal(t, "kubectl-client-side-apply", triggeredBy(plan))
+
+	plan.ManagedFields = append(plan.ManagedFields, metav1.ManagedFieldsEntry{
+		Manager: "kubectl-edit", Operation: metav1.ManagedFieldsOperationUpdate, Time: &newer,
+		FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:releaseVersion":{}}}`)},
+	})
+	assert.Equal(t, "kubectl-edit", triggeredBy(plan))
+
+	plan.Annotations = map[string]string{lifecyclev1alpha1.SpecUpdatedByAnnotation: "jane@example.com"}
+	assert.Equal(t, "jane@example.com", triggeredBy(plan))
+}
+
+func TestCreateUpgradeRecord(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, lifecyclev1alpha1.AddToScheme(scheme))
+
+	now := metav1.Now()
+	plan := newPlanWithConditions(nil)
+	plan.Name = "upgrade-plan"
+	plan.Namespace = "upgrade-controller-system"
+	plan.UID = "plan-uid"
+
+	run := &lifecyclev1alpha1.UpgradeRun{
+		Generation: 1, FromReleaseVersion: "3.0.2", ToReleaseVersion: "3.1.0",
+		StartTime: now, CompletionTime: &now, Result: lifecyclev1alpha1.UpgradeRunSucceeded,
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	recorder := record.NewFakeRecorder(5)
+	r := &UpgradePlanReconciler{Client: c, Scheme: scheme, Recorder: recorder}
+
+	require.NoError(t, r.createUpgradeRecord(context.Background(), plan, run))
+
+	records := &lifecyclev1alpha1.UpgradeRecordList{}
+	require.NoError(t, c.List(context.Background(), records))
+	require.Len(t, records.Items, 1)
+
+	record := records.Items[0]
+	assert.Equal(t, upgradeRecordName("upgrade-plan", run), record.Name)
+	assert.Equal(t, "upgrade-controller-system", record.Namespace)
+	assert.Equal(t, "upgrade-plan", record.Spec.UpgradePlan)
+	assert.Equal(t, "plan-uid", record.Spec.UpgradePlanUID)
+	assert.Equal(t, "3.1.0", record.Spec.ToReleaseVersion)
+	assert.Equal(t, lifecyclev1alpha1.UpgradeRunSucceeded, record.Spec.Result)
+	assert.Empty(t, record.OwnerReferences)
+
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Normal UpgradeRecordCreated")
+
+	// Exporting the run again does not create a duplicate.
+	require.NoError(t, r.createUpgradeRecord(context.Background(), plan, run))
+
+	require.NoError(t, c.List(context.Background(), records))
+	assert.Len(t, records.Items, 1)
+	assert.Empty(t, recorder.Events)
+}
+
+func TestUpgradeRecordName(t *testing.T) {
+	run := &lifecyclev1alpha1.UpgradeRun{Generation: 3, StartTime: metav1.NewTime(time.Unix(1729339200, 0))}
+
+	assert.Equal(t, "upgrade-plan-3-1729339200", upgradeRecordName("upgrade-plan", run))
+
+	name := upgradeRecordName(strings.Repeat("a", 240)+"-plan", run)
+	assert.Len(t, name, 253)
+	assert.True(t, strings.HasSuffix(name, "a-3-1729339200"))
+}
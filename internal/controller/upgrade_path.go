@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// currentReleaseVersion returns the release version which is currently being applied by the given plan.
+// It differs from the target release version while intermediate releases are being applied.
+func currentReleaseVersion(plan *lifecyclev1alpha1.UpgradePlan) string {
+	if path := plan.Status.UpgradePath; path != nil && path.Current != "" {
+		return path.Current
+	}
+
+	return plan.Spec.ReleaseVersion
+}
+
+// isIntermediateRelease reports whether the given plan is currently applying an intermediate release.
+func isIntermediateRelease(plan *lifecyclev1alpha1.UpgradePlan) bool {
+	return currentReleaseVersion(plan) != plan.Spec.ReleaseVersion
+}
+
+// nextIntermediateRelease returns the release following the current one in the upgrade path of the given plan.
+// Returns an empty string if the target release is being applied.
+func nextIntermediateRelease(plan *lifecyclev1alpha1.UpgradePlan) string {
+	path := plan.Status.UpgradePath
+	if path == nil {
+		return ""
+	}
+
+	idx := slices.Index(path.Releases, path.Current)
+	if idx == -1 || idx == len(path.Releases)-1 {
+		return ""
+	}
+
+	return path.Releases[idx+1]
+}
+
+// isIntermediateReleasePending reports whether the upgrade of an intermediate release is yet to be initiated.
+func isIntermediateReleasePending(plan *lifecyclev1alpha1.UpgradePlan) bool {
+	if plan.Status.UpgradePath == nil {
+		return false
+	}
+
+	condition := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.OperatingSystemUpgradedCondition)
+
+	return condition == nil ||
+		(condition.Reason == lifecyclev1alpha1.UpgradePending && condition.Message == intermediateReleasePendingMessage(plan.Status.UpgradePath.Current))
+}
+
+func intermediateReleasePendingMessage(release string) string {
+	return fmt.Sprintf("Upgrade to release %s is not yet started", release)
+}
+
+// advanceUpgradePath moves the given plan to the next release of its upgrade path. The component conditions
+// of the applied release are replaced by pending ones until the upgrade of the next release is initiated,
+// so that the plan cannot be edited in between.
+func advanceUpgradePath(plan *lifecyclev1alpha1.UpgradePlan, next string) {
+	plan.Status.UpgradePath.Current = next
+
+	plan.Status.Conditions = slices.DeleteFunc(plan.Status.Conditions, func(condition metav1.Condition) bool {
+		return isComponentCondition(&condition)
+	})
+
+	// Helm chart conditions are set once the release manifest of the next release is retrieved.
+	setPendingCondition(plan, lifecyclev1alpha1.OperatingSystemUpgradedCondition, intermediateReleasePendingMessage(next))
+	setPendingCondition(plan, lifecyclev1alpha1.KubernetesUpgradedCondition, intermediateReleasePendingMessage(next))
+}
+
+// planUpgradePath determines whether the target release of the given plan has to be reached via intermediate releases.
+// The path is only computed for plans allowing intermediate releases once the target release manifest is present.
+func (r *UpgradePlanReconciler) planUpgradePath(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan) error {
+	plan.Status.UpgradePath = nil
+
+	if !plan.Spec.IntermediateReleases || plan.Annotations[lifecyclev1alpha1.SkipVersionSkewCheckAnnotation] == "true" {
+		return nil
+	}
+
+	manifests, err := r.listAvailableReleaseManifests(ctx, plan)
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(manifests, func(manifest lifecyclev1alpha1.ReleaseManifest) bool {
+		return manifest.Spec.ReleaseVersion == plan.Spec.ReleaseVersion
+	}) {
+		// The target release manifest is retrieved before the path is computed.
+		return nil
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err = r.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	}
+
+	installedRelease, err := r.installedReleaseVersion(ctx, plan)
+	if err != nil {
+		return err
+	}
+
+	releases, err := computeUpgradePath(manifests, nodeList.Items, installedRelease, plan.Spec.ReleaseVersion)
+	if err != nil {
+		return &releaseManifestValidationError{
+			reason:  lifecyclev1alpha1.UpgradePathNotFoundReason,
+			message: err.Error(),
+		}
+	}
+
+	if len(releases) > 1 {
+		plan.Status.UpgradePath = &lifecyclev1alpha1.UpgradePath{
+			Releases: releases,
+			Current:  releases[0],
+		}
+	}
+
+	return nil
+}
+
+// listAvailableReleaseManifests returns the valid release manifests which may be targeted by the given plan.
+// Release manifests in the plan namespace take precedence over the ones in the catalog namespace with the same version.
+func (r *UpgradePlanReconciler) listAvailableReleaseManifests(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan) ([]lifecyclev1alpha1.ReleaseManifest, error) {
+	namespaces := []string{plan.Namespace}
+	if r.CatalogNamespace != "" && r.CatalogNamespace != plan.Namespace {
+		namespaces = append(namespaces, r.CatalogNamespace)
+	}
+
+	var available []lifecyclev1alpha1.ReleaseManifest
+
+	for _, namespace := range namespaces {
+		manifests := &lifecyclev1alpha1.ReleaseManifestList{}
+		if err := r.List(ctx, manifests, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("listing release manifests in namespace %s: %w", namespace, err)
+		}
+
+		for _, manifest := range manifests.Items {
+			if meta.IsStatusConditionFalse(manifest.Status.Conditions, lifecyclev1alpha1.ValidCondition) {
+				continue
+			}
+
+			if slices.ContainsFunc(available, func(m lifecyclev1alpha1.ReleaseManifest) bool {
+				return m.Spec.ReleaseVersion == manifest.Spec.ReleaseVersion
+			}) {
+				continue
+			}
+
+			available = append(available, manifest)
+		}
+	}
+
+	return available, nil
+}
+
+// computeUpgradePath returns the shortest sequence of release versions to apply in order to upgrade the given nodes
+// to the target release. Each step applies a release which can be upgraded from the release of the previous step
+// and whose Kubernetes version neither skips a minor version nor downgrades the nodes upgraded by the previous step.
+// Only releases newer than the installed one are considered and higher releases are preferred among equally short paths.
+func computeUpgradePath(manifests []lifecyclev1alpha1.ReleaseManifest, nodes []corev1.Node, installedRelease, targetRelease string) ([]string, error) {
+	target, err := lifecyclev1alpha1.ValidateReleaseVersion(targetRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	var installed *version.Version
+	if installedRelease != "" {
+		if installed, err = lifecyclev1alpha1.ValidateReleaseVersion(installedRelease); err != nil {
+			return nil, err
+		}
+	}
+
+	type candidate struct {
+		manifest *lifecyclev1alpha1.ReleaseManifest
+		version  *version.Version
+	}
+
+	var candidates []candidate
+	for i := range manifests {
+		v, err := lifecyclev1alpha1.ValidateReleaseVersion(manifests[i].Spec.ReleaseVersion)
+		if err != nil || v.GreaterThan(target) || (installed != nil && !v.GreaterThan(installed)) {
+			continue
+		}
+
+		candidates = append(candidates, candidate{manifest: &manifests[i], version: v})
+	}
+
+	// Prefer the highest releases in order to keep the path as short as possible.
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		switch {
+		case a.version.GreaterThan(b.version):
+			return -1
+		case a.version.LessThan(b.version):
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	// step is a release reached by the breadth-first search. The nodes are simulated after the upgrade to the release,
+	// which only depends on the release itself, so that each release has to be visited once.
+	type step struct {
+		candidate int
+		previous  *step
+		nodes     []corev1.Node
+	}
+
+	start := &step{candidate: len(candidates), nodes: nodes}
+	furthest := start
+	visited := make([]bool, len(candidates))
+	queue := []*step{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		previousRelease := installedRelease
+		if current != start {
+			previousRelease = candidates[current.candidate].manifest.Spec.ReleaseVersion
+		}
+
+		// Only newer releases may follow.
+		for idx := range current.candidate {
+			next := candidates[idx]
+			if visited[idx] || next.manifest.Spec.ValidateUpgradeableFrom(previousRelease) != nil || !isReleaseApplicable(next.manifest, current.nodes) {
+				continue
+			}
+
+			reached := &step{candidate: idx, previous: current, nodes: slices.Clone(current.nodes)}
+			for i := range reached.nodes {
+				kubeletVersion := reached.nodes[i].Status.NodeInfo.KubeletVersion
+				reached.nodes[i].Status.NodeInfo.KubeletVersion = next.manifest.Spec.Components.Kubernetes.Distribution(kubeletVersion).Version
+			}
+
+			if next.version.EqualTo(target) {
+				var path []string
+				for s := reached; s != start; s = s.previous {
+					path = append(path, candidates[s.candidate].manifest.Spec.ReleaseVersion)
+				}
+				slices.Reverse(path)
+
+				return path, nil
+			}
+
+			visited[idx] = true
+			queue = append(queue, reached)
+
+			if furthest == start || idx < furthest.candidate {
+				furthest = reached
+			}
+		}
+	}
+
+	// Report the highest release which could be reached.
+	source := fmt.Sprintf("kubernetes versions %s", strings.Join(kubeletVersions(furthest.nodes), ", "))
+	if furthest != start {
+		source = fmt.Sprintf("release %s and %s", candidates[furthest.candidate].manifest.Spec.ReleaseVersion, source)
+	} else if installedRelease != "" {
+		source = fmt.Sprintf("release %s and %s", installedRelease, source)
+	}
+
+	return nil, fmt.Errorf("no upgrade path to release %s found from %s", targetRelease, source)
+}
+
+// installedReleaseVersion returns the release version currently installed on the cluster. Plans which have not completed
+// an upgrade yet fall back to the highest release recorded on the HelmCharts upgraded by the controller.
+// Returns an empty string if the installed release cannot be determined.
+func (r *UpgradePlanReconciler) installedReleaseVersion(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan) (string, error) {
+	if plan.Status.LastSuccessfulReleaseVersion != "" {
+		return plan.Status.LastSuccessfulReleaseVersion, nil
+	}
+
+	charts := &helmcattlev1.HelmChartList{}
+	if err := r.List(ctx, charts, client.InNamespace(upgrade.KubeSystemNamespace)); err != nil {
+		return "", fmt.Errorf("listing helm charts: %w", err)
+	}
+
+	var installed *version.Version
+	for _, chart := range charts.Items {
+		v, err := version.ParseSemantic(chart.Annotations[upgrade.ReleaseAnnotation])
+		if err != nil {
+			continue
+		}
+
+		if installed == nil || v.GreaterThan(installed) {
+			installed = v
+		}
+	}
+
+	if installed == nil {
+		return "", nil
+	}
+
+	return installed.String(), nil
+}
+
+// isReleaseApplicable reports whether the Kubernetes version of the given release can be applied to all nodes.
+func isReleaseApplicable(manifest *lifecyclev1alpha1.ReleaseManifest, nodes []corev1.Node) bool {
+	for _, node := range nodes {
+		distribution := manifest.Spec.Components.Kubernetes.Distribution(node.Status.NodeInfo.KubeletVersion)
+		if distribution == nil ||
+			lifecyclev1alpha1.ValidateKubernetesVersionSkew([]corev1.Node{node}, distribution.Version) != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func kubeletVersions(nodes []corev1.Node) []string {
+	var versions []string
+	for _, node := range nodes {
+		if v := node.Status.NodeInfo.KubeletVersion; !slices.Contains(versions, v) {
+			versions = append(versions, v)
+		}
+	}
+
+	slices.Sort(versions)
+	return versions
+}
@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newPathManifest(name, namespace, releaseVersion, k3sVersion string) lifecyclev1alpha1.ReleaseManifest {
+	return lifecyclev1alpha1.ReleaseManifest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: lifecyclev1alpha1.ReleaseManifestSpec{
+			ReleaseVersion: releaseVersion,
+			Components: lifecyclev1alpha1.Components{
+				Kubernetes: lifecyclev1alpha1.Kubernetes{K3S: lifecyclev1alpha1.KubernetesDistribution{Version: k3sVersion}},
+			},
+		},
+	}
+}
+
+func newPathNode(name, kubeletVersion string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KubeletVersion: kubeletVersion}},
+	}
+}
+
+func TestComputeUpgradePath(t *testing.T) {
+	constrained := newPathManifest("release-3-3-0", "default", "3.3.0", "v1.32.2+k3s1")
+	constrained.Spec.UpgradeableFrom = "3.2.x"
+
+	// Both 3.1.1 and 3.2.0 can be reached from 3.1.0, while 4.0.0 can only be reached from 3.1.1.
+	backtracking := newPathManifest("release-4-0-0", "default", "4.0.0", "v1.31.4+k3s1")
+	backtracking.Spec.UpgradeableFrom = "3.1.1"
+
+	manifests := []lifecyclev1alpha1.ReleaseManifest{
+		newPathManifest("release-3-0-0", "default", "3.0.0", "v1.28.9+k3s1"),
+		newPathManifest("release-3-0-1", "default", "3.0.1", "v1.28.12+k3s1"),
+		newPathManifest("release-3-1-0", "default", "3.1.0", "v1.30.3+k3s1"),
+		newPathManifest("release-3-1-1", "default", "3.1.1", "v1.30.5+k3s1"),
+		newPathManifest("release-3-2-0", "default", "3.2.0", "v1.31.1+k3s1"),
+		constrained,
+		backtracking,
+	}
+
+	tests := []struct {
+		name             string
+		nodes            []corev1.Node
+		installedRelease string
+		targetRelease    string
+		expectedPath     []string
+		expectedErr      string
+	}{
+		{
+			name:          "Direct upgrade",
+			nodes:         []corev1.Node{newPathNode("node-1", "v1.30.3+k3s1")},
+			targetRelease: "3.2.0",
+			expectedPath:  []string{"3.2.0"},
+		},
+		{
+			name:             "Intermediate releases",
+			nodes:            []corev1.Node{newPathNode("node-1", "v1.29.8+k3s1"), newPathNode("node-2", "v1.29.8+k3s1")},
+			installedRelease: "3.0.1",
+			targetRelease:    "3.3.0",
+			expectedPath:     []string{"3.1.1", "3.2.0", "3.3.0"},
+		},
+		{
+			name:          "Nodes running different versions",
+			nodes:         []corev1.Node{newPathNode("node-1", "v1.29.8+k3s1"), newPathNode("node-2", "v1.30.5+k3s1")},
+			targetRelease: "3.2.0",
+			expectedPath:  []string{"3.1.1", "3.2.0"},
+		},
+		{
+			name:             "Intermediate release required by upgradeable from constraint",
+			nodes:            []corev1.Node{newPathNode("node-1", "v1.31.1+k3s1")},
+			installedRelease: "3.1.1",
+			targetRelease:    "3.3.0",
+			expectedPath:     []string{"3.2.0", "3.3.0"},
+		},
+		{
+			name:             "Lower intermediate release required by upgradeable from constraint",
+			nodes:            []corev1.Node{newPathNode("node-1", "v1.30.3+k3s1")},
+			installedRelease: "3.1.0",
+			targetRelease:    "4.0.0",
+			expectedPath:     []string{"3.1.1", "4.0.0"},
+		},
+		{
+			name:          "Missing intermediate release",
+			nodes:         []corev1.Node{newPathNode("node-1", "v1.28.12+k3s1")},
+			targetRelease: "3.1.1",
+			expectedErr:   "no upgrade path to release 3.1.1 found from release 3.0.1 and kubernetes versions v1.28.12+k3s1",
+		},
+		{
+			name:             "Releases older than the installed one are ignored",
+			nodes:            []corev1.Node{newPathNode("node-1", "v1.28.9+k3s1")},
+			installedRelease: "3.0.1",
+			targetRelease:    "3.0.1",
+			expectedErr:      "no upgrade path to release 3.0.1 found",
+		},
+		{
+			name:          "Unsupported distribution",
+			nodes:         []corev1.Node{newPathNode("node-1", "v1.30.3+rke2r1")},
+			targetRelease: "3.2.0",
+			expectedErr:   "no upgrade path to release 3.2.0 found from kubernetes versions v1.30.3+rke2r1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := computeUpgradePath(manifests, test.nodes, test.installedRelease, test.targetRelease)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedPath, path)
+		})
+	}
+}
+
+func TestPlanUpgradePath(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, lifecyclev1alpha1.AddToScheme(scheme))
+	require.NoError(t, helmcattlev1.AddToScheme(scheme))
+
+	invalid := newPathManifest("release-3-1-1", "default", "3.1.1", "v1.30.5+k3s1")
+	invalid.Status.Conditions = []metav1.Condition{{Type: lifecyclev1alpha1.ValidCondition, Status: metav1.ConditionFalse}}
+
+	node := newPathNode("node-1", "v1.29.8+k3s1")
+	objects := []client.Object{&node, &invalid}
+	for _, manifest := range []lifecyclev1alpha1.ReleaseManifest{
+		newPathManifest("release-3-1-0", "catalog", "3.1.0", "v1.30.3+k3s1"),
+		newPathManifest("release-3-2-0", "default", "3.2.0", "v1.31.1+k3s1"),
+		newPathManifest("release-3-2-0", "catalog", "3.2.0", "v1.32.2+k3s1"),
+		newPathManifest("release-3-3-0", "default", "3.3.0", "v1.33.1+k3s1"),
+	} {
+		objects = append(objects, &manifest)
+	}
+
+	r := &UpgradePlanReconciler{
+		Client:           fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		CatalogNamespace: "catalog",
+	}
+
+	tests := []struct {
+		name           string
+		plan           *lifecyclev1alpha1.UpgradePlan
+		expectedPath   *lifecyclev1alpha1.UpgradePath
+		expectedReason string
+	}{
+		{
+			name: "Intermediate releases",
+			plan: &lifecyclev1alpha1.UpgradePlan{Spec: lifecyclev1alpha1.UpgradePlanSpec{ReleaseVersion: "3.2.0", IntermediateReleases: true}},
+			expectedPath: &lifecyclev1alpha1.UpgradePath{
+				Releases: []string{"3.1.0", "3.2.0"},
+				Current:  "3.1.0",
+			},
+		},
+		{
+			name: "Intermediate releases not allowed",
+			plan: &lifecyclev1alpha1.UpgradePlan{Spec: lifecyclev1alpha1.UpgradePlanSpec{ReleaseVersion: "3.2.0"}},
+		},
+		{
+			name: "Direct upgrade",
+			plan: &lifecyclev1alpha1.UpgradePlan{Spec: lifecyclev1alpha1.UpgradePlanSpec{ReleaseVersion: "3.1.0", IntermediateReleases: true}},
+		},
+		{
+			name: "Missing target release manifest",
+			plan: &lifecyclev1alpha1.UpgradePlan{Spec: lifecyclev1alpha1.UpgradePlanSpec{ReleaseVersion: "3.4.0", IntermediateReleases: true}},
+		},
+		{
+			name:           "Path not found",
+			plan:           &lifecyclev1alpha1.UpgradePlan{Spec: lifecyclev1alpha1.UpgradePlanSpec{ReleaseVersion: "3.3.0", IntermediateReleases: true}},
+			expectedReason: lifecyclev1alpha1.UpgradePathNotFoundReason,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.plan.Namespace = "default"
+			test.plan.Status.UpgradePath = &lifecyclev1alpha1.UpgradePath{Releases: []string{"3.0.0"}, Current: "3.0.0"}
+
+			err := r.planUpgradePath(context.Background(), test.plan)
+			if test.expectedReason != "" {
+				var validationErr *releaseManifestValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, test.expectedReason, validationErr.reason)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedPath, test.plan.Status.UpgradePath)
+		})
+	}
+}
+
+func TestAdvanceUpgradePath(t *testing.T) {
+	plan := newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeSucceeded,
+		lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradeSucceeded,
+		"RancherUpgraded": lifecyclev1alpha1.UpgradeFailed,
+	})
+	meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{
+		Type: lifecyclev1alpha1.ReadyCondition, Status: metav1.ConditionFalse, Reason: lifecyclev1alpha1.UpgradeProgressingReason,
+	})
+	plan.Spec.ReleaseVersion = "3.2.0"
+	plan.Status.UpgradePath = &lifecyclev1alpha1.UpgradePath{Releases: []string{"3.0.1", "3.1.0", "3.2.0"}, Current: "3.0.1"}
+
+	assert.True(t, isIntermediateRelease(plan))
+	assert.False(t, isIntermediateReleasePending(plan))
+
+	next := nextIntermediateRelease(plan)
+	require.Equal(t, "3.1.0", next)
+
+	advanceUpgradePath(plan, next)
+	assert.Equal(t, "3.1.0", currentReleaseVersion(plan))
+	assert.True(t, isIntermediateReleasePending(plan))
+
+	// The component conditions of the applied release are replaced by pending ones.
+	require.Len(t, plan.Status.Conditions, 3)
+	assert.Equal(t, lifecyclev1alpha1.ReadyCondition, plan.Status.Conditions[0].Type)
+	for _, conditionType := range []string{lifecyclev1alpha1.OperatingSystemUpgradedCondition, lifecyclev1alpha1.KubernetesUpgradedCondition} {
+		condition := meta.FindStatusCondition(plan.Status.Conditions, conditionType)
+		require.NotNil(t, condition)
+		assert.Equal(t, lifecyclev1alpha1.UpgradePending, condition.Reason)
+		assert.Equal(t, "Upgrade to release 3.1.0 is not yet started", condition.Message)
+	}
+	assert.Nil(t, meta.FindStatusCondition(plan.Status.Conditions, "RancherUpgraded"))
+
+	// The upgrade of the intermediate release is initiated once the pending conditions are reset.
+	setPendingCondition(plan, lifecyclev1alpha1.OperatingSystemUpgradedCondition, upgradePendingMessage("OS"))
+	assert.False(t, isIntermediateReleasePending(plan))
+
+	advanceUpgradePath(plan, nextIntermediateRelease(plan))
+	assert.False(t, isIntermediateRelease(plan))
+	assert.Empty(t, nextIntermediateRelease(plan))
+}
+
+func TestInstalledReleaseVersion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, helmcattlev1.AddToScheme(scheme))
+
+	newChart := func(name, releaseVersion string) client.Object {
+		chart := &helmcattlev1.HelmChart{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: upgrade.KubeSystemNamespace}}
+		if releaseVersion != "" {
+			chart.Annotations = map[string]string{upgrade.ReleaseAnnotation: releaseVersion}
+		}
+		return chart
+	}
+
+	r := &UpgradePlanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			newChart("rancher", "3.1.1"),
+			newChart("metal3", "3.0.2"),
+			newChart("neuvector", ""),
+		).Build(),
+	}
+
+	plan := &lifecyclev1alpha1.UpgradePlan{}
+	installed, err := r.installedReleaseVersion(context.Background(), plan)
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.1", installed)
+
+	plan.Status.LastSuccessfulReleaseVersion = "3.2.0"
+	installed, err = r.installedReleaseVersion(context.Background(), plan)
+	require.NoError(t, err)
+	assert.Equal(t, "3.2.0", installed)
+}
@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	reportJSONKey     = "report.json"
+	reportMarkdownKey = "report.md"
+)
+
+// upgradeReport summarizes the state of the cluster after an upgrade has completed.
+type upgradeReport struct {
+	UpgradePlan    string                   `json:"upgradePlan"`
+	Namespace      string                   `json:"namespace"`
+	Generation     int64                    `json:"generation"`
+	ReleaseVersion string                   `json:"releaseVersion"`
+	GeneratedAt    metav1.Time              `json:"generatedAt"`
+	Duration       string                   `json:"duration,omitempty"`
+	Stages         []upgradeReportStage     `json:"stages,omitempty"`
+	Nodes          []upgradeReportNode      `json:"nodes"`
+	Charts         []upgradeReportChart     `json:"charts,omitempty"`
+	Components     []upgradeReportComponent `json:"components"`
+}
+
+type upgradeReportStage struct {
+	Name     lifecyclev1alpha1.UpgradePlanPhase `json:"name"`
+	Duration string                             `json:"duration"`
+}
+
+type upgradeReportNode struct {
+	Name           string                     `json:"name"`
+	Role           lifecyclev1alpha1.NodeRole `json:"role"`
+	OSImage        string                     `json:"osImage"`
+	KubeletVersion string                     `json:"kubeletVersion"`
+}
+
+type upgradeReportChart struct {
+	ReleaseName      string `json:"releaseName"`
+	ReleaseVersion   string `json:"releaseVersion"`
+	InstalledVersion string `json:"installedVersion,omitempty"`
+}
+
+type upgradeReportComponent struct {
+	Name    string `json:"name"`
+	Result  string `json:"result"`
+	Message string `json:"message,omitempty"`
+}
+
+// generateUpgradeReport stores the report of the completed upgrade in a ConfigMap referenced from the plan status.
+func (r *UpgradePlanReconciler) generateUpgradeReport(
+	ctx context.Context,
+	plan *lifecyclev1alpha1.UpgradePlan,
+	release *lifecyclev1alpha1.ReleaseManifest,
+	nodeList *corev1.NodeList,
+) error {
+	installedVersions := map[string]string{}
+	for _, chart := range reportCharts(release.Spec.Components.Workloads.Helm) {
+		helmRelease, err := retrieveHelmRelease(chart.ReleaseName)
+		if err != nil {
+			if errors.Is(err, helmdriver.ErrReleaseNotFound) {
+				continue
+			}
+			return fmt.Errorf("retrieving helm release %s: %w", chart.ReleaseName, err)
+		}
+
+		installedVersions[chart.ReleaseName] = helmRelease.Chart.Metadata.Version
+	}
+
+	report := buildUpgradeReport(plan, release, nodeList, installedVersions, metav1.Now())
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling report: %w", err)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-report", plan.Name),
+			Namespace: plan.Namespace,
+			Labels:    upgrade.PlanIdentifierLabels(plan.Name, plan.Namespace),
+		},
+		Data: map[string]string{
+			reportJSONKey:     string(reportJSON),
+			reportMarkdownKey: report.markdown(),
+		},
+	}
+
+	if err = controllerutil.SetControllerReference(plan, configMap, r.Scheme); err != nil {
+		return fmt.Errorf("setting controller reference: %w", err)
+	}
+
+	if err = r.Create(ctx, configMap); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("creating report config map: %w", err)
+		}
+
+		// Reports of previous generations are replaced.
+		if err = r.Update(ctx, configMap); err != nil {
+			return fmt.Errorf("updating report config map: %w", err)
+		}
+	}
+
+	plan.Status.ReportConfigMap = configMap.Name
+	return nil
+}
+
+// isUpgradeReportPending reports whether the report for the current generation of the plan has yet to be generated.
+// Reports are generated once the upgrade completes, before the respective run in the history is finished.
+func isUpgradeReportPending(plan *lifecyclev1alpha1.UpgradePlan) bool {
+	if len(plan.Status.History) == 0 || plan.Status.History[0].Generation != plan.Status.ObservedGeneration {
+		return plan.Status.LastSuccessfulReleaseVersion != plan.Spec.ReleaseVersion
+	}
+
+	return plan.Status.History[0].CompletionTime == nil
+}
+
+// reportCharts flattens the given charts including their dependency and add-on charts.
+func reportCharts(charts []lifecyclev1alpha1.HelmChart) []lifecyclev1alpha1.HelmChart {
+	var flattened []lifecyclev1alpha1.HelmChart
+
+	for _, chart := range charts {
+		flattened = append(flattened, chart.DependencyCharts...)
+		flattened = append(flattened, chart)
+		flattened = append(flattened, chart.AddonCharts...)
+	}
+
+	return flattened
+}
+
+func buildUpgradeReport(
+	plan *lifecyclev1alpha1.UpgradePlan,
+	release *lifecyclev1alpha1.ReleaseManifest,
+	nodeList *corev1.NodeList,
+	installedVersions map[string]string,
+	now metav1.Time,
+) *upgradeReport {
+	report := &upgradeReport{
+		UpgradePlan:    plan.Name,
+		Namespace:      plan.Namespace,
+		Generation:     plan.Generation,
+		ReleaseVersion: release.Spec.ReleaseVersion,
+		GeneratedAt:    now,
+		Nodes:          []upgradeReportNode{},
+		Components:     []upgradeReportComponent{},
+	}
+
+	if len(plan.Status.History) != 0 && plan.Status.History[0].Generation == plan.Generation {
+		report.Duration = now.Sub(plan.Status.History[0].StartTime.Time).Round(time.Second).String()
+	}
+
+	for _, stage := range plan.Status.Stages {
+		if stage.StartTime == nil {
+			continue
+		}
+
+		end := now
+		if stage.CompletionTime != nil {
+			end = *stage.CompletionTime
+		}
+
+		report.Stages = append(report.Stages, upgradeReportStage{
+			Name:     stage.Name,
+			Duration: end.Sub(stage.StartTime.Time).Round(time.Second).String(),
+		})
+	}
+
+	for _, node := range nodeList.Items {
+		report.Nodes = append(report.Nodes, upgradeReportNode{
+			Name:           node.Name,
+			Role:           nodeRole(&node),
+			OSImage:        node.Status.NodeInfo.OSImage,
+			KubeletVersion: node.Status.NodeInfo.KubeletVersion,
+		})
+	}
+
+	for _, chart := range reportCharts(release.Spec.Components.Workloads.Helm) {
+		report.Charts = append(report.Charts, upgradeReportChart{
+			ReleaseName:      chart.ReleaseName,
+			ReleaseVersion:   chart.Version,
+			InstalledVersion: installedVersions[chart.ReleaseName],
+		})
+	}
+
+	for _, condition := range plan.Status.Conditions {
+		if isComponentCondition(&condition) {
+			report.Components = append(report.Components, upgradeReportComponent{
+				Name:    condition.Type,
+				Result:  condition.Reason,
+				Message: condition.Message,
+			})
+		}
+	}
+
+	return report
+}
+
+func (report *upgradeReport) markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Upgrade report: %s/%s\n\n", report.Namespace, report.UpgradePlan)
+	fmt.Fprintf(&b, "- Release version: %s\n", report.ReleaseVersion)
+	fmt.Fprintf(&b, "- Generation: %d\n", report.Generation)
+	fmt.Fprintf(&b, "- Generated at: %s\n", report.GeneratedAt.UTC().Format(time.RFC3339))
+	if report.Duration != "" {
+		fmt.Fprintf(&b, "- Duration: %s\n", report.Duration)
+	}
+
+	if len(report.Stages) != 0 {
+		b.WriteString("\n## Stages\n\n| Stage | Duration |\n|-------|----------|\n")
+		for _, stage := range report.Stages {
+			fmt.Fprintf(&b, "| %s | %s |\n", stage.Name, stage.Duration)
+		}
+	}
+
+	b.WriteString("\n## Nodes\n\n| Node | Role | OS image | Kubelet version |\n|------|------|----------|-----------------|\n")
+	for _, node := range report.Nodes {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", node.Name, node.Role, node.OSImage, node.KubeletVersion)
+	}
+
+	if len(report.Charts) != 0 {
+		b.WriteString("\n## Helm charts\n\n| Release | Release version | Installed version |\n|---------|-----------------|-------------------|\n")
+		for _, chart := range report.Charts {
+			installed := chart.InstalledVersion
+			if installed == "" {
+				installed = "not installed"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", chart.ReleaseName, chart.ReleaseVersion, installed)
+		}
+	}
+
+	b.WriteString("\n## Components\n\n| Component | Result | Message |\n|-----------|--------|---------|\n")
+	for _, component := range report.Components {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", component.Name, component.Result, strings.ReplaceAll(component.Message, "|", "\\|"))
+	}
+
+	return b.String()
+}
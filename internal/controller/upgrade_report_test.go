@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildUpgradeReport(t *testing.T) {
+	now := metav1.Now()
+	started := metav1.NewTime(now.Add(-90 * time.Minute))
+	osCompleted := metav1.NewTime(now.Add(-60 * time.Minute))
+
+	plan := newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeSucceeded,
+		lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradeSucceeded,
+	})
+	plan.Name = "upgrade-plan"
+	plan.Namespace = "upgrade-controller-system"
+	setSkippedCondition(plan, "NeuVectorUpgraded", "'neuvector' chart is not installed")
+	setFailedCondition(plan, "RancherUpgraded", "'rancher' upgrade failed | see logs")
+	plan.Status.History = []lifecyclev1alpha1.UpgradeRun{{Generation: 1, ToReleaseVersion: "3.1.0", StartTime: started}}
+	plan.Status.Stages = []lifecyclev1alpha1.UpgradeStageStatus{
+		{Name: lifecyclev1alpha1.UpgradePlanPhaseOS, StartTime: &started, CompletionTime: &osCompleted},
+		{Name: lifecyclev1alpha1.UpgradePlanPhaseKubernetes, StartTime: &osCompleted},
+		{Name: lifecyclev1alpha1.UpgradePlanPhaseWorkloads},
+	}
+
+	release := &lifecyclev1alpha1.ReleaseManifest{
+		Spec: lifecyclev1alpha1.ReleaseManifestSpec{
+			ReleaseVersion: "3.1.0",
+			Components: lifecyclev1alpha1.Components{
+				Workloads: lifecyclev1alpha1.Workloads{
+					Helm: []lifecyclev1alpha1.HelmChart{
+						{
+							ReleaseName:      "rancher",
+							Version:          "2.9.1",
+							DependencyCharts: []lifecyclev1alpha1.HelmChart{{ReleaseName: "rancher-crd", Version: "2.9.1"}},
+						},
+						{ReleaseName: "neuvector", Version: "104.0.1"},
+					},
+				},
+			},
+		},
+	}
+
+	nodeList := &corev1.NodeList{
+		Items: []corev1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"node-role.kubernetes.io/control-plane": "true"}},
+				Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OSImage: "SL Micro 6.0", KubeletVersion: "v1.30.3+k3s1"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+				Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OSImage: "SL Micro 6.0", KubeletVersion: "v1.30.3+k3s1"}},
+			},
+		},
+	}
+
+	installed := map[string]string{"rancher": "2.9.1", "rancher-crd": "2.9.1"}
+
+	report := buildUpgradeReport(plan, release, nodeList, installed, now)
+	assert.Equal(t, "1h30m0s", report.Duration)
+	assert.Equal(t, []upgradeReportStage{
+		{Name: lifecyclev1alpha1.UpgradePlanPhaseOS, Duration: "30m0s"},
+		{Name: lifecyclev1alpha1.UpgradePlanPhaseKubernetes, Duration: "1h0m0s"},
+	}, report.Stages)
+	assert.Equal(t, []upgradeReportNode{
+		{Name: "node-1", Role: lifecyclev1alpha1.NodeRoleControlPlane, OSImage: "SL Micro 6.0", KubeletVersion: "v1.30.3+k3s1"},
+		{Name: "node-2", Role: lifecyclev1alpha1.NodeRoleWorker, OSImage: "SL Micro 6.0", KubeletVersion: "v1.30.3+k3s1"},
+	}, report.Nodes)
+	assert.Equal(t, []upgradeReportChart{
+		{ReleaseName: "rancher-crd", ReleaseVersion: "2.9.1", InstalledVersion: "2.9.1"},
+		{ReleaseName: "rancher", ReleaseVersion: "2.9.1", InstalledVersion: "2.9.1"},
+		{ReleaseName: "neuvector", ReleaseVersion: "104.0.1"},
+	}, report.Charts)
+	require.Len(t, report.Components, 4)
+	assert.Equal(t, upgradeReportComponent{Name: "NeuVectorUpgraded", Result: lifecyclev1alpha1.UpgradeSkipped, Message: "'neuvector' chart is not installed"}, report.Components[2])
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"kubeletVersion":"v1.30.3+k3s1"`)
+
+	markdown := report.markdown()
+	assert.Contains(t, markdown, "# Upgrade report: upgrade-controller-system/upgrade-plan")
+	assert.Contains(t, markdown, "| node-1 | ControlPlane | SL Micro 6.0 | v1.30.3+k3s1 |")
+	assert.Contains(t, markdown, "| neuvector | 104.0.1 | not installed |")
+	assert.Contains(t, markdown, "| RancherUpgraded | Failed | 'rancher' upgrade failed \\| see logs |")
+}
+
+func TestIsUpgradeReportPending(t *testing.T) {
+	now := metav1.Now()
+
+	plan := newPlanWithConditions(nil)
+	assert.True(t, isUpgradeReportPending(plan))
+
+	plan.Status.LastSuccessfulReleaseVersion = "3.1.0"
+	assert.False(t, isUpgradeReportPending(plan))
+
+	// Upgrades of the same release version within a new generation are reported again.
+	plan.Status.History = []lifecyclev1alpha1.UpgradeRun{{Generation: 1, ToReleaseVersion: "3.1.0", StartTime: now}}
+	assert.True(t, isUpgradeReportPending(plan))
+
+	plan.Status.History[0].CompletionTime = &now
+	assert.False(t, isUpgradeReportPending(plan))
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateUpgradeSummary evaluates the overall phase, progress and stage timings of the given plan
+// based on its component conditions and summarizes them in the Ready condition.
+func updateUpgradeSummary(plan *lifecyclev1alpha1.UpgradePlan, now metav1.Time) {
+	phase, failedComponents := upgradePhase(plan)
+
+	if phase != plan.Status.Phase {
+		updateStageTimes(plan, phase, now)
+		plan.Status.Phase = phase
+	}
+
+	plan.Status.Progress = upgradeProgress(plan)
+
+	condition := metav1.Condition{
+		Type:               lifecyclev1alpha1.ReadyCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: plan.Generation,
+	}
+
+	validation := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.ValidationFailedCondition)
+	preflight := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.PreflightChecksCondition)
+	drain := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.DrainBlockedCondition)
+	removedAPIs := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.RemovedAPIsCondition)
+	stalledIdx := slices.IndexFunc(plan.Status.Conditions, func(c metav1.Condition) bool {
+		return c.Reason == lifecyclev1alpha1.UpgradeStalled
+	})
+
+	switch {
+	case validation != nil:
+		condition.Reason = lifecyclev1alpha1.ValidationFailedCondition
+		condition.Message = validation.Message
+	case preflight != nil && preflight.Status == metav1.ConditionFalse:
+		condition.Reason = lifecyclev1alpha1.PreflightChecksFailedReason
+		condition.Message = preflight.Message
+	case drain != nil && drain.Reason == lifecyclev1alpha1.DrainRefusedReason:
+		condition.Reason = lifecyclev1alpha1.DrainRefusedReason
+		condition.Message = drain.Message
+	case removedAPIs != nil && removedAPIs.Reason == lifecyclev1alpha1.RemovedAPIsBlockedReason:
+		condition.Reason = lifecyclev1alpha1.RemovedAPIsBlockedReason
+		condition.Message = removedAPIs.Message
+	case stalledIdx != -1:
+		stalled := plan.Status.Conditions[stalledIdx]
+		condition.Reason = lifecyclev1alpha1.UpgradeStalled
+		condition.Message = fmt.Sprintf("%s: %s", stalled.Type, stalled.Message)
+	case phase == lifecyclev1alpha1.UpgradePlanPhaseCompleted:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = lifecyclev1alpha1.UpgradeCompletedReason
+		condition.Message = fmt.Sprintf("Upgrade to release %s completed", plan.Spec.ReleaseVersion)
+	case phase == lifecyclev1alpha1.UpgradePlanPhaseFailed:
+		condition.Reason = lifecyclev1alpha1.ComponentUpgradeFailedReason
+		condition.Message = fmt.Sprintf("Upgrade to release %s finished, but the following components failed: %s",
+			plan.Spec.ReleaseVersion, strings.Join(failedComponents, ", "))
+	case isIntermediateRelease(plan):
+		condition.Reason = lifecyclev1alpha1.UpgradeProgressingReason
+		condition.Message = fmt.Sprintf("Upgrade to release %s is in %s phase of intermediate release %s",
+			plan.Spec.ReleaseVersion, phase, currentReleaseVersion(plan))
+	default:
+		condition.Reason = lifecyclev1alpha1.UpgradeProgressingReason
+		condition.Message = fmt.Sprintf("Upgrade to release %s is in %s phase", plan.Spec.ReleaseVersion, phase)
+	}
+
+	meta.SetStatusCondition(&plan.Status.Conditions, condition)
+}
+
+// upgradePhase determines the overall phase of the upgrade.
+// The types of the failed component conditions are returned as well once the upgrade has finished.
+func upgradePhase(plan *lifecyclev1alpha1.UpgradePlan) (lifecyclev1alpha1.UpgradePlanPhase, []string) {
+	if plan.Status.ObservedGeneration != plan.Generation {
+		return lifecyclev1alpha1.UpgradePlanPhasePending, nil
+	}
+
+	if plan.Status.LastSuccessfulReleaseVersion == plan.Spec.ReleaseVersion {
+		var failed []string
+		for _, condition := range plan.Status.Conditions {
+			if isComponentCondition(&condition) && condition.Reason == lifecyclev1alpha1.UpgradeFailed {
+				failed = append(failed, condition.Type)
+			}
+		}
+
+		if len(failed) != 0 {
+			return lifecyclev1alpha1.UpgradePlanPhaseFailed, failed
+		}
+
+		return lifecyclev1alpha1.UpgradePlanPhaseCompleted, nil
+	}
+
+	osCondition := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.OperatingSystemUpgradedCondition)
+
+	switch {
+	case osCondition == nil || osCondition.Reason == lifecyclev1alpha1.UpgradePending:
+		return lifecyclev1alpha1.UpgradePlanPhasePending, nil
+	case osCondition.Status != metav1.ConditionTrue:
+		return lifecyclev1alpha1.UpgradePlanPhaseOS, nil
+	case !meta.IsStatusConditionTrue(plan.Status.Conditions, lifecyclev1alpha1.KubernetesUpgradedCondition):
+		return lifecyclev1alpha1.UpgradePlanPhaseKubernetes, nil
+	default:
+		return lifecyclev1alpha1.UpgradePlanPhaseWorkloads, nil
+	}
+}
+
+// updateStageTimes completes all ongoing stages other than the given phase and starts the latter if it is a stage.
+func updateStageTimes(plan *lifecyclev1alpha1.UpgradePlan, phase lifecyclev1alpha1.UpgradePlanPhase, now metav1.Time) {
+	for i := range plan.Status.Stages {
+		stage := &plan.Status.Stages[i]
+		if stage.Name != phase && stage.StartTime != nil && stage.CompletionTime == nil {
+			stage.CompletionTime = &now
+		}
+	}
+
+	switch phase {
+	case lifecyclev1alpha1.UpgradePlanPhaseOS, lifecyclev1alpha1.UpgradePlanPhaseKubernetes, lifecyclev1alpha1.UpgradePlanPhaseWorkloads:
+	default:
+		return
+	}
+
+	if !slices.ContainsFunc(plan.Status.Stages, func(stage lifecyclev1alpha1.UpgradeStageStatus) bool {
+		return stage.Name == phase
+	}) {
+		plan.Status.Stages = append(plan.Status.Stages, lifecyclev1alpha1.UpgradeStageStatus{Name: phase, StartTime: &now})
+	}
+}
+
+// upgradeProgress returns the number of finished components out of all components tracked in the plan conditions.
+func upgradeProgress(plan *lifecyclev1alpha1.UpgradePlan) string {
+	var finished, total int
+
+	for _, condition := range plan.Status.Conditions {
+		if !isComponentCondition(&condition) {
+			continue
+		}
+
+		total++
+		if condition.Status == metav1.ConditionTrue ||
+			condition.Reason == lifecyclev1alpha1.UpgradeSkipped || condition.Reason == lifecyclev1alpha1.UpgradeFailed {
+			finished++
+		}
+	}
+
+	return fmt.Sprintf("%d/%d", finished, total)
+}
+
+// isComponentCondition reports whether the given condition tracks the upgrade of a single component.
+func isComponentCondition(condition *metav1.Condition) bool {
+	return slices.Contains(componentStates, condition.Reason)
+}
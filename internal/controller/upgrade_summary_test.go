@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpgradePhase(t *testing.T) {
+	rancherCondition := lifecyclev1alpha1.GetChartConditionType("Rancher")
+
+	tests := []struct {
+		name           string
+		conditions     map[string]string
+		completed      bool
+		expectedPhase  lifecyclev1alpha1.UpgradePlanPhase
+		expectedFailed []string
+	}{
+		{
+			name: "Upgrade not started",
+			conditions: map[string]string{
+				lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradePending,
+				lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradePending,
+			},
+			expectedPhase: lifecyclev1alpha1.UpgradePlanPhasePending,
+		},
+		{
+			name: "OS upgrade in progress",
+			conditions: map[string]string{
+				lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeInProgress,
+				lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradePending,
+			},
+			expectedPhase: lifecyclev1alpha1.UpgradePlanPhaseOS,
+		},
+		{
+			name: "Kubernetes upgrade in progress",
+			conditions: map[string]string{
+				lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeSucceeded,
+				lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradeInProgress,
+			},
+			expectedPhase: lifecyclev1alpha1.UpgradePlanPhaseKubernetes,
+		},
+		{
+			name: "Workloads upgrade in progress",
+			conditions: map[string]string{
+				lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeSucceeded,
+				lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradeSucceeded,
+				rancherCondition: lifecyclev1alpha1.UpgradeInProgress,
+			},
+			expectedPhase: lifecyclev1alpha1.UpgradePlanPhaseWorkloads,
+		},
+		{
+			name: "Upgrade completed",
+			conditions: map[string]string{
+				lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeSucceeded,
+				lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradeSucceeded,
+				rancherCondition: lifecyclev1alpha1.UpgradeSkipped,
+			},
+			completed:     true,
+			expectedPhase: lifecyclev1alpha1.UpgradePlanPhaseCompleted,
+		},
+		{
+			name: "Upgrade failed",
+			conditions: map[string]string{
+				lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeSucceeded,
+				lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradeSucceeded,
+				rancherCondition: lifecyclev1alpha1.UpgradeFailed,
+			},
+			completed:      true,
+			expectedPhase:  lifecyclev1alpha1.UpgradePlanPhaseFailed,
+			expectedFailed: []string{rancherCondition},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := newPlanWithConditions(test.conditions)
+			if test.completed {
+				plan.Status.LastSuccessfulReleaseVersion = plan.Spec.ReleaseVersion
+			}
+
+			phase, failed := upgradePhase(plan)
+			assert.Equal(t, test.expectedPhase, phase)
+			assert.Equal(t, test.expectedFailed, failed)
+		})
+	}
+}
+
+func TestUpdateUpgradeSummary(t *testing.T) {
+	start := metav1.NewTime(time.Now().Truncate(time.Second))
+	plan := newPlanWithConditions(map[string]string{
+		lifecyclev1alpha1.OperatingSystemUpgradedCondition: lifecyclev1alpha1.UpgradeInProgress,
+		lifecyclev1alpha1.KubernetesUpgradedCondition:      lifecyclev1alpha1.UpgradePending,
+	})
+
+	updateUpgradeSummary(plan, start)
+
+	assert.Equal(t, lifecyclev1alpha1.UpgradePlanPhaseOS, plan.Status.Phase)
+	assert.Equal(t, "0/2", plan.Status.Progress)
+	require.Len(t, plan.Status.Stages, 1)
+	assert.Equal(t, lifecyclev1alpha1.UpgradeStageStatus{Name: lifecyclev1alpha1.UpgradePlanPhaseOS, StartTime: &start}, plan.Status.Stages[0])
+
+	ready := meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.ReadyCondition)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionFalse, ready.Status)
+	assert.Equal(t, lifecyclev1alpha1.UpgradeProgressingReason, ready.Reason)
+	assert.Equal(t, plan.Generation, ready.ObservedGeneration)
+
+	end := metav1.NewTime(start.Add(time.Hour))
+	setSuccessfulCondition(plan, lifecyclev1alpha1.OperatingSystemUpgradedCondition, "")
+	setSuccessfulCondition(plan, lifecyclev1alpha1.KubernetesUpgradedCondition, "")
+	plan.Status.LastSuccessfulReleaseVersion = plan.Spec.ReleaseVersion
+
+	updateUpgradeSummary(plan, end)
+
+	assert.Equal(t, lifecyclev1alpha1.UpgradePlanPhaseCompleted, plan.Status.Phase)
+	assert.Equal(t, "2/2", plan.Status.Progress)
+	require.Len(t, plan.Status.Stages, 1)
+	assert.Equal(t, &end, plan.Status.Stages[0].CompletionTime)
+
+	ready = meta.FindStatusCondition(plan.Status.Conditions, lifecyclev1alpha1.ReadyCondition)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionTrue, ready.Status)
+	assert.Equal(t, lifecyclev1alpha1.UpgradeCompletedReason, ready.Reason)
+}
+
+func newPlanWithConditions(conditions map[string]string) *lifecyclev1alpha1.UpgradePlan {
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1},
+		Spec:       lifecyclev1alpha1.UpgradePlanSpec{ReleaseVersion: "3.1.0"},
+		Status:     lifecyclev1alpha1.UpgradePlanStatus{ObservedGeneration: 1},
+	}
+
+	for conditionType, reason := range conditions {
+		status := metav1.ConditionFalse
+		switch reason {
+		case lifecyclev1alpha1.UpgradeSucceeded:
+			status = metav1.ConditionTrue
+		case lifecyclev1alpha1.UpgradePending, lifecyclev1alpha1.UpgradeError:
+			status = metav1.ConditionUnknown
+		}
+
+		meta.SetStatusCondition(&plan.Status.Conditions, metav1.Condition{Type: conditionType, Status: status, Reason: reason})
+	}
+
+	return plan
+}
@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// rejectedBumpRetryInterval is the delay before a rejected release version bump is attempted again.
+const rejectedBumpRetryInterval = 10 * time.Minute
+
+// UpgradeChannelReconciler bumps the release version of UpgradePlans following an upgrade channel
+type UpgradeChannelReconciler struct {
+	client.Client
+	Scheme           *runtime.Scheme
+	Recorder         record.EventRecorder
+	CatalogNamespace string
+}
+
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=upgradeplans,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=releasemanifests,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile bumps the release version of an UpgradePlan once a newer
+// release manifest matching its channel becomes available.
+func (r *UpgradeChannelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	plan := &lifecyclev1alpha1.UpgradePlan{}
+
+	if err := r.Get(ctx, req.NamespacedName, plan); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if plan.Spec.Channel == nil || !plan.ObjectMeta.DeletionTimestamp.IsZero() || !isUpgradeFinished(plan) {
+		return ctrl.Result{}, nil
+	}
+
+	logger := log.FromContext(ctx)
+
+	manifest, err := r.findChannelRelease(ctx, plan)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if manifest == nil {
+		return ctrl.Result{}, nil
+	}
+
+	inWindow, untilNextWindow, err := inMaintenanceWindow(plan.Spec.Channel.MaintenanceWindows, time.Now())
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("evaluating maintenance windows: %w", err)
+	} else if !inWindow {
+		logger.Info("Waiting for maintenance window", "releaseVersion", manifest.Spec.ReleaseVersion, "after", untilNextWindow)
+		return ctrl.Result{RequeueAfter: untilNextWindow}, nil
+	}
+
+	previousVersion := plan.Spec.ReleaseVersion
+
+	plan.Spec.ReleaseVersion = manifest.Spec.ReleaseVersion
+	if plan.Spec.ReleaseManifestRef != nil {
+		plan.Spec.ReleaseManifestRef = &lifecyclev1alpha1.ReleaseManifestReference{Name: manifest.Name, Namespace: manifest.Namespace}
+	}
+
+	if err = r.Update(ctx, plan); err != nil {
+		if apierrors.IsForbidden(err) || apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) {
+			r.Recorder.Eventf(plan, corev1.EventTypeWarning, lifecyclev1alpha1.ReleaseVersionBumpRejectedReason,
+				"Bumping release version from %s to %s following channel %s was rejected: %s",
+				previousVersion, manifest.Spec.ReleaseVersion, plan.Spec.Channel.Version, err)
+
+			return ctrl.Result{RequeueAfter: rejectedBumpRetryInterval}, nil
+		}
+
+		return ctrl.Result{}, fmt.Errorf("bumping release version: %w", err)
+	}
+
+	r.Recorder.Eventf(plan, corev1.EventTypeNormal, lifecyclev1alpha1.ReleaseVersionBumpedReason,
+		"Release version bumped from %s to %s following channel %s", previousVersion, manifest.Spec.ReleaseVersion, plan.Spec.Channel.Version)
+
+	return ctrl.Result{}, nil
+}
+
+// findChannelRelease returns the release manifest with the highest version matching the channel of the given plan
+// which is newer than the currently applied release. Returns nil if there is no such release manifest.
+func (r *UpgradeChannelReconciler) findChannelRelease(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan) (*lifecyclev1alpha1.ReleaseManifest, error) {
+	namespaces := []string{plan.Namespace}
+	if r.CatalogNamespace != "" && r.CatalogNamespace != plan.Namespace {
+		namespaces = append(namespaces, r.CatalogNamespace)
+	}
+
+	var manifests []lifecyclev1alpha1.ReleaseManifest
+
+	for _, namespace := range namespaces {
+		list := &lifecyclev1alpha1.ReleaseManifestList{}
+		if err := r.List(ctx, list, &client.ListOptions{Namespace: namespace}); err != nil {
+			return nil, fmt.Errorf("listing release manifests: %w", err)
+		}
+
+		manifests = append(manifests, list.Items...)
+	}
+
+	return selectChannelRelease(plan.Spec.Channel.Version, plan.Status.LastSuccessfulReleaseVersion, manifests)
+}
+
+func selectChannelRelease(channel, currentVersion string, manifests []lifecyclev1alpha1.ReleaseManifest) (*lifecyclev1alpha1.ReleaseManifest, error) {
+	constraint, err := semver.NewConstraint(channel)
+	if err != nil {
+		return nil, fmt.Errorf("parsing channel version: %w", err)
+	}
+
+	current, err := semver.NewVersion(currentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("parsing current release version: %w", err)
+	}
+
+	var selected *lifecyclev1alpha1.ReleaseManifest
+	var selectedVersion *semver.Version
+
+	for i := range manifests {
+		manifest := &manifests[i]
+
+		if !meta.IsStatusConditionTrue(manifest.Status.Conditions, lifecyclev1alpha1.ValidCondition) {
+			continue
+		}
+
+		v, err := semver.NewVersion(manifest.Spec.ReleaseVersion)
+		if err != nil || !v.GreaterThan(current) || !constraint.Check(v) {
+			continue
+		}
+
+		// Manifests in the plan namespace are listed first and take precedence over catalog ones.
+		if selectedVersion == nil || v.GreaterThan(selectedVersion) {
+			selected, selectedVersion = manifest, v
+		}
+	}
+
+	return selected, nil
+}
+
+// isUpgradeFinished reports whether the latest requested upgrade of the given plan has been successfully completed.
+// Upgrades whose components failed or stalled are not considered finished, as the release version was set regardless.
+func isUpgradeFinished(plan *lifecyclev1alpha1.UpgradePlan) bool {
+	if plan.Status.ObservedGeneration != plan.Generation || plan.Status.LastSuccessfulReleaseVersion != plan.Spec.ReleaseVersion {
+		return false
+	}
+
+	unfinishedStates := []string{
+		lifecyclev1alpha1.UpgradeInProgress,
+		lifecyclev1alpha1.UpgradePending,
+		lifecyclev1alpha1.UpgradeError,
+		lifecyclev1alpha1.UpgradeFailed,
+		lifecyclev1alpha1.UpgradeStalled,
+	}
+
+	return !slices.ContainsFunc(plan.Status.Conditions, func(condition metav1.Condition) bool {
+		return slices.Contains(unfinishedStates, condition.Reason)
+	})
+}
+
+// inMaintenanceWindow reports whether the given time is within any of the given maintenance windows.
+// If not, the duration until the next window starts is returned as well.
+func inMaintenanceWindow(windows []lifecyclev1alpha1.MaintenanceWindow, now time.Time) (bool, time.Duration, error) {
+	if len(windows) == 0 {
+		return true, 0, nil
+	}
+
+	var untilNext time.Duration
+
+	for _, window := range windows {
+		location, err := time.LoadLocation(window.TimeZone)
+		if err != nil {
+			return false, 0, fmt.Errorf("loading time zone: %w", err)
+		}
+
+		startTime, err := time.ParseInLocation("15:04", window.Start, location)
+		if err != nil {
+			return false, 0, fmt.Errorf("parsing start time: %w", err)
+		}
+
+		local := now.In(location)
+
+		// Windows started during the previous week may still be open.
+		for offset := -7; offset <= 7; offset++ {
+			day := local.AddDate(0, 0, offset)
+			start := time.Date(day.Year(), day.Month(), day.Day(), startTime.Hour(), startTime.Minute(), 0, 0, location)
+
+			if len(window.Days) != 0 && !slices.Contains(window.Days, lifecyclev1alpha1.Weekday(start.Weekday().String())) {
+				continue
+			}
+
+			switch {
+			case !now.Before(start) && now.Before(start.Add(window.Duration.Duration)):
+				return true, 0, nil
+			case start.After(now) && (untilNext == 0 || start.Sub(now) < untilNext):
+				untilNext = start.Sub(now)
+			}
+		}
+	}
+
+	return false, untilNext, nil
+}
+
+func (r *UpgradeChannelReconciler) findUpgradePlansFromReleaseManifest(ctx context.Context, manifest client.Object) []reconcile.Request {
+	listOpts := &client.ListOptions{}
+	if manifest.GetNamespace() != r.CatalogNamespace {
+		listOpts.Namespace = manifest.GetNamespace()
+	}
+
+	plans := &lifecyclev1alpha1.UpgradePlanList{}
+	if err := r.List(ctx, plans, listOpts); err != nil {
+		logger := log.FromContext(ctx)
+		logger.Error(err, "failed to list upgrade plans")
+
+		return []reconcile.Request{}
+	}
+
+	var requests []reconcile.Request
+	for _, plan := range plans.Items {
+		if plan.Spec.Channel != nil {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: plan.Namespace, Name: plan.Name},
+			})
+		}
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *UpgradeChannelReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("upgradechannel").
+		For(&lifecyclev1alpha1.UpgradePlan{}).
+		Watches(&lifecyclev1alpha1.ReleaseManifest{}, handler.EnqueueRequestsFromMapFunc(r.findUpgradePlansFromReleaseManifest)).
+		Complete(r)
+}
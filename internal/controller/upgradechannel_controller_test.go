@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestSelectChannelRelease(t *testing.T) {
+	validCondition := []metav1.Condition{{Type: lifecyclev1alpha1.ValidCondition, Status: metav1.ConditionTrue}}
+	invalidCondition := []metav1.Condition{{Type: lifecyclev1alpha1.ValidCondition, Status: metav1.ConditionFalse}}
+
+	newManifest := func(name, namespace, version string, conditions []metav1.Condition) lifecyclev1alpha1.ReleaseManifest {
+		return lifecyclev1alpha1.ReleaseManifest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       lifecyclev1alpha1.ReleaseManifestSpec{ReleaseVersion: version},
+			Status:     lifecyclev1alpha1.ReleaseManifestStatus{Conditions: conditions},
+		}
+	}
+
+	manifests := []lifecyclev1alpha1.ReleaseManifest{
+		newManifest("release-3-0-3", "default", "3.0.3", validCondition),
+		newManifest("release-3-1-0", "default", "3.1.0", validCondition),
+		newManifest("release-3-1-1", "default", "3.1.1", validCondition),
+		newManifest("release-3-1-2", "catalog", "3.1.2", validCondition),
+		newManifest("release-3-1-3", "default", "3.1.3", invalidCondition),
+		newManifest("release-3-2-0", "default", "3.2.0", validCondition),
+	}
+
+	tests := []struct {
+		name             string
+		channel          string
+		currentVersion   string
+		expectedManifest string
+		expectedErr      string
+	}{
+		{
+			name:             "Latest patch release",
+			channel:          "3.1.x",
+			currentVersion:   "3.1.0",
+			expectedManifest: "catalog/release-3-1-2",
+		},
+		{
+			name:             "Latest minor release",
+			channel:          "3.x",
+			currentVersion:   "3.1.0",
+			expectedManifest: "default/release-3-2-0",
+		},
+		{
+			name:           "Already on the latest release",
+			channel:        "3.0.x",
+			currentVersion: "3.0.3",
+		},
+		{
+			name:           "Invalid channel",
+			channel:        "latest",
+			currentVersion: "3.0.3",
+			expectedErr:    "parsing channel version",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest, err := selectChannelRelease(test.channel, test.currentVersion, manifests)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			if test.expectedManifest == "" {
+				assert.Nil(t, manifest)
+				return
+			}
+
+			require.NotNil(t, manifest)
+			assert.Equal(t, test.expectedManifest, manifest.Namespace+"/"+manifest.Name)
+		})
+	}
+}
+
+func TestInMaintenanceWindow(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, time.October, 2, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		windows           []lifecyclev1alpha1.MaintenanceWindow
+		expectedInWindow  bool
+		expectedUntilNext time.Duration
+	}{
+		{
+			name:             "No maintenance windows",
+			expectedInWindow: true,
+		},
+		{
+			name: "Within daily window",
+			windows: []lifecyclev1alpha1.MaintenanceWindow{
+				{Start: "10:00", Duration: metav1.Duration{Duration: time.Hour}},
+			},
+			expectedInWindow: true,
+		},
+		{
+			name: "Before daily window",
+			windows: []lifecyclev1alpha1.MaintenanceWindow{
+				{Start: "22:00", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+			},
+			expectedUntilNext: 11*time.Hour + 30*time.Minute,
+		},
+		{
+			name: "Within window started on the previous day",
+			windows: []lifecyclev1alpha1.MaintenanceWindow{
+				{Days: []lifecyclev1alpha1.Weekday{"Tuesday"}, Start: "22:00", Duration: metav1.Duration{Duration: 14 * time.Hour}},
+			},
+			expectedInWindow: true,
+		},
+		{
+			name: "Weekend window",
+			windows: []lifecyclev1alpha1.MaintenanceWindow{
+				{Days: []lifecyclev1alpha1.Weekday{"Saturday", "Sunday"}, Start: "02:00", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			},
+			expectedUntilNext: 2*24*time.Hour + 15*time.Hour + 30*time.Minute,
+		},
+		{
+			name: "Window in a different time zone",
+			windows: []lifecyclev1alpha1.MaintenanceWindow{
+				{Start: "12:00", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Europe/Berlin"},
+			},
+			expectedInWindow: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inWindow, untilNext, err := inMaintenanceWindow(test.windows, now)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedInWindow, inWindow)
+			assert.Equal(t, test.expectedUntilNext, untilNext)
+		})
+	}
+}
+
+func TestIsUpgradeFinished(t *testing.T) {
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1},
+		Spec:       lifecyclev1alpha1.UpgradePlanSpec{ReleaseVersion: "3.1.0"},
+		Status: lifecyclev1alpha1.UpgradePlanStatus{
+			ObservedGeneration:           1,
+			LastSuccessfulReleaseVersion: "3.1.0",
+			Conditions: []metav1.Condition{
+				{Type: lifecyclev1alpha1.KubernetesUpgradedCondition, Reason: lifecyclev1alpha1.UpgradeSucceeded},
+			},
+		},
+	}
+	assert.True(t, isUpgradeFinished(plan))
+
+	plan.Status.Conditions = append(plan.Status.Conditions, metav1.Condition{Type: "RancherUpgraded", Reason: lifecyclev1alpha1.UpgradeInProgress})
+	assert.False(t, isUpgradeFinished(plan))
+
+	plan.Status.Conditions[1].Reason = lifecyclev1alpha1.UpgradeFailed
+	assert.False(t, isUpgradeFinished(plan))
+
+	plan.Status.Conditions[1].Reason = lifecyclev1alpha1.UpgradeStalled
+	assert.False(t, isUpgradeFinished(plan))
+
+	plan.Status.Conditions = plan.Status.Conditions[:1]
+	plan.Spec.ReleaseVersion = "3.1.1"
+	assert.False(t, isUpgradeFinished(plan))
+}
+
+func TestUpgradeChannelReconcilerRejectedBump(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, lifecyclev1alpha1.AddToScheme(scheme))
+
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "plan", Namespace: "default", Generation: 1},
+		Spec: lifecyclev1alpha1.UpgradePlanSpec{
+			ReleaseVersion: "3.1.0",
+			Channel:        &lifecyclev1alpha1.UpgradeChannel{Version: "3.1.x"},
+		},
+		Status: lifecyclev1alpha1.UpgradePlanStatus{ObservedGeneration: 1, LastSuccessfulReleaseVersion: "3.1.0"},
+	}
+	manifest := &lifecyclev1alpha1.ReleaseManifest{
+		ObjectMeta: metav1.ObjectMeta{Name: "release-3-1-1", Namespace: "default"},
+		Spec:       lifecyclev1alpha1.ReleaseManifestSpec{ReleaseVersion: "3.1.1"},
+		Status: lifecyclev1alpha1.ReleaseManifestStatus{
+			Conditions: []metav1.Condition{{Type: lifecyclev1alpha1.ValidCondition, Status: metav1.ConditionTrue}},
+		},
+	}
+
+	recorder := record.NewFakeRecorder(1)
+	r := &UpgradeChannelReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(plan, manifest).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(context.Context, client.WithWatch, client.Object, ...client.UpdateOption) error {
+					return apierrors.NewForbidden(lifecyclev1alpha1.GroupVersion.WithResource("upgradeplans").GroupResource(),
+						"plan", errors.New("upgrade is still in progress"))
+				},
+			}).Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "plan", Namespace: "default"}})
+	require.NoError(t, err)
+	assert.Equal(t, rejectedBumpRetryInterval, result.RequeueAfter)
+
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, "Warning ReleaseVersionBumpRejected Bumping release version from 3.1.0 to 3.1.1 following channel 3.1.x was rejected")
+	assert.Contains(t, event, "upgrade is still in progress")
+}
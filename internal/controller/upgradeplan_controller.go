@@ -21,12 +21,15 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"time"
 
 	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
 	"github.com/k3s-io/helm-controller/pkg/controllers/chart"
 	upgradecattlev1 "github.com/rancher/system-upgrade-controller/pkg/apis/upgrade.cattle.io/v1"
 	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/notification"
 	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	"go.opentelemetry.io/otel/trace"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -56,6 +59,33 @@ type UpgradePlanReconciler struct {
 	ServiceAccount       string
 	ReleaseManifestImage string
 	Kubectl              upgrade.ContainerImage
+	// CatalogNamespace holds release manifests shared across all namespaces.
+	// It is searched after the namespace of the upgrade plan.
+	CatalogNamespace string
+	// FailureLogs collects the logs of failed upgrade jobs. Disabled if nil.
+	FailureLogs *FailureLogCollector
+	// Notifier delivers condition transitions to NotificationTargets. Disabled if nil.
+	Notifier *notification.Sender
+	// RecordUpgrades enables the export of finished upgrade runs to UpgradeRecord resources.
+	RecordUpgrades bool
+	// Tracer records the spans of the reconciliations. Disabled if nil.
+	Tracer trace.Tracer
+	// PreflightChecks are performed before the OS upgrade of each generation starts.
+	PreflightChecks []PreflightCheck
+	// DrainAnalyzer inspects the nodes targeted by drain-enabled SUC Plans before their creation. Disabled if nil.
+	DrainAnalyzer *DrainAnalyzer
+	// RemovedAPIScanner looks up usages of API versions removed by the target Kubernetes version
+	// before the Kubernetes upgrade starts. Disabled if nil.
+	RemovedAPIScanner *RemovedAPIScanner
+	// ChartFetcher retrieves the target versions of Helm charts in order to validate them
+	// against the target Kubernetes version and the merged values before upgrading. Disabled if nil.
+	ChartFetcher ChartFetcher
+	// APIReader retrieves the Secrets and ConfigMaps referenced by the Helm values of upgrade plans,
+	// as well as the repository credentials of HelmCharts, without caching them.
+	APIReader client.Reader
+
+	// notifications queues the deliveries of the Notifier per NotificationTarget.
+	notifications notification.Queue
 }
 
 // +kubebuilder:rbac:groups=lifecycle.suse.com,resources=upgradeplans,verbs=get;list;watch;create;update;patch;delete
@@ -63,25 +93,53 @@ type UpgradePlanReconciler struct {
 // +kubebuilder:rbac:groups=lifecycle.suse.com,resources=upgradeplans/finalizers,verbs=update
 // +kubebuilder:rbac:groups=upgrade.cattle.io,resources=plans,verbs=create;list;get;watch;delete
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=watch;list
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;delete;create;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;delete;create;update;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
-// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get
 // +kubebuilder:rbac:groups=helm.cattle.io,resources=helmcharts,verbs=get;update;list;watch;create
 // +kubebuilder:rbac:groups=helm.cattle.io,resources=helmcharts/status,verbs=get
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
 // +kubebuilder:rbac:groups=lifecycle.suse.com,resources=releasemanifests,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=upgraderecords,verbs=create
+// +kubebuilder:rbac:groups=lifecycle.suse.com,resources=notificationtargets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=list
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update;patch
+// +kubebuilder:rbac:groups=apps,resources=daemonsets;replicasets;statefulsets,verbs=list
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies;ingresses;ingressclasses,verbs=list
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations;validatingwebhookconfigurations,verbs=list
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=list
+// +kubebuilder:rbac:groups=apiregistration.k8s.io,resources=apiservices,verbs=list
+// +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=list
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=list
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;clusterrolebindings;roles;rolebindings,verbs=list
+// +kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=list
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=csidrivers;csinodes;storageclasses;volumeattachments;csistoragecapacities,verbs=list
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=list
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=list
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=list
+// +kubebuilder:rbac:groups=node.k8s.io,resources=runtimeclasses,verbs=list
+// +kubebuilder:rbac:groups=flowcontrol.apiserver.k8s.io,resources=flowschemas;prioritylevelconfigurations,verbs=list
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-func (r *UpgradePlanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *UpgradePlanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	plan := &lifecyclev1alpha1.UpgradePlan{}
 
 	if err := r.Get(ctx, req.NamespacedName, plan); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	ctx, span := r.startSpan(generationContext(ctx, plan), "Reconcile",
+		upgradePlanAttribute.String(plan.Name),
+		namespaceAttribute.String(plan.Namespace),
+		generationAttribute.Int64(plan.Generation),
+		releaseVersionAttribute.String(currentReleaseVersion(plan)))
+	defer func() { endSpan(span, err) }()
+
 	logger := log.FromContext(ctx)
 	logger.Info("Reconciling UpgradePlan")
 
@@ -99,6 +157,8 @@ func (r *UpgradePlanReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return ctrl.Result{}, err
 		}
 
+		deleteUpgradeMetrics(plan)
+
 		controllerutil.RemoveFinalizer(plan, lifecyclev1alpha1.UpgradePlanFinalizer)
 		return ctrl.Result{}, r.Update(ctx, plan)
 	}
@@ -110,10 +170,32 @@ func (r *UpgradePlanReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{Requeue: true}, r.Update(ctx, plan)
 	}
 
-	result, err := r.reconcileNormal(ctx, plan)
+	previousConditions := slices.Clone(plan.Status.Conditions)
+	previousHistory := slices.Clone(plan.Status.History)
+	previousSuffix := plan.Status.SUCNameSuffix
+
+	result, err = r.reconcileNormal(ctx, plan)
+	updateUpgradeSummary(plan, metav1.Now())
+
+	if run := finishUpgradeRun(plan, metav1.Now()); run != nil && r.RecordUpgrades {
+		if recordErr := r.createUpgradeRecord(ctx, plan, run); recordErr != nil {
+			// Retry the export during the next reconciliation.
+			run.CompletionTime = nil
+			err = errors.Join(err, recordErr)
+		}
+	}
+
+	recordUpgradeMetrics(plan, previousConditions, time.Now())
 
 	// Attempt to update the plan status before returning.
-	return result, errors.Join(err, r.Status().Update(ctx, plan))
+	if statusErr := r.Status().Update(ctx, plan); statusErr != nil {
+		// Transitions are notified once they have been persisted.
+		return result, errors.Join(err, statusErr)
+	}
+
+	r.notify(ctx, plan, previousConditions)
+	r.traceFinishedRuns(ctx, plan, previousHistory, previousSuffix)
+	return result, err
 }
 
 func (r *UpgradePlanReconciler) reconcileDelete(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan) error {
@@ -157,13 +239,60 @@ func (r *UpgradePlanReconciler) reconcileDelete(ctx context.Context, upgradePlan
 }
 
 func (r *UpgradePlanReconciler) reconcileNormal(ctx context.Context, upgradePlan *lifecyclev1alpha1.UpgradePlan) (ctrl.Result, error) {
+	if upgradePlan.Status.ObservedGeneration != upgradePlan.Generation {
+		continued, err := continueUpgradeRun(upgradePlan)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if continued {
+			log.FromContext(ctx).Info("Continuing upgrade run with overridden settings")
+		}
+	}
+
+	if upgradePlan.Status.ObservedGeneration != upgradePlan.Generation {
+		if err := r.planUpgradePath(ctx, upgradePlan); err != nil {
+			var validationErr *releaseManifestValidationError
+			if errors.As(err, &validationErr) {
+				setValidationFailedCondition(upgradePlan, validationErr.reason, validationErr.message)
+				return ctrl.Result{}, nil
+			}
+
+			return ctrl.Result{}, fmt.Errorf("planning upgrade path: %w", err)
+		}
+	}
+
 	release, err := r.retrieveReleaseManifest(ctx, upgradePlan)
 	if err != nil {
+		var validationErr *releaseManifestValidationError
+		if errors.As(err, &validationErr) {
+			setValidationFailedCondition(upgradePlan, validationErr.reason, validationErr.message)
+			return ctrl.Result{}, nil
+		}
+
 		if !errors.Is(err, errReleaseManifestNotFound) {
+			releaseManifestFetchFailures.WithLabelValues(releaseManifestLookupFailure).Inc()
 			return ctrl.Result{}, fmt.Errorf("retrieving release manifest: %w", err)
 		}
 
-		return ctrl.Result{}, r.createReleaseManifest(ctx, upgradePlan)
+		if err = r.createReleaseManifest(ctx, upgradePlan); err != nil {
+			releaseManifestFetchFailures.WithLabelValues(releaseManifestJobCreationFailure).Inc()
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	valid := meta.FindStatusCondition(release.Status.Conditions, lifecyclev1alpha1.ValidCondition)
+	if valid == nil {
+		// The plan will be reconciled again once the release manifest has been validated.
+		log.FromContext(ctx).Info("Waiting for release manifest validation", "releaseManifest", release.Name)
+		return ctrl.Result{}, nil
+	} else if valid.Status == metav1.ConditionFalse {
+		msg := fmt.Sprintf("Release manifest '%s' is invalid: %s", release.Name, valid.Message)
+		setValidationFailedCondition(upgradePlan, lifecyclev1alpha1.InvalidReleaseManifestReason, msg)
+
+		return ctrl.Result{}, nil
 	}
 
 	nodeList := &corev1.NodeList{}
@@ -173,18 +302,29 @@ func (r *UpgradePlanReconciler) reconcileNormal(ctx context.Context, upgradePlan
 
 	supportedArchitectures := lifecyclev1alpha1.SupportedArchitectures(release.Spec.Components.OperatingSystem.SupportedArchs)
 	if unsupportedNodes := findUnsupportedNodes(nodeList, supportedArchitectures); len(unsupportedNodes) > 0 {
-		condition := metav1.Condition{
-			Type:    lifecyclev1alpha1.ValidationFailedCondition,
-			Status:  metav1.ConditionTrue,
-			Reason:  lifecyclev1alpha1.UnsupportedArchitectureReason,
-			Message: fmt.Sprintf("One or more cluster nodes are running on unsupported architecture: %s", unsupportedNodes),
-		}
-		meta.SetStatusCondition(&upgradePlan.Status.Conditions, condition)
+		msg := fmt.Sprintf("One or more cluster nodes are running on unsupported architecture: %s", unsupportedNodes)
+		setValidationFailedCondition(upgradePlan, lifecyclev1alpha1.UnsupportedArchitectureReason, msg)
 
 		return ctrl.Result{}, nil
 	}
 
-	if upgradePlan.Status.ObservedGeneration != upgradePlan.Generation {
+	starting := upgradePlan.Status.ObservedGeneration != upgradePlan.Generation || isIntermediateReleasePending(upgradePlan)
+
+	if starting {
+		installedRelease, err := r.installedReleaseVersion(ctx, upgradePlan)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err = release.Spec.ValidateUpgradeableFrom(installedRelease); err != nil {
+			setValidationFailedCondition(upgradePlan, lifecyclev1alpha1.UnsupportedUpgradeSourceReason, err.Error())
+			return ctrl.Result{}, nil
+		}
+	}
+
+	meta.RemoveStatusCondition(&upgradePlan.Status.Conditions, lifecyclev1alpha1.ValidationFailedCondition)
+
+	if starting {
 		suffix, err := upgrade.GenerateSuffix()
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("generating suffix: %w", err)
@@ -197,7 +337,26 @@ func (r *UpgradePlanReconciler) reconcileNormal(ctx context.Context, upgradePlan
 		}
 
 		upgradePlan.Status.SUCNameSuffix = suffix
-		upgradePlan.Status.ObservedGeneration = upgradePlan.Generation
+		upgradePlan.Status.Nodes = nil
+		upgradePlan.Status.Stages = nil
+		upgradePlan.Status.PreflightChecks = nil
+		meta.RemoveStatusCondition(&upgradePlan.Status.Conditions, lifecyclev1alpha1.PreflightChecksCondition)
+		upgradePlan.Status.DrainBlockers = nil
+		meta.RemoveStatusCondition(&upgradePlan.Status.Conditions, lifecyclev1alpha1.DrainBlockedCondition)
+		upgradePlan.Status.RemovedAPIs = nil
+		meta.RemoveStatusCondition(&upgradePlan.Status.Conditions, lifecyclev1alpha1.RemovedAPIsCondition)
+
+		// Intermediate releases are applied within the upgrade run of the current generation.
+		if upgradePlan.Status.ObservedGeneration != upgradePlan.Generation {
+			runSpecHash, err := upgradePlan.Spec.RunHash()
+			if err != nil {
+				return ctrl.Result{}, fmt.Errorf("hashing plan spec: %w", err)
+			}
+
+			upgradePlan.Status.ObservedGeneration = upgradePlan.Generation
+			upgradePlan.Status.RunSpecHash = runSpecHash
+			startUpgradeRun(upgradePlan, metav1.Now())
+		}
 
 		setPendingCondition(upgradePlan, lifecyclev1alpha1.OperatingSystemUpgradedCondition, upgradePendingMessage("OS"))
 		setPendingCondition(upgradePlan, lifecyclev1alpha1.KubernetesUpgradedCondition, upgradePendingMessage("Kubernetes"))
@@ -211,21 +370,48 @@ func (r *UpgradePlanReconciler) reconcileNormal(ctx context.Context, upgradePlan
 
 	switch {
 	case !meta.IsStatusConditionTrue(upgradePlan.Status.Conditions, lifecyclev1alpha1.OperatingSystemUpgradedCondition):
+		if r.isPreflightPending(upgradePlan) {
+			passed, err := r.reconcilePreflight(ctx, upgradePlan, nodeList)
+			if err != nil || !passed {
+				return ctrl.Result{RequeueAfter: preflightRetryInterval}, err
+			}
+		}
+
 		return r.reconcileOS(ctx, upgradePlan, release.Spec.ReleaseVersion, &release.Spec.Components.OperatingSystem, nodeList)
 	case !meta.IsStatusConditionTrue(upgradePlan.Status.Conditions, lifecyclev1alpha1.KubernetesUpgradedCondition):
-		return r.reconcileKubernetes(ctx, upgradePlan, &release.Spec.Components.Kubernetes, nodeList)
+		return r.reconcileKubernetes(ctx, upgradePlan, &release.Spec.Components.Kubernetes, release.Spec.Components.Workloads.Helm, nodeList)
 	}
 
 	for _, chart := range release.Spec.Components.Workloads.Helm {
 		if !isHelmUpgradeFinished(upgradePlan, lifecyclev1alpha1.GetChartConditionType(chart.PrettyName)) {
-			return r.reconcileHelmChart(ctx, upgradePlan, &chart)
+			k8sDistro, err := targetKubernetesDistribution(nodeList, &release.Spec.Components.Kubernetes)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			return r.reconcileHelmChart(ctx, upgradePlan, &chart, k8sDistro.Version)
+		}
+	}
+
+	if isUpgradeReportPending(upgradePlan) {
+		if err = r.generateUpgradeReport(ctx, upgradePlan, release, nodeList); err != nil {
+			return ctrl.Result{}, fmt.Errorf("generating upgrade report: %w", err)
 		}
 	}
 
+	upgradePlan.Status.LastSuccessfulReleaseVersion = release.Spec.ReleaseVersion
+
+	if next := nextIntermediateRelease(upgradePlan); next != "" {
+		r.Recorder.Eventf(upgradePlan, corev1.EventTypeNormal, lifecyclev1alpha1.IntermediateReleaseAppliedReason,
+			"Intermediate release %s applied, continuing with release %s", release.Spec.ReleaseVersion, next)
+
+		advanceUpgradePath(upgradePlan, next)
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	logger := log.FromContext(ctx)
 	logger.Info("Upgrade completed")
 
-	upgradePlan.Status.LastSuccessfulReleaseVersion = release.Spec.ReleaseVersion
 	return ctrl.Result{}, nil
 }
 
@@ -260,6 +446,19 @@ func isHelmUpgradeFinished(plan *lifecyclev1alpha1.UpgradePlan, conditionType st
 	return false
 }
 
+func isJobFinished(conditions []batchv1.JobCondition) bool {
+	return slices.ContainsFunc(conditions, func(condition batchv1.JobCondition) bool {
+		return condition.Status == corev1.ConditionTrue &&
+			(condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed)
+	})
+}
+
+func isJobFailed(conditions []batchv1.JobCondition) bool {
+	return slices.ContainsFunc(conditions, func(condition batchv1.JobCondition) bool {
+		return condition.Status == corev1.ConditionTrue && condition.Type == batchv1.JobFailed
+	})
+}
+
 func parseDrainOptions(nodeList *corev1.NodeList, plan *lifecyclev1alpha1.UpgradePlan) (drainControlPlane bool, drainWorker bool) {
 	var controlPlaneCounter, workerCounter int
 	for _, node := range nodeList.Items {
@@ -304,6 +503,11 @@ func upgradePendingMessage(component string) string {
 	return fmt.Sprintf("%s upgrade is not yet started", component)
 }
 
+func setValidationFailedCondition(plan *lifecyclev1alpha1.UpgradePlan, reason, message string) {
+	condition := metav1.Condition{Type: lifecyclev1alpha1.ValidationFailedCondition, Status: metav1.ConditionTrue, Reason: reason, Message: message}
+	meta.SetStatusCondition(&plan.Status.Conditions, condition)
+}
+
 type setCondition func(plan *lifecyclev1alpha1.UpgradePlan, conditionType string, message string)
 
 func setPendingCondition(plan *lifecyclev1alpha1.UpgradePlan, conditionType, message string) {
@@ -331,6 +535,11 @@ func setFailedCondition(plan *lifecyclev1alpha1.UpgradePlan, conditionType, mess
 	meta.SetStatusCondition(&plan.Status.Conditions, condition)
 }
 
+func setStalledCondition(plan *lifecyclev1alpha1.UpgradePlan, conditionType, message string) {
+	condition := metav1.Condition{Type: conditionType, Status: metav1.ConditionFalse, Reason: lifecyclev1alpha1.UpgradeStalled, Message: message}
+	meta.SetStatusCondition(&plan.Status.Conditions, condition)
+}
+
 func setSkippedCondition(plan *lifecyclev1alpha1.UpgradePlan, conditionType, message string) {
 	condition := metav1.Condition{Type: conditionType, Status: metav1.ConditionFalse, Reason: lifecyclev1alpha1.UpgradeSkipped, Message: message}
 	meta.SetStatusCondition(&plan.Status.Conditions, condition)
@@ -340,9 +549,26 @@ func (r *UpgradePlanReconciler) findUpgradePlanFromJob(ctx context.Context, job
 	// Check whether the Job was created by the Upgrade Controller first
 	requests := r.findUpgradePlanFromLabel(ctx, job)
 	if len(requests) != 0 {
+		// The only jobs created by the Upgrade Controller are fetching release manifests.
+		if isJobFailed(job.(*batchv1.Job).Status.Conditions) {
+			releaseManifestFetchFailures.WithLabelValues(releaseManifestJobFailure).Inc()
+		}
 		return requests
 	}
 
+	// Check whether the Job was created by SUC
+	if sucPlanName := job.GetLabels()[upgrade.SUCPlanLabel]; sucPlanName != "" {
+		sucPlan := &upgradecattlev1.Plan{}
+		if err := r.Get(ctx, types.NamespacedName{Name: sucPlanName, Namespace: upgrade.SUCNamespace}, sucPlan); err != nil {
+			logger := log.FromContext(ctx)
+			logger.Error(err, "failed to get SUC plan")
+
+			return []reconcile.Request{}
+		}
+
+		return r.findUpgradePlanFromLabel(ctx, sucPlan)
+	}
+
 	// Check whether the Job was created by the Helm Controller
 	jobLabels := job.GetLabels()
 	chartName, ok := jobLabels[chart.Label]
@@ -377,6 +603,66 @@ func (r *UpgradePlanReconciler) findUpgradePlanFromLabel(_ context.Context, obje
 	}
 }
 
+func (r *UpgradePlanReconciler) findUpgradePlansFromReleaseManifest(ctx context.Context, object client.Object) []reconcile.Request {
+	manifest, ok := object.(*lifecyclev1alpha1.ReleaseManifest)
+	if !ok {
+		return []reconcile.Request{}
+	}
+
+	plans := &lifecyclev1alpha1.UpgradePlanList{}
+	if err := r.List(ctx, plans); err != nil {
+		logger := log.FromContext(ctx)
+		logger.Error(err, "failed to list upgrade plans")
+
+		return []reconcile.Request{}
+	}
+
+	var requests []reconcile.Request
+	for _, plan := range plans.Items {
+		if referencesReleaseManifest(&plan, manifest, r.CatalogNamespace) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: plan.Namespace, Name: plan.Name},
+			})
+		}
+	}
+
+	return requests
+}
+
+func (r *UpgradePlanReconciler) findUpgradePlansFromNode(ctx context.Context, _ client.Object) []reconcile.Request {
+	plans := &lifecyclev1alpha1.UpgradePlanList{}
+	if err := r.List(ctx, plans); err != nil {
+		logger := log.FromContext(ctx)
+		logger.Error(err, "failed to list upgrade plans")
+
+		return []reconcile.Request{}
+	}
+
+	var requests []reconcile.Request
+	for _, plan := range plans.Items {
+		if isNodeUpgradeInProgress(&plan) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: plan.Namespace, Name: plan.Name},
+			})
+		}
+	}
+
+	return requests
+}
+
+// isNodeUpgradeInProgress reports whether either the OS or the Kubernetes upgrade of the given plan is ongoing.
+// Stalled upgrades are included so that progressing nodes can resume them.
+func isNodeUpgradeInProgress(plan *lifecyclev1alpha1.UpgradePlan) bool {
+	for _, conditionType := range []string{lifecyclev1alpha1.OperatingSystemUpgradedCondition, lifecyclev1alpha1.KubernetesUpgradedCondition} {
+		condition := meta.FindStatusCondition(plan.Status.Conditions, conditionType)
+		if condition != nil && (condition.Reason == lifecyclev1alpha1.UpgradeInProgress || condition.Reason == lifecyclev1alpha1.UpgradeStalled) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *UpgradePlanReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	definitionsGetter := clientset.NewForConfigOrDie(mgr.GetConfig()).ApiextensionsV1().CustomResourceDefinitions()
@@ -391,8 +677,15 @@ func (r *UpgradePlanReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return fmt.Errorf("verifying System Upgrade Controller installation: %w", err)
 	}
 
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &lifecyclev1alpha1.ReleaseManifest{}, releaseVersionIndexKey, indexReleaseVersion); err != nil {
+		return fmt.Errorf("indexing release manifests: %w", err)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&lifecyclev1alpha1.UpgradePlan{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Annotation changes may override the validation of the plan.
+		For(&lifecyclev1alpha1.UpgradePlan{}, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}),
+		)).
 		Watches(&upgradecattlev1.Plan{}, handler.EnqueueRequestsFromMapFunc(r.findUpgradePlanFromLabel), builder.WithPredicates(predicate.Funcs{
 			CreateFunc: func(e event.CreateEvent) bool {
 				return false
@@ -400,9 +693,8 @@ func (r *UpgradePlanReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			UpdateFunc: func(e event.UpdateEvent) bool {
 				// Upgrade plans are being constantly updated on every node change.
 				// Ensure that the reconciliation only covers the scenarios
-				// where the plans are no longer actively being applied to a node.
-				return len(e.ObjectNew.(*upgradecattlev1.Plan).Status.Applying) == 0 &&
-					len(e.ObjectOld.(*upgradecattlev1.Plan).Status.Applying) != 0
+				// where the progress of the plans changes.
+				return isSUCPlanProgressChanged(e.ObjectOld.(*upgradecattlev1.Plan), e.ObjectNew.(*upgradecattlev1.Plan))
 			},
 			DeleteFunc: func(e event.DeleteEvent) bool {
 				return false
@@ -414,13 +706,6 @@ func (r *UpgradePlanReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			},
 			UpdateFunc: func(e event.UpdateEvent) bool {
 				// Only requeue an upgrade plan when a respective job finishes.
-				isJobFinished := func(conditions []batchv1.JobCondition) bool {
-					return slices.ContainsFunc(conditions, func(condition batchv1.JobCondition) bool {
-						return condition.Status == corev1.ConditionTrue &&
-							(condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed)
-					})
-				}
-
 				return isJobFinished(e.ObjectNew.(*batchv1.Job).Status.Conditions) &&
 					!isJobFinished(e.ObjectOld.(*batchv1.Job).Status.Conditions)
 			},
@@ -431,6 +716,30 @@ func (r *UpgradePlanReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				return false
 			},
 		})).
+		Watches(&lifecyclev1alpha1.ReleaseManifest{}, handler.EnqueueRequestsFromMapFunc(r.findUpgradePlansFromReleaseManifest), builder.WithPredicates(predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				// Only requeue the referencing upgrade plans when the validation result changes.
+				oldValid := meta.FindStatusCondition(e.ObjectOld.(*lifecyclev1alpha1.ReleaseManifest).Status.Conditions, lifecyclev1alpha1.ValidCondition)
+				newValid := meta.FindStatusCondition(e.ObjectNew.(*lifecyclev1alpha1.ReleaseManifest).Status.Conditions, lifecyclev1alpha1.ValidCondition)
+
+				return newValid != nil && (oldValid == nil || oldValid.Status != newValid.Status)
+			},
+		})).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.findUpgradePlansFromNode), builder.WithPredicates(predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return false
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				// Only requeue the upgrade plans when the node state relevant for tracking the upgrade changes.
+				return isNodeUpgradeStateChanged(e.ObjectOld.(*corev1.Node), e.ObjectNew.(*corev1.Node))
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		})).
 		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findUpgradePlanFromLabel), builder.WithPredicates(predicate.Funcs{
 			DeleteFunc: func(e event.DeleteEvent) bool {
 				return false
@@ -0,0 +1,344 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// maxValuesDiffs limits the number of values diffs kept in the values diff ConfigMap.
+	maxValuesDiffs = 20
+
+	// maxValuesDiffSize limits the size of a single values diff. Larger diffs only list the changed paths.
+	maxValuesDiffSize = 32 * 1024
+
+	redactedValue = "<redacted>"
+
+	valuesAdded   = "add"
+	valuesRemoved = "remove"
+	valuesChanged = "change"
+)
+
+// secretKeyRegex matches the keys of values which are likely to hold sensitive data.
+var secretKeyRegex = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|private[-_]?key|api[-_]?key|access[-_]?key|license[-_]?key)`)
+
+// valuesChange describes a single difference between the previous and the new values of a Helm chart.
+type valuesChange struct {
+	Path      string `json:"path"`
+	Operation string `json:"op"`
+	Old       any    `json:"old,omitempty"`
+	New       any    `json:"new,omitempty"`
+}
+
+// recordValuesDiff stores the difference between the previous and the new values of the given chart
+// in the values diff ConfigMap of the plan. Recording is best effort and errors are only logged
+// in order to not interfere with the upgrade.
+func (r *UpgradePlanReconciler) recordValuesDiff(
+	ctx context.Context,
+	plan *lifecyclev1alpha1.UpgradePlan,
+	releaseChart *lifecyclev1alpha1.HelmChart,
+	previousValues any,
+	values []byte,
+) {
+	logger := log.FromContext(ctx).WithValues("chart", releaseChart.ReleaseName)
+
+	changes, err := diffHelmValues(previousValues, values)
+	if err != nil {
+		logger.Error(err, "failed to compute values diff")
+		return
+	}
+
+	diff, err := encodeValuesDiff(changes)
+	if err != nil {
+		logger.Error(err, "failed to encode values diff")
+		return
+	}
+
+	releaseVersion := currentReleaseVersion(plan)
+	key := valuesDiffKey(releaseVersion, releaseChart.ReleaseName)
+
+	entries := slices.DeleteFunc(slices.Clone(plan.Status.ValuesDiffs), func(entry lifecyclev1alpha1.HelmValuesDiff) bool {
+		return entry.Key == key
+	})
+
+	var evicted []string
+	for len(entries) >= maxValuesDiffs {
+		evicted = append(evicted, entries[0].Key)
+		entries = entries[1:]
+	}
+
+	configMapName := valuesDiffConfigMapName(plan)
+	if err = r.storeValuesDiff(ctx, plan, configMapName, key, diff, evicted); err != nil {
+		logger.Error(err, "failed to store values diff")
+		return
+	}
+
+	entry := lifecyclev1alpha1.HelmValuesDiff{
+		Key:            key,
+		Chart:          releaseChart.ReleaseName,
+		ReleaseVersion: releaseVersion,
+		RecordTime:     metav1.Now(),
+	}
+	for _, change := range changes {
+		switch change.Operation {
+		case valuesAdded:
+			entry.Added++
+		case valuesRemoved:
+			entry.Removed++
+		case valuesChanged:
+			entry.Changed++
+		}
+	}
+
+	plan.Status.ValuesDiffConfigMap = configMapName
+	plan.Status.ValuesDiffs = append(entries, entry)
+}
+
+// storeValuesDiff writes the diff under the given key of the values diff ConfigMap and removes the evicted keys.
+// Existing ConfigMaps are patched in order to avoid caching ConfigMaps cluster-wide.
+func (r *UpgradePlanReconciler) storeValuesDiff(ctx context.Context, plan *lifecyclev1alpha1.UpgradePlan, name, key, diff string, evicted []string) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: plan.Namespace,
+			Labels:    upgrade.PlanIdentifierLabels(plan.Name, plan.Namespace),
+		},
+		Data: map[string]string{key: diff},
+	}
+
+	if err := controllerutil.SetControllerReference(plan, configMap, r.Scheme); err != nil {
+		return fmt.Errorf("setting controller reference: %w", err)
+	}
+
+	err := r.Create(ctx, configMap)
+	if err == nil {
+		return nil
+	} else if !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("creating values diff config map: %w", err)
+	}
+
+	data := map[string]any{key: diff}
+	for _, k := range evicted {
+		data[k] = nil
+	}
+
+	patch, err := json.Marshal(map[string]any{"data": data})
+	if err != nil {
+		return fmt.Errorf("marshaling patch: %w", err)
+	}
+
+	if err = r.Patch(ctx, configMap, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return fmt.Errorf("patching values diff config map: %w", err)
+	}
+
+	return nil
+}
+
+// valuesDiffMessage links the values diff recorded for the given chart from the message of its condition.
+func valuesDiffMessage(plan *lifecyclev1alpha1.UpgradePlan, releaseName, message string) string {
+	key := valuesDiffKey(currentReleaseVersion(plan), releaseName)
+
+	if !slices.ContainsFunc(plan.Status.ValuesDiffs, func(entry lifecyclev1alpha1.HelmValuesDiff) bool {
+		return entry.Key == key
+	}) {
+		return message
+	}
+
+	return fmt.Sprintf("%s. Values diff stored in ConfigMap %s under key %s", message, plan.Status.ValuesDiffConfigMap, key)
+}
+
+func valuesDiffConfigMapName(plan *lifecyclev1alpha1.UpgradePlan) string {
+	return fmt.Sprintf("%s-values-diff", plan.Name)
+}
+
+func valuesDiffKey(releaseVersion, releaseName string) string {
+	return fmt.Sprintf("%s.%s", releaseVersion, releaseName)
+}
+
+// diffHelmValues compares the installed values of a chart with the merged values it is upgraded with.
+// Values whose keys look like they hold sensitive data are redacted.
+func diffHelmValues(previousValues any, values []byte) ([]valuesChange, error) {
+	previous, err := parseInstalledValues(previousValues)
+	if err != nil {
+		return nil, err
+	}
+
+	// Normalize the previous values the same way as the merged ones, e.g. numbers decoded from JSON.
+	previous, err = normalizeValues(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	current := map[string]any{}
+	if err = yaml.Unmarshal(values, &current); err != nil {
+		return nil, fmt.Errorf("unmarshaling chart values: %w", err)
+	}
+
+	var changes []valuesChange
+	diffValues(nil, previous, current, false, &changes)
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes, nil
+}
+
+func normalizeValues(values map[string]any) (map[string]any, error) {
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling chart values: %w", err)
+	}
+
+	normalized := map[string]any{}
+	if err = yaml.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("unmarshaling chart values: %w", err)
+	}
+
+	return normalized, nil
+}
+
+func diffValues(path []string, previous, current any, redact bool, changes *[]valuesChange) {
+	previousMap, previousIsMap := previous.(map[string]any)
+	currentMap, currentIsMap := current.(map[string]any)
+
+	// Added and removed maps are reported per value.
+	if previous == nil && len(currentMap) != 0 {
+		previousIsMap = true
+	} else if current == nil && len(previousMap) != 0 {
+		currentIsMap = true
+	}
+
+	if previousIsMap && currentIsMap {
+		for key, value := range previousMap {
+			diffValues(append(slices.Clone(path), key), value, currentMap[key], redact || secretKeyRegex.MatchString(key), changes)
+		}
+
+		for key, value := range currentMap {
+			if _, ok := previousMap[key]; !ok {
+				diffValues(append(slices.Clone(path), key), nil, value, redact || secretKeyRegex.MatchString(key), changes)
+			}
+		}
+
+		return
+	}
+
+	if reflect.DeepEqual(previous, current) {
+		return
+	}
+
+	change := valuesChange{
+		Path: valuesPath(path),
+		Old:  redactValue(previous, redact),
+		New:  redactValue(current, redact),
+	}
+
+	switch {
+	case previous == nil:
+		change.Operation = valuesAdded
+	case current == nil:
+		change.Operation = valuesRemoved
+	default:
+		change.Operation = valuesChanged
+	}
+
+	*changes = append(*changes, change)
+}
+
+// redactValue replaces the given value if redacted, as well as any nested values whose keys look sensitive.
+func redactValue(value any, redact bool) any {
+	if value == nil {
+		return nil
+	}
+
+	if redact {
+		return redactedValue
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, nested := range v {
+			redacted[key] = redactValue(nested, secretKeyRegex.MatchString(key))
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, 0, len(v))
+		for _, nested := range v {
+			redacted = append(redacted, redactValue(nested, false))
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// valuesPath joins the given keys with dots, quoting keys which contain dots themselves.
+func valuesPath(keys []string) string {
+	var b strings.Builder
+
+	for i, key := range keys {
+		if strings.Contains(key, ".") {
+			fmt.Fprintf(&b, "[%q]", key)
+			continue
+		}
+
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(key)
+	}
+
+	return b.String()
+}
+
+// encodeValuesDiff marshals the given changes. The values are omitted from diffs exceeding the size limit
+// and the changes are truncated if the paths alone still exceed it.
+func encodeValuesDiff(changes []valuesChange) (string, error) {
+	if changes == nil {
+		changes = []valuesChange{}
+	}
+
+	diff, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if len(diff) <= maxValuesDiffSize {
+		return string(diff), nil
+	}
+
+	paths := make([]valuesChange, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, valuesChange{Path: change.Path, Operation: change.Operation})
+	}
+
+	for {
+		if diff, err = json.MarshalIndent(paths, "", "  "); err != nil {
+			return "", err
+		}
+
+		if len(diff) <= maxValuesDiffSize || len(paths) == 0 {
+			return string(diff), nil
+		}
+
+		paths = paths[:len(paths)/2]
+	}
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDiffHelmValues(t *testing.T) {
+	// Numbers of Helm release configs are decoded from JSON.
+	previous := map[string]any{
+		"replicas": float64(1),
+		"image":    map[string]any{"tag": "v1.0.0", "pullPolicy": "IfNotPresent"},
+		"auth":     map[string]any{"username": "admin", "password": "old-password"},
+		"ingress":  map[string]any{"hosts": []any{"a.example.com"}},
+		"legacy":   true,
+	}
+
+	values := []byte(`
+replicas: 1
+image:
+  tag: v1.1.0
+  pullPolicy: IfNotPresent
+auth:
+  username: admin
+  password: new-password
+ingress:
+  hosts: [a.example.com, b.example.com]
+license:
+  licenseKey: abc
+  edition: enterprise
+"global.io":
+  enabled: true
+`)
+
+	changes, err := diffHelmValues(previous, values)
+	require.NoError(t, err)
+
+	assert.Equal(t, []valuesChange{
+		{Path: `["global.io"].enabled`, Operation: valuesAdded, New: true},
+		{Path: "auth.password", Operation: valuesChanged, Old: redactedValue, New: redactedValue},
+		{Path: "image.tag", Operation: valuesChanged, Old: "v1.0.0", New: "v1.1.0"},
+		{Path: "ingress.hosts", Operation: valuesChanged, Old: []any{"a.example.com"}, New: []any{"a.example.com", "b.example.com"}},
+		{Path: "legacy", Operation: valuesRemoved, Old: true},
+		{Path: "license.edition", Operation: valuesAdded, New: "enterprise"},
+		{Path: "license.licenseKey", Operation: valuesAdded, New: redactedValue},
+	}, changes)
+
+	// Nested sensitive values of added maps are redacted as well.
+	changes, err = diffHelmValues("", []byte("database:\n  host: db\n  credentials:\n    user: admin\n"))
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, valuesChange{Path: "database.credentials.user", Operation: valuesAdded, New: redactedValue}, changes[0])
+
+	changes, err = diffHelmValues("replicas: 2\n", []byte("replicas: 2\n"))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestEncodeValuesDiff(t *testing.T) {
+	var changes []valuesChange
+	for i := range 200 {
+		changes = append(changes, valuesChange{
+			Path:      fmt.Sprintf("config.entry%d", i),
+			Operation: valuesAdded,
+			New:       fmt.Sprintf("%0200d", i),
+		})
+	}
+
+	diff, err := encodeValuesDiff(changes)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(diff), maxValuesDiffSize)
+
+	var decoded []valuesChange
+	require.NoError(t, json.Unmarshal([]byte(diff), &decoded))
+	require.Len(t, decoded, 200)
+	assert.Equal(t, valuesChange{Path: "config.entry0", Operation: valuesAdded}, decoded[0])
+
+	diff, err = encodeValuesDiff(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", diff)
+}
+
+func TestRecordValuesDiff(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, lifecyclev1alpha1.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &UpgradePlanReconciler{Client: c, Scheme: scheme}
+
+	plan := newPlanWithConditions(nil)
+	plan.Name = "upgrade-plan"
+	plan.Namespace = "upgrade-controller-system"
+
+	rancher := &lifecyclev1alpha1.HelmChart{ReleaseName: "rancher"}
+	r.recordValuesDiff(context.Background(), plan, rancher, "replicas: 1\n", []byte("replicas: 3\n"))
+
+	require.Len(t, plan.Status.ValuesDiffs, 1)
+	entry := plan.Status.ValuesDiffs[0]
+	assert.Equal(t, "3.1.0.rancher", entry.Key)
+	assert.Equal(t, "rancher", entry.Chart)
+	assert.Equal(t, "3.1.0", entry.ReleaseVersion)
+	assert.Equal(t, 1, entry.Changed)
+	assert.Equal(t, "upgrade-plan-values-diff", plan.Status.ValuesDiffConfigMap)
+
+	assert.Equal(t, "Chart rancher upgrade is in progress. Values diff stored in ConfigMap upgrade-plan-values-diff under key 3.1.0.rancher",
+		valuesDiffMessage(plan, "rancher", "Chart rancher upgrade is in progress"))
+	assert.Equal(t, "Chart metal3 upgrade is in progress", valuesDiffMessage(plan, "metal3", "Chart metal3 upgrade is in progress"))
+
+	// The oldest diffs are evicted from the existing ConfigMap.
+	for i := 1; i < maxValuesDiffs; i++ {
+		plan.Status.ValuesDiffs = append(plan.Status.ValuesDiffs, lifecyclev1alpha1.HelmValuesDiff{Key: fmt.Sprintf("3.0.%d.rancher", i)})
+	}
+
+	r.recordValuesDiff(context.Background(), plan, &lifecyclev1alpha1.HelmChart{ReleaseName: "metal3"}, map[string]any{}, []byte("enabled: true\n"))
+	require.Len(t, plan.Status.ValuesDiffs, maxValuesDiffs)
+	assert.Equal(t, "3.0.1.rancher", plan.Status.ValuesDiffs[0].Key)
+	assert.Equal(t, "3.1.0.metal3", plan.Status.ValuesDiffs[maxValuesDiffs-1].Key)
+	assert.Equal(t, 1, plan.Status.ValuesDiffs[maxValuesDiffs-1].Added)
+
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "upgrade-plan-values-diff", Namespace: plan.Namespace}, configMap))
+	assert.NotContains(t, configMap.Data, "3.1.0.rancher")
+	assert.JSONEq(t, `[{"path": "enabled", "op": "add", "new": true}]`, configMap.Data["3.1.0.metal3"])
+	require.Len(t, configMap.OwnerReferences, 1)
+	assert.Equal(t, "upgrade-plan", configMap.OwnerReferences[0].Name)
+}
@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// valuesSecretKey is the key of the values Secret of a HelmChart holding the values resolved from Secrets.
+const valuesSecretKey = "values.yaml"
+
+// resolveHelmValues returns the values sources of the given chart in merge order, including the values of referenced
+// ConfigMaps, as well as the values of referenced Secrets which are kept out of the values content of the HelmChart.
+func (r *UpgradePlanReconciler) resolveHelmValues(
+	ctx context.Context,
+	upgradePlan *lifecyclev1alpha1.UpgradePlan,
+	releaseChart *lifecyclev1alpha1.HelmChart,
+) ([]valuesSource, map[string]any, error) {
+	sources := []valuesSource{{
+		name:     "release",
+		values:   releaseChart.Values,
+		strategy: releaseChart.MergeStrategy,
+		mergeKey: releaseChart.MergeKey,
+	}}
+
+	var secretValues map[string]any
+
+	for _, h := range upgradePlan.Spec.Helm {
+		if releaseChart.Name != h.Chart {
+			continue
+		}
+
+		for _, ref := range h.ValuesFrom {
+			values, err := r.referencedValues(ctx, upgradePlan.Namespace, ref)
+			if err != nil {
+				return nil, nil, err
+			} else if values == nil {
+				continue
+			}
+
+			if ref.Kind == lifecyclev1alpha1.SecretValuesReference {
+				secretValues = mergeMaps(secretValues, values)
+				continue
+			}
+
+			raw, err := json.Marshal(values)
+			if err != nil {
+				return nil, nil, fmt.Errorf("marshaling values of %s %s: %w", ref.Kind, ref.Name, err)
+			}
+
+			sources = append(sources, valuesSource{
+				name:   fmt.Sprintf("%s %s", ref.Kind, ref.Name),
+				values: &apiextensionsv1.JSON{Raw: raw},
+			})
+		}
+
+		sources = append(sources, valuesSource{
+			name:     "user",
+			values:   h.Values,
+			strategy: h.MergeStrategy,
+			mergeKey: h.MergeKey,
+		})
+		break
+	}
+
+	return sources, secretValues, nil
+}
+
+// referencedValues retrieves the values stored under the selected key of the referenced Secret or ConfigMap.
+// Missing optional references resolve to nil values.
+func (r *UpgradePlanReconciler) referencedValues(ctx context.Context, namespace string, ref lifecyclev1alpha1.HelmValuesReference) (map[string]any, error) {
+	key := ref.Key
+	if key == "" {
+		key = lifecyclev1alpha1.DefaultValuesReferenceKey
+	}
+
+	namespacedName := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+
+	var data []byte
+	var found bool
+	var err error
+
+	switch ref.Kind {
+	case lifecyclev1alpha1.SecretValuesReference:
+		secret := &corev1.Secret{}
+		if err = r.APIReader.Get(ctx, namespacedName, secret); err == nil {
+			data, found = secret.Data[key]
+		}
+	case lifecyclev1alpha1.ConfigMapValuesReference:
+		configMap := &corev1.ConfigMap{}
+		if err = r.APIReader.Get(ctx, namespacedName, configMap); err == nil {
+			var value string
+			if value, found = configMap.Data[key]; found {
+				data = []byte(value)
+			} else {
+				data, found = configMap.BinaryData[key]
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported values reference kind '%s'", ref.Kind)
+	}
+
+	if err != nil {
+		if apierrors.IsNotFound(err) && ref.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("retrieving %s %s: %w", ref.Kind, ref.Name, err)
+	}
+
+	if !found {
+		if ref.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s %s does not contain key %s", ref.Kind, ref.Name, key)
+	}
+
+	values := map[string]any{}
+	if err = yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("unmarshaling values of %s %s: %w", ref.Kind, ref.Name, err)
+	}
+
+	return values, nil
+}
+
+// withSecretValues merges the values resolved from Secrets into the given chart values
+// the same way as the values Secret is applied on top of the values content of the HelmChart.
+func withSecretValues(values []byte, secretValues map[string]any) ([]byte, error) {
+	if len(secretValues) == 0 {
+		return values, nil
+	}
+
+	v := map[string]any{}
+	if err := yaml.Unmarshal(values, &v); err != nil {
+		return nil, fmt.Errorf("unmarshaling chart values: %w", err)
+	}
+
+	merged, err := yaml.Marshal(mergeMaps(v, secretValues))
+	if err != nil {
+		return nil, fmt.Errorf("marshaling chart values: %w", err)
+	}
+
+	return merged, nil
+}
+
+func valuesSecretName(chartName string) string {
+	return fmt.Sprintf("%s-upgrade-values", chartName)
+}
+
+// storeValuesSecret writes the values resolved from Secrets to the values Secret of the given HelmChart
+// and references it from the chart. The values Secret is removed if there are no such values.
+func (r *UpgradePlanReconciler) storeValuesSecret(
+	ctx context.Context,
+	upgradePlan *lifecyclev1alpha1.UpgradePlan,
+	chart *helmcattlev1.HelmChart,
+	secretValues map[string]any,
+) error {
+	name := valuesSecretName(chart.Name)
+	isValuesSecret := func(spec helmcattlev1.SecretSpec) bool {
+		return spec.Name == name
+	}
+
+	referenced := slices.ContainsFunc(chart.Spec.ValuesSecrets, isValuesSecret)
+	chart.Spec.ValuesSecrets = slices.DeleteFunc(chart.Spec.ValuesSecrets, isValuesSecret)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: chart.Namespace,
+		},
+	}
+
+	if len(secretValues) == 0 {
+		if !referenced {
+			return nil
+		}
+
+		if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("deleting values secret: %w", err)
+		}
+
+		return nil
+	}
+
+	values, err := yaml.Marshal(secretValues)
+	if err != nil {
+		return fmt.Errorf("marshaling secret values: %w", err)
+	}
+
+	secret.Labels = upgrade.PlanIdentifierLabels(upgradePlan.Name, upgradePlan.Namespace)
+	secret.Type = corev1.SecretTypeOpaque
+	secret.Data = map[string][]byte{valuesSecretKey: values}
+
+	if err = r.Create(ctx, secret); apierrors.IsAlreadyExists(err) {
+		err = r.Update(ctx, secret)
+	}
+
+	if err != nil {
+		return fmt.Errorf("storing values secret: %w", err)
+	}
+
+	chart.Spec.ValuesSecrets = append(chart.Spec.ValuesSecrets, helmcattlev1.SecretSpec{
+		Name: name,
+		Keys: []string{valuesSecretKey},
+	})
+
+	return nil
+}
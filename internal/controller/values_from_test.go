@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	helmcattlev1 "github.com/k3s-io/helm-controller/pkg/apis/helm.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"github.com/suse-edge/upgrade-controller/internal/upgrade"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolveHelmValues(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "rancher-license", Namespace: "default"},
+			Data: map[string][]byte{
+				"values.yaml": []byte("license:\n  key: abc\n"),
+				"admin.yaml":  []byte("bootstrapPassword: secret\nlicense:\n  edition: enterprise\n"),
+			},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "rancher-settings", Namespace: "default"},
+			Data:       map[string]string{"settings.yaml": "replicas: 3\n"},
+		},
+	).Build()
+	r := &UpgradePlanReconciler{Client: c, APIReader: c}
+
+	releaseChart := &lifecyclev1alpha1.HelmChart{
+		Name:          "rancher",
+		Values:        &apiextensionsv1.JSON{Raw: []byte(`{"replicas": 1}`)},
+		MergeStrategy: lifecyclev1alpha1.ValuesMergeStrategyDeepMerge,
+	}
+
+	plan := &lifecyclev1alpha1.UpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgrade-plan", Namespace: "default"},
+		Spec: lifecyclev1alpha1.UpgradePlanSpec{Helm: []lifecyclev1alpha1.HelmValues{
+			{Chart: "metal3", Values: &apiextensionsv1.JSON{Raw: []byte(`{}`)}},
+			{
+				Chart:         "rancher",
+				Values:        &apiextensionsv1.JSON{Raw: []byte(`{"env": []}`)},
+				MergeStrategy: lifecyclev1alpha1.ValuesMergeStrategyStrategicMerge,
+				MergeKey:      "key",
+				ValuesFrom: []lifecyclev1alpha1.HelmValuesReference{
+					{Kind: lifecyclev1alpha1.SecretValuesReference, Name: "rancher-license"},
+					{Kind: lifecyclev1alpha1.ConfigMapValuesReference, Name: "rancher-settings", Key: "settings.yaml"},
+					{Kind: lifecyclev1alpha1.SecretValuesReference, Name: "rancher-license", Key: "admin.yaml"},
+					{Kind: lifecyclev1alpha1.ConfigMapValuesReference, Name: "missing", Optional: true},
+					{Kind: lifecyclev1alpha1.SecretValuesReference, Name: "rancher-license", Key: "missing.yaml", Optional: true},
+				},
+			},
+		}},
+	}
+
+	sources, secretValues, err := r.resolveHelmValues(context.Background(), plan, releaseChart)
+	require.NoError(t, err)
+
+	require.Len(t, sources, 3)
+	assert.Equal(t, valuesSource{name: "release", values: releaseChart.Values, strategy: lifecyclev1alpha1.ValuesMergeStrategyDeepMerge}, sources[0])
+	assert.Equal(t, "ConfigMap rancher-settings", sources[1].name)
+	assert.JSONEq(t, `{"replicas": 3}`, string(sources[1].values.Raw))
+	assert.Equal(t, valuesSource{
+		name:     "user",
+		values:   plan.Spec.Helm[1].Values,
+		strategy: lifecyclev1alpha1.ValuesMergeStrategyStrategicMerge,
+		mergeKey: "key",
+	}, sources[2])
+
+	assert.Equal(t, map[string]any{
+		"bootstrapPassword": "secret",
+		"license":           map[string]any{"key": "abc", "edition": "enterprise"},
+	}, secretValues)
+
+	// Secret values are not part of the values content, but are validated with it.
+	values, err := mergeHelmValues("replicas: 2\n", sources...)
+	require.NoError(t, err)
+	assert.NotContains(t, string(values), "bootstrapPassword")
+
+	validationValues, err := withSecretValues(values, secretValues)
+	require.NoError(t, err)
+	assert.Contains(t, string(validationValues), "bootstrapPassword: secret")
+
+	// Secret values take precedence over inline values for the same key and do not follow their merge strategy.
+	plan.Spec.Helm[1].Values = &apiextensionsv1.JSON{Raw: []byte(`{"bootstrapPassword": "inline", "env": [{"key": "A", "value": "1"}], "license": {"edition": null}}`)}
+	plan.Spec.Helm[1].ValuesFrom = []lifecyclev1alpha1.HelmValuesReference{{Kind: lifecyclev1alpha1.SecretValuesReference, Name: "rancher-license", Key: "admin.yaml"}}
+
+	sources, secretValues, err = r.resolveHelmValues(context.Background(), plan, releaseChart)
+	require.NoError(t, err)
+
+	values, err = mergeHelmValues("license:\n  edition: community\nenv: [{key: A, value: '0'}, {key: B, value: '0'}]\n", sources...)
+	require.NoError(t, err)
+	assert.Contains(t, string(values), "bootstrapPassword: inline")
+	assert.NotContains(t, string(values), "edition")
+
+	validationValues, err = withSecretValues(values, secretValues)
+	require.NoError(t, err)
+
+	merged := map[string]any{}
+	require.NoError(t, yaml.Unmarshal(validationValues, &merged))
+	assert.Equal(t, "secret", merged["bootstrapPassword"])
+	assert.Equal(t, map[string]any{"edition": "enterprise"}, merged["license"])
+	assert.Equal(t, []any{map[string]any{"key": "A", "value": "1"}, map[string]any{"key": "B", "value": "0"}}, merged["env"])
+
+	plan.Spec.Helm[1].ValuesFrom = []lifecyclev1alpha1.HelmValuesReference{{Kind: lifecyclev1alpha1.SecretValuesReference, Name: "missing"}}
+	_, _, err = r.resolveHelmValues(context.Background(), plan, releaseChart)
+	assert.ErrorContains(t, err, "retrieving Secret missing")
+
+	plan.Spec.Helm[1].ValuesFrom = []lifecyclev1alpha1.HelmValuesReference{{Kind: lifecyclev1alpha1.ConfigMapValuesReference, Name: "rancher-settings"}}
+	_, _, err = r.resolveHelmValues(context.Background(), plan, releaseChart)
+	assert.EqualError(t, err, "ConfigMap rancher-settings does not contain key values.yaml")
+}
+
+func TestStoreValuesSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &UpgradePlanReconciler{Client: c}
+
+	plan := &lifecyclev1alpha1.UpgradePlan{ObjectMeta: metav1.ObjectMeta{Name: "upgrade-plan", Namespace: "default"}}
+	chart := &helmcattlev1.HelmChart{
+		ObjectMeta: metav1.ObjectMeta{Name: "rancher", Namespace: upgrade.KubeSystemNamespace},
+		Spec: helmcattlev1.HelmChartSpec{
+			ValuesSecrets: []helmcattlev1.SecretSpec{{Name: "custom-values"}},
+		},
+	}
+	secretKey := types.NamespacedName{Name: "rancher-upgrade-values", Namespace: upgrade.KubeSystemNamespace}
+
+	require.NoError(t, r.storeValuesSecret(context.Background(), plan, chart, map[string]any{"password": "old"}))
+	require.NoError(t, r.storeValuesSecret(context.Background(), plan, chart, map[string]any{"password": "new"}))
+
+	assert.Equal(t, []helmcattlev1.SecretSpec{
+		{Name: "custom-values"},
+		{Name: "rancher-upgrade-values", Keys: []string{"values.yaml"}},
+	}, chart.Spec.ValuesSecrets)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), secretKey, secret))
+	assert.Equal(t, "password: new\n", string(secret.Data["values.yaml"]))
+	assert.Equal(t, upgrade.PlanIdentifierLabels(plan.Name, plan.Namespace), secret.Labels)
+
+	// The values Secret is removed once the chart no longer receives secret values.
+	require.NoError(t, r.storeValuesSecret(context.Background(), plan, chart, nil))
+	assert.Equal(t, []helmcattlev1.SecretSpec{{Name: "custom-values"}}, chart.Spec.ValuesSecrets)
+
+	err := c.Get(context.Background(), secretKey, secret)
+	assert.True(t, apierrors.IsNotFound(err))
+}
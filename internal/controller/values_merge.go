@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// listItemDeleteDirective removes the list item with the same merge key when using the StrategicMerge strategy.
+const listItemDeleteDirective = "$patch"
+
+// valuesSource holds additional values combined with the installed values of a chart.
+type valuesSource struct {
+	name     string
+	values   *apiextensionsv1.JSON
+	strategy lifecyclev1alpha1.ValuesMergeStrategy
+	mergeKey string
+}
+
+// mergeValues combines the given values using the given strategy.
+// JSON patches are not merged, but applied separately via applyValuesPatch.
+func mergeValues(values, additional map[string]any, strategy lifecyclev1alpha1.ValuesMergeStrategy, mergeKey string) map[string]any {
+	switch strategy {
+	case lifecyclev1alpha1.ValuesMergeStrategyDeepMerge:
+		return deepMergeMaps(values, additional, "")
+	case lifecyclev1alpha1.ValuesMergeStrategyStrategicMerge:
+		if mergeKey == "" {
+			mergeKey = lifecyclev1alpha1.DefaultValuesMergeKey
+		}
+		return deepMergeMaps(values, additional, mergeKey)
+	default:
+		return mergeMaps(values, additional)
+	}
+}
+
+// deepMergeMaps recursively merges m2 into m1, removing the keys whose value is null in m2.
+// Lists of maps are merged by the value of the given merge key, unless it is empty.
+func deepMergeMaps(m1, m2 map[string]any, mergeKey string) map[string]any {
+	out := make(map[string]any, len(m1))
+	for k, v := range m1 {
+		out[k] = v
+	}
+
+	for k, v := range m2 {
+		switch inner := v.(type) {
+		case nil:
+			delete(out, k)
+			continue
+		case map[string]any:
+			outInner, _ := out[k].(map[string]any)
+			out[k] = deepMergeMaps(outInner, inner, mergeKey)
+			continue
+		case []any:
+			if mergeKey != "" {
+				outInner, _ := out[k].([]any)
+				if merged, ok := mergeLists(outInner, inner, mergeKey); ok {
+					out[k] = merged
+					continue
+				}
+			}
+		}
+		out[k] = v
+	}
+
+	return out
+}
+
+// mergeLists merges the items of l2 into the items of l1 with the same merge key value and appends the others.
+// Items of l2 containing the delete directive remove the matching items of l1 instead.
+// Lists whose items are not all maps containing the merge key cannot be merged and are reported as such.
+func mergeLists(l1, l2 []any, mergeKey string) ([]any, bool) {
+	if len(l2) == 0 || !isKeyedList(l1, mergeKey) || !isKeyedList(l2, mergeKey) {
+		return nil, false
+	}
+
+	out := slices.Clone(l1)
+
+	for _, item := range l2 {
+		m := item.(map[string]any)
+		key := fmt.Sprint(m[mergeKey])
+
+		i := slices.IndexFunc(out, func(o any) bool {
+			return fmt.Sprint(o.(map[string]any)[mergeKey]) == key
+		})
+
+		if m[listItemDeleteDirective] == "delete" {
+			if i >= 0 {
+				out = slices.Delete(out, i, i+1)
+			}
+			continue
+		}
+
+		if i >= 0 {
+			out[i] = deepMergeMaps(out[i].(map[string]any), m, mergeKey)
+		} else {
+			out = append(out, deepMergeMaps(nil, m, mergeKey))
+		}
+	}
+
+	return out, true
+}
+
+func isKeyedList(l []any, mergeKey string) bool {
+	for _, item := range l {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		if _, ok = m[mergeKey]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyValuesPatch applies the given RFC 6902 JSON patch to the values.
+// As patches are re-applied on top of installed values which already contain the result of earlier upgrades,
+// operations are skipped if their result is already present: "remove" and "move" operations whose source path
+// is missing, as well as "add" and "copy" operations whose value is already present at the target path
+// (or anywhere in the target list when appending). Test operations are skipped as well if the other operations
+// of the patch leave the values unchanged, since the patch has then already been applied.
+func applyValuesPatch(values map[string]any, rawPatch []byte) (map[string]any, error) {
+	patch, err := jsonpatch.DecodePatch(rawPatch)
+	if err != nil {
+		return nil, fmt.Errorf("decoding JSON patch: %w", err)
+	}
+
+	doc, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling chart values: %w", err)
+	}
+
+	onlyTests := !slices.ContainsFunc(patch, func(operation jsonpatch.Operation) bool {
+		return operation.Kind() != "test"
+	})
+
+	patched, err := applyValuesOperations(doc, patch, !onlyTests)
+	if err != nil {
+		return nil, err
+	}
+
+	if !onlyTests && !jsonpatch.Equal(doc, patched) {
+		if patched, err = applyValuesOperations(doc, patch, false); err != nil {
+			return nil, err
+		}
+	}
+
+	out := map[string]any{}
+	if err = json.Unmarshal(patched, &out); err != nil {
+		return nil, fmt.Errorf("unmarshaling patched chart values: %w", err)
+	}
+
+	return out, nil
+}
+
+// applyValuesOperations applies the operations of the patch to the document one by one,
+// skipping the operations whose result is already present as well as test operations if requested.
+func applyValuesOperations(doc []byte, patch jsonpatch.Patch, skipTests bool) ([]byte, error) {
+	for _, operation := range patch {
+		if skipTests && operation.Kind() == "test" {
+			continue
+		}
+
+		var current any
+		if err := json.Unmarshal(doc, &current); err != nil {
+			return nil, fmt.Errorf("unmarshaling chart values: %w", err)
+		}
+
+		applied, err := isOperationApplied(current, operation)
+		if err != nil {
+			return nil, err
+		}
+
+		if applied {
+			continue
+		}
+
+		if doc, err = (jsonpatch.Patch{operation}).Apply(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// isOperationApplied reports whether the result of the given operation is already present in the values.
+func isOperationApplied(values any, operation jsonpatch.Operation) (bool, error) {
+	switch operation.Kind() {
+	case "remove":
+		path, err := operation.Path()
+		if err != nil {
+			return false, err
+		}
+
+		_, found := lookupValuesPath(values, path)
+		return !found, nil
+	case "move":
+		from, err := operation.From()
+		if err != nil {
+			return false, err
+		}
+
+		_, found := lookupValuesPath(values, from)
+		return !found, nil
+	case "add", "copy":
+		path, err := operation.Path()
+		if err != nil {
+			return false, err
+		}
+
+		var value any
+		if operation.Kind() == "copy" {
+			from, err := operation.From()
+			if err != nil {
+				return false, err
+			}
+
+			var found bool
+			if value, found = lookupValuesPath(values, from); !found {
+				return false, nil
+			}
+		} else if value, err = operation.ValueInterface(); err != nil {
+			return false, err
+		}
+
+		if parent, ok := strings.CutSuffix(path, "/-"); ok {
+			list, _ := lookupValuesPath(values, parent)
+			items, _ := list.([]any)
+
+			return slices.ContainsFunc(items, func(item any) bool {
+				return reflect.DeepEqual(item, value)
+			}), nil
+		}
+
+		existing, found := lookupValuesPath(values, path)
+		return found && reflect.DeepEqual(existing, value), nil
+	default:
+		return false, nil
+	}
+}
+
+// lookupValuesPath returns the value referenced by the given RFC 6901 JSON pointer, if any.
+func lookupValuesPath(values any, pointer string) (any, bool) {
+	if pointer == "" {
+		return values, true
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch v := values.(type) {
+		case map[string]any:
+			value, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			values = value
+		case []any:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			values = v[idx]
+		default:
+			return nil, false
+		}
+	}
+
+	return values, true
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lifecyclev1alpha1 "github.com/suse-edge/upgrade-controller/api/v1alpha1"
+	"gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const installedMergeValues = `
+replicas: 1
+legacy:
+  enabled: true
+image:
+  tag: v1.0.0
+  pullPolicy: IfNotPresent
+env:
+  - name: LOG_LEVEL
+    value: info
+  - name: LEGACY_MODE
+    value: "true"
+hosts:
+  - a.example.com
+`
+
+func TestMergeHelmValuesStrategies(t *testing.T) {
+	tests := []struct {
+		name           string
+		sources        []valuesSource
+		expectedValues string
+		expectedErr    string
+	}{
+		{
+			name: "Merge replaces lists and keeps null values",
+			sources: []valuesSource{{
+				name:   "user",
+				values: &apiextensionsv1.JSON{Raw: []byte(`{"legacy": null, "env": [{"name": "LOG_LEVEL", "value": "debug"}]}`)},
+			}},
+			expectedValues: `
+replicas: 1
+legacy: null
+image: {tag: v1.0.0, pullPolicy: IfNotPresent}
+env: [{name: LOG_LEVEL, value: debug}]
+hosts: [a.example.com]
+`,
+		},
+		{
+			name: "Deep merge removes null values",
+			sources: []valuesSource{{
+				name:     "user",
+				values:   &apiextensionsv1.JSON{Raw: []byte(`{"legacy": null, "image": {"pullPolicy": null, "tag": "v1.1.0"}, "hosts": ["b.example.com"]}`)},
+				strategy: lifecyclev1alpha1.ValuesMergeStrategyDeepMerge,
+			}},
+			expectedValues: `
+replicas: 1
+image: {tag: v1.1.0}
+env: [{name: LOG_LEVEL, value: info}, {name: LEGACY_MODE, value: "true"}]
+hosts: [b.example.com]
+`,
+		},
+		{
+			name: "Strategic merge merges lists by key",
+			sources: []valuesSource{{
+				name: "release",
+				values: &apiextensionsv1.JSON{Raw: []byte(`{
+					"env": [
+						{"name": "LOG_LEVEL", "value": "debug"},
+						{"name": "LEGACY_MODE", "$patch": "delete"},
+						{"name": "NEW_MODE", "value": "true"}
+					],
+					"hosts": ["b.example.com"]
+				}`)},
+				strategy: lifecyclev1alpha1.ValuesMergeStrategyStrategicMerge,
+			}},
+			expectedValues: `
+replicas: 1
+legacy: {enabled: true}
+image: {tag: v1.0.0, pullPolicy: IfNotPresent}
+env: [{name: LOG_LEVEL, value: debug}, {name: NEW_MODE, value: "true"}]
+hosts: [b.example.com]
+`,
+		},
+		{
+			name: "Strategic merge with custom merge key",
+			sources: []valuesSource{{
+				name:     "release",
+				values:   &apiextensionsv1.JSON{Raw: []byte(`{"env": [{"value": "info", "name": "LOG_FORMAT"}]}`)},
+				strategy: lifecyclev1alpha1.ValuesMergeStrategyStrategicMerge,
+				mergeKey: "value",
+			}},
+			expectedValues: `
+replicas: 1
+legacy: {enabled: true}
+image: {tag: v1.0.0, pullPolicy: IfNotPresent}
+env: [{name: LOG_FORMAT, value: info}, {name: LEGACY_MODE, value: "true"}]
+hosts: [a.example.com]
+`,
+		},
+		{
+			name: "JSON patches are applied after merging",
+			sources: []valuesSource{
+				{
+					name:     "release",
+					values:   &apiextensionsv1.JSON{Raw: []byte(`[{"op": "remove", "path": "/env/1"}, {"op": "move", "from": "/legacy", "path": "/compat"}]`)},
+					strategy: lifecyclev1alpha1.ValuesMergeStrategyJSONPatch,
+				},
+				{
+					name:   "user",
+					values: &apiextensionsv1.JSON{Raw: []byte(`{"replicas": 3}`)},
+				},
+			},
+			expectedValues: `
+replicas: 3
+compat: {enabled: true}
+image: {tag: v1.0.0, pullPolicy: IfNotPresent}
+env: [{name: LOG_LEVEL, value: info}]
+hosts: [a.example.com]
+`,
+		},
+		{
+			name: "Failed JSON patch",
+			sources: []valuesSource{{
+				name:     "user",
+				values:   &apiextensionsv1.JSON{Raw: []byte(`[{"op": "test", "path": "/replicas", "value": 2}]`)},
+				strategy: lifecyclev1alpha1.ValuesMergeStrategyJSONPatch,
+			}},
+			expectedErr: "Applying the user values patch failed: testing value /replicas failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := mergeHelmValues(installedMergeValues, test.sources...)
+			if test.expectedErr != "" {
+				var validationErr *chartValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Contains(t, validationErr.Error(), test.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			expected := map[string]any{}
+			require.NoError(t, yaml.Unmarshal([]byte(test.expectedValues), &expected))
+
+			actual := map[string]any{}
+			require.NoError(t, yaml.Unmarshal(values, &actual))
+
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestMergeHelmValuesReappliedPatch(t *testing.T) {
+	patch := valuesSource{
+		name: "user",
+		values: &apiextensionsv1.JSON{Raw: []byte(`[
+			{"op": "remove", "path": "/legacy"},
+			{"op": "move", "from": "/hosts", "path": "/ingress/hosts"},
+			{"op": "test", "path": "/image/tag", "value": "v1.0.0"},
+			{"op": "replace", "path": "/image/tag", "value": "v1.1.0"},
+			{"op": "add", "path": "/env/0", "value": {"name": "DEBUG", "value": "false"}},
+			{"op": "add", "path": "/env/-", "value": {"name": "TRACE", "value": "true"}},
+			{"op": "copy", "from": "/ingress/hosts/0", "path": "/tls/hosts/-"}
+		]`)},
+		strategy: lifecyclev1alpha1.ValuesMergeStrategyJSONPatch,
+	}
+
+	values, err := mergeHelmValues(`{"legacy": {"enabled": true}, "hosts": ["a.example.com"], "ingress": {}, "image": {"tag": "v1.0.0"}, "env": [], "tls": {"hosts": []}}`, patch)
+	require.NoError(t, err)
+
+	// Upgrades apply the patch again on top of the installed values holding the result of the previous patch.
+	reapplied, err := mergeHelmValues(string(values), patch)
+	require.NoError(t, err)
+
+	expected := map[string]any{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+ingress: {hosts: [a.example.com]}
+image: {tag: v1.1.0}
+env: [{name: DEBUG, value: "false"}, {name: TRACE, value: "true"}]
+tls: {hosts: [a.example.com]}
+`), &expected))
+
+	for _, v := range [][]byte{values, reapplied} {
+		actual := map[string]any{}
+		require.NoError(t, yaml.Unmarshal(v, &actual))
+		assert.Equal(t, expected, actual)
+	}
+
+	// Test operations still guard patches which have not been applied yet.
+	_, err = mergeHelmValues(`{"image": {"tag": "v2.0.0"}, "env": []}`, patch)
+	assert.ErrorContains(t, err, "Applying the user values patch failed")
+}
+
+func TestLookupValuesPath(t *testing.T) {
+	values := map[string]any{
+		"a/b": map[string]any{"c~d": []any{"x", "y"}},
+	}
+
+	value, found := lookupValuesPath(values, "")
+	assert.True(t, found)
+	assert.Equal(t, values, value)
+
+	value, found = lookupValuesPath(values, "/a~1b/c~0d/1")
+	assert.True(t, found)
+	assert.Equal(t, "y", value)
+
+	_, found = lookupValuesPath(values, "/a~1b")
+	assert.True(t, found)
+
+	for _, pointer := range []string{"/a~1b/c~0d/2", "/a~1b/c~0d/-", "/a", "/a~1b/c~0d/0/e"} {
+		_, found = lookupValuesPath(values, pointer)
+		assert.False(t, found, pointer)
+	}
+}
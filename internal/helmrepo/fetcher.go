@@ -0,0 +1,163 @@
+package helmrepo
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+const (
+	ociPrefix = "oci://"
+
+	// maxChartSize limits the size of the chart archives being read.
+	maxChartSize = 20 << 20
+)
+
+// Fetcher retrieves Helm charts from HTTP repositories and OCI registries.
+type Fetcher struct {
+	Client *http.Client
+}
+
+// Credentials configure the access to the repository of a chart. They mirror the repository settings of a HelmChart.
+type Credentials struct {
+	// Username and Password authenticate to HTTP repositories, as well as to OCI registries not listed in DockerConfig.
+	Username string
+	Password string
+
+	// PassCredentials sends the credentials to all hosts instead of the repository host only,
+	// e.g. when the chart archives of a repository are served from a different domain.
+	PassCredentials bool
+
+	// DockerConfig is the content of a ".dockerconfigjson" file holding the credentials of OCI registries.
+	DockerConfig []byte
+
+	// CAData holds PEM encoded certificates trusted in addition to the system certificates.
+	CAData []byte
+
+	InsecureSkipTLSVerify bool
+	PlainHTTP             bool
+}
+
+// fetch holds the state of a single chart retrieval.
+type fetch struct {
+	client      *http.Client
+	credentials Credentials
+}
+
+// Fetch retrieves the given version of a chart. OCI charts are referenced either via the chart name
+// (e.g. "oci://registry.suse.com/edge/metallb-chart") or via an "oci://" repository holding the chart.
+func (f *Fetcher) Fetch(ctx context.Context, repository, name, version string, credentials Credentials) (*chart.Chart, error) {
+	client, err := f.client(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	fetch := &fetch{client: client, credentials: credentials}
+
+	var archive []byte
+
+	switch {
+	case strings.HasPrefix(name, ociPrefix):
+		archive, err = fetch.pullChart(ctx, strings.TrimPrefix(name, ociPrefix), version)
+	case strings.HasPrefix(repository, ociPrefix):
+		reference := strings.TrimSuffix(strings.TrimPrefix(repository, ociPrefix), "/") + "/" + name
+		archive, err = fetch.pullChart(ctx, reference, version)
+	case repository != "":
+		archive, err = fetch.downloadChart(ctx, repository, name, version)
+	default:
+		return nil, fmt.Errorf("chart %s specifies neither a repository nor an OCI reference", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := loader.LoadArchive(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("loading chart archive: %w", err)
+	}
+
+	return c, nil
+}
+
+// client returns the HTTP client of the fetcher, configured with the TLS settings of the given credentials.
+func (f *Fetcher) client(credentials Credentials) (*http.Client, error) {
+	if len(credentials.CAData) == 0 && !credentials.InsecureSkipTLSVerify {
+		return f.Client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t, ok := f.Client.Transport.(*http.Transport); ok {
+		transport = t.Clone()
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = credentials.InsecureSkipTLSVerify
+
+	if len(credentials.CAData) != 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(credentials.CAData) {
+			return nil, fmt.Errorf("repository CA does not contain any valid certificate")
+		}
+
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: f.Client.CheckRedirect,
+		Timeout:       f.Client.Timeout,
+	}, nil
+}
+
+// get retrieves the given URL, sending the basic auth credentials if requested.
+func (f *fetch) get(ctx context.Context, url string, limit int64, authenticate bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+
+	if authenticate && f.credentials.Username != "" {
+		req.SetBasicAuth(f.credentials.Username, f.credentials.Password)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return readBody(resp, limit)
+}
+
+// readBody reads the body of a successful response, failing if it exceeds the given limit.
+func readBody(resp *http.Response, limit int64) ([]byte, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response exceeds %d bytes", limit)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,208 @@
+package helmrepo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newChartArchive(t *testing.T, name, version, kubeVersion string) []byte {
+	files := map[string]string{
+		"Chart.yaml":         fmt.Sprintf("apiVersion: v2\nname: %s\nversion: %s\nkubeVersion: '%s'\n", name, version, kubeVersion),
+		"values.yaml":        "replicas: 1\n",
+		"values.schema.json": `{"type": "object", "required": ["replicas"]}`,
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for file, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name + "/" + file, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	return buf.Bytes()
+}
+
+func TestFetcher_Repository(t *testing.T) {
+	archive := newChartArchive(t, "rancher", "2.9.1", ">= 1.26.0-0")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/server-charts/index.yaml":
+			fmt.Fprint(w, `
+apiVersion: v1
+entries:
+  rancher:
+    - version: 2.9.2
+      urls: [https://example.com/rancher-2.9.2.tgz]
+    - version: 2.9.1
+      urls: [rancher-2.9.1.tgz]
+`)
+		case "/server-charts/rancher-2.9.1.tgz":
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := &Fetcher{Client: server.Client()}
+
+	chart, err := fetcher.Fetch(context.Background(), server.URL+"/server-charts/", "rancher", "2.9.1", Credentials{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "rancher", chart.Metadata.Name)
+	assert.Equal(t, "2.9.1", chart.Metadata.Version)
+	assert.Equal(t, ">= 1.26.0-0", chart.Metadata.KubeVersion)
+	assert.NotEmpty(t, chart.Schema)
+
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/server-charts", "rancher", "2.8.0", Credentials{})
+	assert.ErrorContains(t, err, "version 2.8.0 of chart rancher not found in repository")
+
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/missing", "rancher", "2.9.1", Credentials{})
+	assert.ErrorContains(t, err, "fetching index file of repository")
+
+	_, err = fetcher.Fetch(context.Background(), "", "rancher", "2.9.1", Credentials{})
+	assert.EqualError(t, err, "chart rancher specifies neither a repository nor an OCI reference")
+}
+
+func TestFetcher_Registry(t *testing.T) {
+	const token = "abc"
+
+	archive := newChartArchive(t, "metallb", "302.0.0+up0.14.9", "")
+	sum := sha256.Sum256(archive)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "repository:edge/metallb-chart:pull", r.URL.Query().Get("scope"))
+			fmt.Fprintf(w, `{"token": "%s"}`, token)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/edge/metallb-chart/manifests/302.0.0_up0.14.9":
+			assert.Equal(t, ociManifestMediaType, r.Header.Get("Accept"))
+			fmt.Fprintf(w, `{"layers": [{"mediaType": "application/vnd.cncf.helm.config.v1+json", "digest": "sha256:config"},
+				{"mediaType": "%s", "digest": "%s"}]}`, chartLayerMediaType, digest)
+		case "/v2/edge/metallb-chart/blobs/" + digest:
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := &Fetcher{Client: server.Client()}
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	chart, err := fetcher.Fetch(context.Background(), "", "oci://"+host+"/edge/metallb-chart", "302.0.0+up0.14.9", Credentials{})
+	require.NoError(t, err)
+	assert.Equal(t, "metallb", chart.Metadata.Name)
+
+	chart, err = fetcher.Fetch(context.Background(), "oci://"+host+"/edge/", "metallb-chart", "302.0.0+up0.14.9", Credentials{})
+	require.NoError(t, err)
+	assert.Equal(t, "302.0.0+up0.14.9", chart.Metadata.Version)
+
+	_, err = fetcher.Fetch(context.Background(), "", "oci://"+host+"/edge/metallb-chart", "303.0.0+up0.15.0", Credentials{})
+	assert.ErrorContains(t, err, "unexpected status code 404")
+}
+
+func TestFetcher_Credentials(t *testing.T) {
+	archive := newChartArchive(t, "rancher", "2.9.1", "")
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/index.yaml":
+			fmt.Fprint(w, "entries:\n  rancher:\n    - version: 2.9.1\n      urls: [rancher-2.9.1.tgz]\n")
+		case "/rancher-2.9.1.tgz":
+			_, _ = w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	fetcher := &Fetcher{Client: &http.Client{}}
+
+	_, err := fetcher.Fetch(context.Background(), server.URL, "rancher", "2.9.1", Credentials{Username: "user", Password: "pass"})
+	assert.ErrorContains(t, err, "certificate")
+
+	_, err = fetcher.Fetch(context.Background(), server.URL, "rancher", "2.9.1", Credentials{CAData: caData})
+	assert.ErrorContains(t, err, "unexpected status code 401")
+
+	chart, err := fetcher.Fetch(context.Background(), server.URL, "rancher", "2.9.1",
+		Credentials{Username: "user", Password: "pass", CAData: caData})
+	require.NoError(t, err)
+	assert.Equal(t, "rancher", chart.Metadata.Name)
+
+	chart, err = fetcher.Fetch(context.Background(), server.URL, "rancher", "2.9.1",
+		Credentials{Username: "user", Password: "pass", InsecureSkipTLSVerify: true})
+	require.NoError(t, err)
+	assert.Equal(t, "2.9.1", chart.Metadata.Version)
+
+	_, err = fetcher.Fetch(context.Background(), server.URL, "rancher", "2.9.1", Credentials{CAData: []byte("invalid")})
+	assert.EqualError(t, err, "repository CA does not contain any valid certificate")
+}
+
+func TestFetch_RegistryCredentials(t *testing.T) {
+	dockerConfig := []byte(`{"auths": {
+		"https://registry.example.com": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("robot:token")) + `"},
+		"mirror.example.com": {"username": "mirror", "password": "secret"}
+	}}`)
+
+	f := &fetch{credentials: Credentials{Username: "user", Password: "pass", DockerConfig: dockerConfig}}
+
+	tests := []struct {
+		host             string
+		expectedUsername string
+		expectedPassword string
+	}{
+		{host: "registry.example.com", expectedUsername: "robot", expectedPassword: "token"},
+		{host: "mirror.example.com", expectedUsername: "mirror", expectedPassword: "secret"},
+		{host: "registry.suse.com", expectedUsername: "user", expectedPassword: "pass"},
+	}
+
+	for _, test := range tests {
+		username, password, err := f.registryCredentials(test.host)
+		require.NoError(t, err)
+		assert.Equal(t, test.expectedUsername, username, test.host)
+		assert.Equal(t, test.expectedPassword, password, test.host)
+	}
+
+	f.credentials.DockerConfig = []byte("{")
+	_, _, err := f.registryCredentials("registry.example.com")
+	assert.ErrorContains(t, err, "parsing docker config")
+}
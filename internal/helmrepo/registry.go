@@ -0,0 +1,133 @@
+package helmrepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/suse-edge/upgrade-controller/internal/catalog"
+)
+
+const (
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	chartLayerMediaType  = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	// maxManifestSize limits the size of the OCI manifests being read.
+	maxManifestSize = 4 << 20
+)
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+// pullChart retrieves the chart layer of the given OCI reference such as "registry.suse.com/edge/metallb-chart".
+func (f *fetch) pullChart(ctx context.Context, reference, version string) ([]byte, error) {
+	registry, err := catalog.NewRegistrySource(f.client, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if registry.Username, registry.Password, err = f.registryCredentials(registry.Host); err != nil {
+		return nil, err
+	}
+
+	scheme := "https"
+	if f.credentials.PlainHTTP {
+		scheme = "http"
+	}
+
+	// Helm replaces "+" with "_" since it is not allowed in OCI tags.
+	tag := strings.ReplaceAll(version, "+", "_")
+
+	resp, err := registry.Get(ctx, fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, registry.Host, registry.Repository, tag), ociManifestMediaType)
+	if err != nil {
+		return nil, fmt.Errorf("fetching manifest of chart %s:%s: %w", reference, tag, err)
+	}
+
+	data, err := readBody(resp, maxManifestSize)
+	if err != nil {
+		return nil, fmt.Errorf("fetching manifest of chart %s:%s: %w", reference, tag, err)
+	}
+
+	var manifest ociManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest of chart %s:%s: %w", reference, tag, err)
+	}
+
+	var layer *ociDescriptor
+	for i := range manifest.Layers {
+		if manifest.Layers[i].MediaType == chartLayerMediaType {
+			layer = &manifest.Layers[i]
+			break
+		}
+	}
+
+	if layer == nil {
+		return nil, fmt.Errorf("manifest of chart %s:%s does not contain a chart layer", reference, tag)
+	}
+
+	resp, err = registry.Get(ctx, fmt.Sprintf("%s://%s/v2/%s/blobs/%s", scheme, registry.Host, registry.Repository, layer.Digest), chartLayerMediaType)
+	if err != nil {
+		return nil, fmt.Errorf("pulling chart %s:%s: %w", reference, tag, err)
+	}
+
+	archive, err := readBody(resp, maxChartSize)
+	if err != nil {
+		return nil, fmt.Errorf("pulling chart %s:%s: %w", reference, tag, err)
+	}
+
+	sum := sha256.Sum256(archive)
+	if digest := "sha256:" + hex.EncodeToString(sum[:]); digest != layer.Digest {
+		return nil, fmt.Errorf("digest %s of chart %s:%s does not match the expected digest %s", digest, reference, tag, layer.Digest)
+	}
+
+	return archive, nil
+}
+
+type dockerConfig struct {
+	Auths map[string]struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	} `json:"auths"`
+}
+
+// registryCredentials returns the credentials of the given registry host. Entries of the Docker config take precedence
+// over the basic auth credentials.
+func (f *fetch) registryCredentials(host string) (username, password string, err error) {
+	if len(f.credentials.DockerConfig) != 0 {
+		var config dockerConfig
+		if err = json.Unmarshal(f.credentials.DockerConfig, &config); err != nil {
+			return "", "", fmt.Errorf("parsing docker config: %w", err)
+		}
+
+		for key, auth := range config.Auths {
+			if strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://"), "/") != host {
+				continue
+			}
+
+			if auth.Username != "" {
+				return auth.Username, auth.Password, nil
+			}
+
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return "", "", fmt.Errorf("decoding docker config auth of registry %s: %w", host, err)
+			}
+
+			username, password, _ = strings.Cut(string(decoded), ":")
+			return username, password, nil
+		}
+	}
+
+	return f.credentials.Username, f.credentials.Password, nil
+}
@@ -0,0 +1,65 @@
+package helmrepo
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxIndexSize limits the size of the repository index files being read.
+const maxIndexSize = 64 << 20
+
+type indexFile struct {
+	Entries map[string][]indexEntry `yaml:"entries"`
+}
+
+type indexEntry struct {
+	Version string   `yaml:"version"`
+	URLs    []string `yaml:"urls"`
+}
+
+// downloadChart looks up the chart version in the index file of an HTTP repository and downloads its archive.
+func (f *fetch) downloadChart(ctx context.Context, repository, name, version string) ([]byte, error) {
+	base, err := url.Parse(strings.TrimSuffix(repository, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("parsing repository URL: %w", err)
+	}
+
+	data, err := f.get(ctx, base.JoinPath("index.yaml").String(), maxIndexSize, true)
+	if err != nil {
+		return nil, fmt.Errorf("fetching index file of repository %s: %w", repository, err)
+	}
+
+	var index indexFile
+	if err = yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("parsing index file of repository %s: %w", repository, err)
+	}
+
+	idx := slices.IndexFunc(index.Entries[name], func(entry indexEntry) bool {
+		return entry.Version == version && len(entry.URLs) != 0
+	})
+	if idx == -1 {
+		return nil, fmt.Errorf("version %s of chart %s not found in repository %s", version, name, repository)
+	}
+
+	archiveURL, err := url.Parse(index.Entries[name][idx].URLs[0])
+	if err != nil {
+		return nil, fmt.Errorf("parsing chart URL: %w", err)
+	}
+
+	archiveURL = base.ResolveReference(archiveURL)
+
+	// Credentials are only sent to other hosts if explicitly requested.
+	authenticate := archiveURL.Host == base.Host || f.credentials.PassCredentials
+
+	archive, err := f.get(ctx, archiveURL.String(), maxChartSize, authenticate)
+	if err != nil {
+		return nil, fmt.Errorf("downloading chart %s: %w", name, err)
+	}
+
+	return archive, nil
+}
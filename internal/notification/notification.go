@@ -0,0 +1,116 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/suse-edge/upgrade-controller/internal/safehttp"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// SignatureHeader holds the hex encoded HMAC-SHA256 signature of the payload, prefixed with "sha256=".
+	SignatureHeader = "X-Upgrade-Controller-Signature"
+	// EventHeader holds the type of the condition which transitioned.
+	EventHeader = "X-Upgrade-Controller-Event"
+)
+
+// Event describes a condition transition of an UpgradePlan.
+type Event struct {
+	UpgradePlan    string    `json:"upgradePlan"`
+	Namespace      string    `json:"namespace"`
+	ReleaseVersion string    `json:"releaseVersion"`
+	Condition      string    `json:"condition"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason"`
+	PreviousReason string    `json:"previousReason,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// Sender delivers events to webhook endpoints.
+// Deliveries failing due to network errors or server side errors are retried.
+type Sender struct {
+	Client  *http.Client
+	Backoff wait.Backoff
+}
+
+// errPermanent marks delivery failures which are not retried.
+var errPermanent = errors.New("permanent delivery failure")
+
+// Send posts the JSON encoded event to the given URL. The payload is signed if a signing key is provided.
+func (s *Sender) Send(ctx context.Context, url string, signingKey []byte, event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+
+	var lastErr error
+	err = wait.ExponentialBackoffWithContext(ctx, s.Backoff, func(ctx context.Context) (bool, error) {
+		lastErr = s.post(ctx, url, signingKey, event.Condition, payload)
+		if lastErr == nil {
+			return true, nil
+		}
+
+		if errors.Is(lastErr, errPermanent) || errors.Is(lastErr, safehttp.ErrAddressNotAllowed) {
+			return false, lastErr
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		if lastErr != nil {
+			return fmt.Errorf("delivering event: %w", lastErr)
+		}
+		return fmt.Errorf("delivering event: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Sender) post(ctx context.Context, url string, signingKey []byte, condition string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("building request: %w: %w", errPermanent, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, condition)
+	if len(signingKey) != 0 {
+		req.Header.Set(SignatureHeader, Sign(signingKey, payload))
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body in order to reuse the connection.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	default:
+		return fmt.Errorf("%w: unexpected status code %d", errPermanent, resp.StatusCode)
+	}
+}
+
+// Sign returns the signature of the payload as sent in the SignatureHeader.
+func Sign(key, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
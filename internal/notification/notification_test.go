@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suse-edge/upgrade-controller/internal/safehttp"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func newSender() *Sender {
+	return &Sender{
+		Client:  http.DefaultClient,
+		Backoff: wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 3},
+	}
+}
+
+func TestSend(t *testing.T) {
+	key := []byte("secret")
+	event := &Event{
+		UpgradePlan:    "upgrade-plan",
+		Namespace:      "upgrade-controller-system",
+		ReleaseVersion: "3.1.0",
+		Condition:      "OSUpgraded",
+		Status:         "False",
+		Reason:         "Failed",
+		PreviousReason: "InProgress",
+		Message:        "OS upgrade failed",
+	}
+
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "OSUpgraded", r.Header.Get(EventHeader))
+		assert.Equal(t, Sign(key, body), r.Header.Get(SignatureHeader))
+
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	require.NoError(t, newSender().Send(context.Background(), server.URL, key, event))
+	assert.Equal(t, *event, received)
+}
+
+func TestSendUnsigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+	}))
+	defer server.Close()
+
+	require.NoError(t, newSender().Send(context.Background(), server.URL, nil, &Event{Condition: "Ready"}))
+}
+
+func TestSendRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}))
+	defer server.Close()
+
+	require.NoError(t, newSender().Send(context.Background(), server.URL, nil, &Event{Condition: "Ready"}))
+	assert.EqualValues(t, 3, attempts.Load())
+}
+
+func TestSendFailure(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := newSender().Send(context.Background(), server.URL, nil, &Event{Condition: "Ready"})
+	require.Error(t, err)
+	assert.EqualError(t, err, "delivering event: unexpected status code 500")
+	assert.EqualValues(t, 3, attempts.Load())
+
+	// Client errors are not retried.
+	attempts.Store(0)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	err = newSender().Send(context.Background(), server.URL, nil, &Event{Condition: "Ready"})
+	require.Error(t, err)
+	assert.EqualError(t, err, "delivering event: permanent delivery failure: unexpected status code 400")
+	assert.EqualValues(t, 1, attempts.Load())
+}
+
+func TestSendRefusedAddress(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	sender := newSender()
+	sender.Client = safehttp.NewClient(time.Second, nil)
+
+	err := sender.Send(context.Background(), server.URL, nil, &Event{Condition: "Ready"})
+	assert.ErrorContains(t, err, "connections to non-public address 127.0.0.1 are not allowed")
+	assert.ErrorIs(t, err, safehttp.ErrAddressNotAllowed)
+	assert.Zero(t, requests.Load())
+}
@@ -0,0 +1,61 @@
+package notification
+
+import "sync"
+
+// DefaultQueueSize is the number of deliveries queued per target if the queue size is not set.
+const DefaultQueueSize = 100
+
+// Queue runs the deliveries of each target in order, one at a time. Deliveries exceeding the size
+// of the queue of a target are rejected. The zero value is ready to use.
+type Queue struct {
+	Size int
+
+	mu      sync.Mutex
+	pending map[string][]func()
+}
+
+// Enqueue schedules the delivery for the given target. Returns false if the queue of the target is full.
+func (q *Queue) Enqueue(target string, delivery func()) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	size := q.Size
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+
+	deliveries, running := q.pending[target]
+	if len(deliveries) >= size {
+		return false
+	}
+
+	if q.pending == nil {
+		q.pending = map[string][]func(){}
+	}
+	q.pending[target] = append(deliveries, delivery)
+
+	if !running {
+		go q.run(target)
+	}
+
+	return true
+}
+
+// run processes the deliveries of the given target until its queue is empty.
+func (q *Queue) run(target string) {
+	for {
+		q.mu.Lock()
+		deliveries := q.pending[target]
+		if len(deliveries) == 0 {
+			delete(q.pending, target)
+			q.mu.Unlock()
+			return
+		}
+
+		delivery := deliveries[0]
+		q.pending[target] = deliveries[1:]
+		q.mu.Unlock()
+
+		delivery()
+	}
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	q := &Queue{Size: 2}
+
+	block := make(chan struct{})
+	var mu sync.Mutex
+	var delivered []string
+
+	deliver := func(name string) func() {
+		return func() {
+			<-block
+			mu.Lock()
+			delivered = append(delivered, name)
+			mu.Unlock()
+		}
+	}
+
+	assert.True(t, q.Enqueue("target-a", deliver("a1")))
+
+	// The first delivery is running, the following ones are queued.
+	assert.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.pending["target-a"]) == 0
+	}, time.Second, time.Millisecond)
+
+	assert.True(t, q.Enqueue("target-a", deliver("a2")))
+	assert.True(t, q.Enqueue("target-a", deliver("a3")))
+	assert.False(t, q.Enqueue("target-a", deliver("a4")))
+	assert.True(t, q.Enqueue("target-b", deliver("b1")))
+
+	close(block)
+
+	assert.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.pending) == 0
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.ElementsMatch(t, []string{"a1", "a2", "a3", "b1"}, delivered)
+
+	var targetA []string
+	for _, name := range delivered {
+		if name[0] == 'a' {
+			targetA = append(targetA, name)
+		}
+	}
+	assert.Equal(t, []string{"a1", "a2", "a3"}, targetA)
+}
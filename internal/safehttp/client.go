@@ -0,0 +1,72 @@
+// Package safehttp provides HTTP clients for requesting user supplied URLs.
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// NewClient returns an HTTP client which refuses to connect to loopback, private, link-local and other non-public
+// addresses unless they are part of the allowed networks. The addresses are verified after DNS resolution so that
+// endpoints cannot be redirected to internal services. Proxies are not used as they would bypass the verification.
+func NewClient(timeout time.Duration, allowedNetworks []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return verifyAddress(address, allowedNetworks)
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// ErrAddressNotAllowed is returned when connecting to an address which is neither public nor allowed.
+var ErrAddressNotAllowed = errors.New("address not allowed")
+
+// ParseNetworks parses the given CIDR notations, e.g. "10.43.0.0/16".
+func ParseNetworks(cidrs []string) ([]netip.Prefix, error) {
+	networks := make([]netip.Prefix, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		network, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing network '%s': %w", cidr, err)
+		}
+		networks = append(networks, network.Masked())
+	}
+
+	return networks, nil
+}
+
+func verifyAddress(address string, allowedNetworks []netip.Prefix) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: parsing address %s: %w", ErrAddressNotAllowed, address, err)
+	}
+
+	addr := addrPort.Addr().Unmap()
+	if isPublicAddress(addr) || slices.ContainsFunc(allowedNetworks, func(network netip.Prefix) bool {
+		return network.Contains(addr)
+	}) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: connections to non-public address %s are not allowed", ErrAddressNotAllowed, addr)
+}
+
+func isPublicAddress(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range as per RFC 6598.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
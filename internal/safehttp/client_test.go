@@ -0,0 +1,73 @@
+package safehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyAddress(t *testing.T) {
+	allowed := []netip.Prefix{netip.MustParsePrefix("10.43.0.0/16")}
+
+	tests := []struct {
+		address     string
+		expectedErr string
+	}{
+		{address: "203.0.113.10:443"},
+		{address: "[2001:db8::1]:443"},
+		{address: "10.43.12.1:80"},
+		{address: "127.0.0.1:80", expectedErr: "connections to non-public address 127.0.0.1 are not allowed"},
+		{address: "[::1]:80", expectedErr: "connections to non-public address ::1 are not allowed"},
+		{address: "10.0.0.1:80", expectedErr: "connections to non-public address 10.0.0.1 are not allowed"},
+		{address: "192.168.1.1:80", expectedErr: "connections to non-public address 192.168.1.1 are not allowed"},
+		{address: "169.254.169.254:80", expectedErr: "connections to non-public address 169.254.169.254 are not allowed"},
+		{address: "100.64.0.1:80", expectedErr: "connections to non-public address 100.64.0.1 are not allowed"},
+		{address: "[::ffff:127.0.0.1]:80", expectedErr: "connections to non-public address 127.0.0.1 are not allowed"},
+		{address: "[fd00::1]:80", expectedErr: "connections to non-public address fd00::1 are not allowed"},
+		{address: "0.0.0.0:80", expectedErr: "connections to non-public address 0.0.0.0 are not allowed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.address, func(t *testing.T) {
+			err := verifyAddress(test.address, allowed)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+				assert.ErrorIs(t, err, ErrAddressNotAllowed)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.43.1.5/16", "fd00::/8"})
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.43.0.0/16"), netip.MustParsePrefix("fd00::/8")}, networks)
+
+	_, err = ParseNetworks([]string{"10.43.0.0"})
+	assert.ErrorContains(t, err, "parsing network '10.43.0.0'")
+}
+
+func TestNewClient(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second, nil).Get(server.URL)
+	assert.ErrorContains(t, err, "connections to non-public address 127.0.0.1 are not allowed")
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+	assert.Zero(t, requests)
+
+	resp, err := NewClient(time.Second, []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName is the instrumentation scope of the spans recorded by the controller.
+	TracerName = "github.com/suse-edge/upgrade-controller"
+
+	serviceName = "upgrade-controller"
+)
+
+// Supported OTLP protocols.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// Options configures the OTLP exporter of the tracer provider.
+type Options struct {
+	// Endpoint is the URL of the OTLP receiver, e.g. http://otel-collector:4317.
+	// Plain text connections are used for http URLs.
+	Endpoint string
+	// Protocol is either ProtocolGRPC or ProtocolHTTP.
+	Protocol string
+}
+
+// NewTracerProvider creates a tracer provider exporting the spans in batches to the configured OTLP receiver.
+func NewTracerProvider(ctx context.Context, opts Options) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch opts.Protocol {
+	case ProtocolGRPC:
+		exporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(opts.Endpoint))
+	case ProtocolHTTP:
+		exporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol: %s", opts.Protocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("creating resource: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithIDGenerator(IDGenerator{}),
+	), nil
+}
+
+type generationKey struct{}
+
+type generationIDs struct {
+	uid    string
+	suffix string
+}
+
+// GenerationIDs derives the trace and root span identifiers of an upgrade generation.
+// Generations are identified by the UID of the upgrade plan and the name suffix of its SUC resources.
+func GenerationIDs(uid, suffix string) (trace.TraceID, trace.SpanID) {
+	sum := sha256.Sum256([]byte(uid + "/" + suffix))
+
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	copy(traceID[:], sum[:16])
+	copy(spanID[:], sum[16:24])
+
+	return traceID, spanID
+}
+
+// ContextWithGeneration returns a context whose spans are children of the root span of the given upgrade generation.
+// The root span itself is recorded separately via StartGenerationSpan once the generation has finished.
+func ContextWithGeneration(ctx context.Context, uid, suffix string) context.Context {
+	traceID, spanID := GenerationIDs(uid, suffix)
+
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+}
+
+// StartGenerationSpan starts the root span of the given upgrade generation.
+// The identifiers of the span are only honored by tracer providers using the IDGenerator of this package.
+func StartGenerationSpan(ctx context.Context, tracer trace.Tracer, uid, suffix, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	ctx = context.WithValue(ctx, generationKey{}, generationIDs{uid: uid, suffix: suffix})
+
+	return tracer.Start(ctx, name, append(opts, trace.WithNewRoot())...)
+}
+
+// IDGenerator generates random identifiers except for the root spans of upgrade generations
+// whose identifiers are derived from the generation.
+type IDGenerator struct{}
+
+var _ sdktrace.IDGenerator = IDGenerator{}
+
+func (IDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	if generation, ok := ctx.Value(generationKey{}).(generationIDs); ok {
+		return GenerationIDs(generation.uid, generation.suffix)
+	}
+
+	var traceID trace.TraceID
+	_, _ = rand.Read(traceID[:])
+
+	return traceID, newSpanID()
+}
+
+func (IDGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	return newSpanID()
+}
+
+func newSpanID() trace.SpanID {
+	var spanID trace.SpanID
+	_, _ = rand.Read(spanID[:])
+
+	return spanID
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestGenerationIDs(t *testing.T) {
+	traceID, spanID := GenerationIDs("plan-uid", "abcdef")
+	assert.True(t, traceID.IsValid())
+	assert.True(t, spanID.IsValid())
+
+	sameTraceID, sameSpanID := GenerationIDs("plan-uid", "abcdef")
+	assert.Equal(t, traceID, sameTraceID)
+	assert.Equal(t, spanID, sameSpanID)
+
+	otherTraceID, _ := GenerationIDs("plan-uid", "ghijkl")
+	assert.NotEqual(t, traceID, otherTraceID)
+}
+
+func TestGenerationSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter), sdktrace.WithIDGenerator(IDGenerator{}))
+	tracer := provider.Tracer(TracerName)
+
+	ctx := ContextWithGeneration(context.Background(), "plan-uid", "abcdef")
+	_, reconcileSpan := tracer.Start(ctx, "Reconcile")
+	reconcileSpan.End()
+
+	_, rootSpan := StartGenerationSpan(ctx, tracer, "plan-uid", "abcdef", "UpgradePlan")
+	rootSpan.End()
+
+	_, unrelatedSpan := tracer.Start(context.Background(), "Reconcile")
+	unrelatedSpan.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	traceID, spanID := GenerationIDs("plan-uid", "abcdef")
+
+	assert.Equal(t, traceID, spans[0].SpanContext.TraceID())
+	assert.Equal(t, spanID, spans[0].Parent.SpanID())
+
+	assert.Equal(t, traceID, spans[1].SpanContext.TraceID())
+	assert.Equal(t, spanID, spans[1].SpanContext.SpanID())
+	assert.False(t, spans[1].Parent.IsValid())
+
+	assert.NotEqual(t, traceID, spans[2].SpanContext.TraceID())
+	assert.True(t, spans[2].SpanContext.IsValid())
+}
@@ -18,6 +18,9 @@ const (
 
 	ControlPlaneLabel = "node-role.kubernetes.io/control-plane"
 
+	// SUCPlanLabel is set by SUC on the jobs it creates for applying a plan.
+	SUCPlanLabel = "upgrade.cattle.io/plan"
+
 	KubeSystemNamespace = "kube-system"
 	SUCNamespace        = "cattle-system"
 
@@ -26,7 +26,9 @@ func ReleaseManifestInstallJob(releaseManifest, kubectl ContainerImage, serviceA
 	}
 
 	workloadName := fmt.Sprintf("apply-release-manifest-%s", strings.ReplaceAll(releaseManifest.Version, ".", "-"))
-	ttl := int32(0)
+	// Finished jobs are deleted by the controller once observed. The TTL only serves as a fallback
+	// and leaves enough time for collecting the logs of failed pods.
+	ttl := int32(300)
 
 	volumeMount := corev1.VolumeMount{
 		Name:      "release",
@@ -75,7 +77,8 @@ func ReleaseManifestInstallJob(releaseManifest, kubectl ContainerImage, serviceA
 							},
 						},
 					},
-					RestartPolicy:      "OnFailure",
+					// Failed pods are retained so that their logs can be collected.
+					RestartPolicy:      "Never",
 					ServiceAccountName: serviceAccount,
 				},
 			},
@@ -64,10 +64,10 @@ func TestReleaseManifestInstallJob(t *testing.T) {
 	assert.Equal(t, "release", job.Spec.Template.Spec.Volumes[0].Name)
 	assert.NotNil(t, job.Spec.Template.Spec.Volumes[0].EmptyDir)
 
-	assert.EqualValues(t, "OnFailure", job.Spec.Template.Spec.RestartPolicy)
+	assert.EqualValues(t, "Never", job.Spec.Template.Spec.RestartPolicy)
 	assert.Equal(t, "upgrade-controller-sa", job.Spec.Template.Spec.ServiceAccountName)
 
-	ttl := int32(0)
+	ttl := int32(300)
 	assert.Equal(t, &ttl, job.Spec.TTLSecondsAfterFinished)
 
 	job, err = ReleaseManifestInstallJob(ContainerImage{Version: "3.1.0"}, kubectl, serviceAccount, namespace, labels)